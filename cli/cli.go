@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"main/config"
+)
+
+// defaultConfigPath is the path of the config file used if no other path is given.
+const defaultConfigPath = "config.yml"
+
+// command is a subcommand of the CLI.
+type command struct {
+	// Usage is the synopsis of the arguments of the command.
+	usage string
+
+	// Summary is a single line describing the command.
+	summary string
+
+	// Run runs the command with the given flag set and arguments.
+	// The flag set holds the global flags, and the command registers its own flags before parsing the arguments.
+	run func(flags *flag.FlagSet, opts *options, args []string) error
+}
+
+// commands holds the subcommands of the CLI, where the key is the name of the command.
+var commands = map[string]*command{
+	"mine":            mineCommand,
+	"validate-config": validateConfigCommand,
+	"diff":            diffCommand,
+	"query":           queryCommand,
+	"explain":         explainCommand,
+	"stats":           statsCommand,
+	"export":          exportCommand,
+	"graph":           graphCommand,
+	"check-licenses":  checkLicensesCommand,
+}
+
+// defaultCommand is the command run if no command is given.
+const defaultCommand = "mine"
+
+// options holds the global flags shared by all commands.
+type options struct {
+	// The path of the config file.
+	configPath string
+
+	// The config values set by flags, formatted as "key=value".
+	overrides overrides
+
+	// The paths of the profiles.
+	profiler profiler
+}
+
+// register registers the global flags with the given flag set.
+func (o *options) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.configPath, "config", defaultConfigPath, "path of the config file")
+	flags.Var(&o.overrides, "set", "override a config value, e.g. --set reader.max_workers=4 (repeatable)")
+	flags.StringVar(&o.profiler.cpuProfile, "cpuprofile", "", "write a CPU profile to the given file")
+	flags.StringVar(&o.profiler.memProfile, "memprofile", "", "write a memory profile to the given file")
+	flags.StringVar(&o.profiler.trace, "trace", "", "write an execution trace to the given file")
+}
+
+// loadConfig reads the config file and applies the overrides of the environment and the flags, in this order.
+func (o *options) loadConfig() (*config.Config, error) {
+	c, err := config.NewConfig(o.configPath)
+	if err != nil {
+		return nil, exitError(ExitConfig, err)
+	}
+	return o.applyOverrides(c)
+}
+
+// loadConfigIfExists reads the config file like loadConfig, if it exists.
+// Otherwise, the overrides are applied to an empty config, e.g. without a fallback license.
+// It is used by the commands reading output files, which don't require a config.
+func (o *options) loadConfigIfExists() (*config.Config, error) {
+	if _, err := os.Stat(o.configPath); errors.Is(err, fs.ErrNotExist) {
+		return o.applyOverrides(&config.Config{})
+	}
+	return o.loadConfig()
+}
+
+// applyOverrides applies the overrides of the environment and the flags to the given config, in this order.
+func (o *options) applyOverrides(c *config.Config) (*config.Config, error) {
+	if err := c.ApplyEnv(os.LookupEnv); err != nil {
+		return nil, exitError(ExitConfig, err)
+	}
+	for _, o := range o.overrides {
+		key, value, _ := strings.Cut(o, "=")
+		if err := c.Set(key, value); err != nil {
+			return nil, exitError(ExitConfig, err)
+		}
+	}
+	return c, nil
+}
+
+// overrides is a repeatable flag holding config values formatted as "key=value".
+type overrides []string
+
+func (o *overrides) String() string {
+	return strings.Join(*o, ",")
+}
+
+func (o *overrides) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected key=value, got %s", value)
+	}
+	*o = append(*o, value)
+	return nil
+}
+
+// Run runs the CLI with the given arguments, excluding the program name, and returns the exit code.
+// The global flags may be given before or after the name of the command.
+func Run(args []string) int {
+	opts := &options{}
+	global := flag.NewFlagSet("brew-metadata-miner", flag.ContinueOnError)
+	global.SetOutput(io.Discard)
+	opts.register(global)
+	if err := global.Parse(args); errors.Is(err, flag.ErrHelp) {
+		printUsage(os.Stdout)
+		return ExitOK
+	} else if err != nil {
+		printUsage(os.Stderr)
+		fmt.Fprintln(os.Stderr, err)
+		return ExitUsage
+	}
+	args = global.Args()
+
+	name := defaultCommand
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage(os.Stdout)
+		return ExitOK
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage(os.Stderr)
+		fmt.Fprintf(os.Stderr, "unknown command %s\n", name)
+		return ExitUsage
+	}
+
+	// The command's flag set starts with the values of the global flags.
+	// The values are copied before registering resets them to their defaults.
+	values := make(map[string]string)
+	global.Visit(func(f *flag.Flag) {
+		if f.Name != "set" {
+			values[f.Name] = f.Value.String()
+		}
+	})
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	opts.register(flags)
+	for name, value := range values {
+		flags.Set(name, value)
+	}
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: brew-metadata-miner %s [flags] %s\n\n%s\n\nFlags:\n", name, cmd.usage, cmd.summary)
+		flags.PrintDefaults()
+	}
+
+	err := cmd.run(flags, opts, args)
+	if stopErr := opts.profiler.stop(); stopErr != nil {
+		fmt.Fprintln(os.Stderr, stopErr)
+	}
+
+	if err == nil {
+		return ExitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	fmt.Fprintln(os.Stderr, err)
+	return exitCode(err)
+}
+
+// parseFlags parses the flags of a command and checks the number of remaining arguments.
+// If max is negative, the number of arguments isn't limited.
+// Once the flags have been parsed, profiling is started if enabled.
+func parseFlags(flags *flag.FlagSet, opts *options, args []string, min, max int) error {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return exitError(ExitUsage, err)
+	}
+	if flags.NArg() < min || (max >= 0 && flags.NArg() > max) {
+		flags.Usage()
+		return exitError(ExitUsage, fmt.Errorf("invalid number of arguments"))
+	}
+	return opts.profiler.start()
+}
+
+// isFlagSet returns true if the flag of the given name has been set on the command line.
+func isFlagSet(flags *flag.FlagSet, name string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// printUsage prints the usage of the CLI to the given writer.
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "Usage: brew-metadata-miner [flags] <command> [flags] [arguments]\n\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-16s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(w, "\nThe command defaults to %s. Run a command with --help to list its flags.\n", defaultCommand)
+	fmt.Fprintf(w, "Any config key may be overridden by --set key=value or the environment variable %s<KEY>.\n", config.EnvPrefix)
+}
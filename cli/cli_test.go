@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeFiles writes the given files to the given directory.
+func writeFiles(dir string, files map[string]string) {
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// captureStdout returns what the given function writes to stdout.
+func captureStdout(f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		log.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	out := make(chan string)
+	go func() {
+		content, _ := io.ReadAll(r)
+		out <- string(content)
+	}()
+	f()
+	w.Close()
+	return <-out
+}
+
+// fooChecksum is the checksum of the archive of the formula foo of the core repository created by setup.
+const fooChecksum = "49a4418733c508c03ad79a29e95acec9a2fbc4c7306131d2a8f5ef32012e67e2"
+
+// setup creates a core repository and a config file using it and returns the path of the config file
+// and the output directory.
+func setup(t *testing.T) (string, string) {
+	dir := t.TempDir()
+	writeFiles(dir, map[string]string{
+		"repo/Formula/f/foo.rb": "class Foo < Formula\n  homepage \"https://example.com/foo\"\n  url \"https://example.com/foo-1.0.tar.gz\"\n  sha256 \"" + fooChecksum + "\"\n  license \"MIT\"\nend\n",
+		"repo/Formula/b/bar.rb": "class Bar < Formula\n  url \"https://example.com/bar-1.0.tar.gz\"\n  depends_on \"foo\" => :build\nend\n",
+		"config.yml": fmt.Sprintf(`output_dir: %s
+core_repo:
+  url: https://github.com/Homebrew/homebrew-core.git
+  branch: master
+  dir: %s
+  clone: false
+json: true
+reader:
+  max_workers: 2
+  fallback_license: pseudo
+`, filepath.Join(dir, "out"), filepath.Join(dir, "repo")),
+	})
+	return filepath.Join(dir, "config.yml"), filepath.Join(dir, "out")
+}
+
+func TestRun(t *testing.T) {
+	configPath, outputDir := setup(t)
+
+	assert.Equal(t, ExitOK, Run([]string{"help"}))
+	assert.Equal(t, ExitUsage, Run([]string{"unknown"}))
+	assert.Equal(t, ExitUsage, Run([]string{"explain", "--config", configPath}))
+
+	// No heap profile is written if the command fails to parse its arguments.
+	memProfile := filepath.Join(t.TempDir(), "mem.prof")
+	assert.Equal(t, ExitUsage, Run([]string{"stats", "--memprofile", memProfile, "--unknown"}))
+	assert.NoFileExists(t, memProfile)
+	assert.Equal(t, ExitOK, Run([]string{"validate-config", "--memprofile", memProfile, "--config", configPath}))
+	assert.FileExists(t, memProfile)
+	assert.Equal(t, ExitConfig, Run([]string{"validate-config", "--config", filepath.Join(outputDir, "missing.yml")}))
+	assert.Equal(t, ExitConfig, Run([]string{"--config", configPath, "validate-config", "--set", "reader.max_workers=0"}))
+	assert.Equal(t, ExitOK, Run([]string{"--config", configPath, "validate-config"}))
+	assert.Equal(t, ExitConfig, Run([]string{"--config", configPath, "validate-config",
+		"--set", "core_repo.clone=false", "--set", "core_repo.dir=" + filepath.Join(outputDir, "missing")}))
+
+	// Environment variables override the config file.
+	t.Setenv("BREW_MINER_READER_MAX_WORKERS", "-1")
+	assert.Equal(t, ExitConfig, Run([]string{"validate-config", "--config", configPath}))
+	t.Setenv("BREW_MINER_READER_MAX_WORKERS", "1")
+
+	// A dry run writes no files.
+	assert.Equal(t, ExitOK, Run([]string{"mine", "--config", configPath, "--dry-run"}))
+	entries, _ := os.ReadDir(outputDir)
+	assert.Empty(t, entries)
+
+	assert.Equal(t, ExitOK, Run([]string{"mine", "--config", configPath}))
+	tsv, _ := filepath.Glob(filepath.Join(outputDir, "deps-brew-*.tsv"))
+	json, _ := filepath.Glob(filepath.Join(outputDir, "deps-brew-*.json"))
+	if assert.Len(t, tsv, 1) && assert.Len(t, json, 1) {
+		assert.Equal(t, ExitOK, Run([]string{"query", tsv[0], "foo"}))
+		assert.Equal(t, ExitOK, Run([]string{"query", "--dependents", "--json", json[0], "foo"}))
+		assert.Equal(t, ExitFailure, Run([]string{"query", tsv[0], "baz"}))
+		assert.Equal(t, ExitOK, Run([]string{"stats", "--config", configPath, tsv[0]}))
+		assert.Equal(t, ExitOK, Run([]string{"stats", "--format", "markdown", "--top", "1", "--fallback-license", "pseudo", json[0]}))
+
+		// The fallback license defaults to the one of the config.
+		out := captureStdout(func() {
+			assert.Equal(t, ExitOK, Run([]string{"stats", "--json", "--config", configPath, tsv[0]}))
+		})
+		assert.Contains(t, out, `"fallback_licenses": 1,`)
+		out = captureStdout(func() {
+			assert.Equal(t, ExitOK, Run([]string{"stats", "--json", "--config", configPath, "--set", "reader.fallback_license=Apache-2.0", tsv[0]}))
+		})
+		assert.Contains(t, out, `"fallback_licenses": 0,`)
+
+		// Without a config file, no fallback license is assumed.
+		out = captureStdout(func() {
+			assert.Equal(t, ExitOK, Run([]string{"stats", "--json", tsv[0]}))
+		})
+		assert.Contains(t, out, `"fallback_licenses": 0,`)
+		assert.Equal(t, ExitOK, Run([]string{"stats", "--config", filepath.Join(outputDir, "missing.yml"), tsv[0]}))
+		assert.Equal(t, ExitUsage, Run([]string{"stats", "--format", "html", tsv[0]}))
+		assert.Equal(t, ExitOK, Run([]string{"diff", tsv[0], json[0]}))
+		assert.Equal(t, ExitParse, Run([]string{"stats", "--config", configPath, configPath}))
+		assert.Equal(t, ExitOK, Run([]string{"check-licenses", "--config", configPath, "--json", tsv[0]}))
+		// Without a config file, the default policy flags no fallback license.
+		assert.Equal(t, ExitOK, Run([]string{"check-licenses", "--json", tsv[0]}))
+		assert.Equal(t, ExitOK, Run([]string{"check-licenses", "--config", filepath.Join(outputDir, "missing.yml"), tsv[0]}))
+		assert.Equal(t, ExitConfig, Run([]string{"check-licenses", "--policy", configPath, tsv[0]}))
+		policyPath := filepath.Join(t.TempDir(), "policy.yml")
+		writeFiles(filepath.Dir(policyPath), map[string]string{"policy.yml": "rules:\n  - name: no-mit\n    deny: [MIT]\n"})
+		assert.Equal(t, ExitFailure, Run([]string{"check-licenses", "--policy", policyPath, tsv[0]}))
+	}
+
+	// The output directory is no longer empty.
+	assert.Equal(t, ExitConfig, Run([]string{"mine", "--config", configPath}))
+	assert.Equal(t, ExitOK, Run([]string{"mine", "--config", configPath, "--set", "output_policy=overwrite"}))
+	assert.Equal(t, ExitConfig, Run([]string{"mine", "--config", configPath, "--set", "output_policy=append"}))
+
+	assert.Equal(t, ExitOK, Run([]string{"explain", "--config", configPath, "bar"}))
+	assert.Equal(t, ExitParse, Run([]string{"explain", "--config", configPath, "baz"}))
+}
+
+func TestExport(t *testing.T) {
+	configPath, outputDir := setup(t)
+	assert.Equal(t, ExitOK, Run([]string{"mine", "--config", configPath}))
+	snapshots, _ := filepath.Glob(filepath.Join(outputDir, "deps-brew-*.json"))
+	if !assert.Len(t, snapshots, 1) {
+		return
+	}
+
+	exportPath := filepath.Join(t.TempDir(), "bom.json")
+	assert.Equal(t, ExitOK, Run([]string{"export", "--formula", "bar", "--output", exportPath, snapshots[0]}))
+	content, err := os.ReadFile(exportPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var bom struct {
+		BOMFormat    string `json:"bomFormat"`
+		SerialNumber string `json:"serialNumber"`
+		Metadata     struct {
+			Component struct {
+				Name string `json:"name"`
+			} `json:"component"`
+		} `json:"metadata"`
+		Components []struct {
+			Name     string           `json:"name"`
+			Scope    string           `json:"scope"`
+			Licenses []map[string]any `json:"licenses"`
+		} `json:"components"`
+		Dependencies []struct {
+			Ref       string   `json:"ref"`
+			DependsOn []string `json:"dependsOn"`
+		} `json:"dependencies"`
+	}
+	if err := json.Unmarshal(content, &bom); err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, "CycloneDX", bom.BOMFormat)
+	assert.Regexp(t, "^urn:uuid:[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", bom.SerialNumber)
+	assert.Equal(t, "bar", bom.Metadata.Component.Name)
+	if assert.Len(t, bom.Components, 1) {
+		assert.Equal(t, "foo", bom.Components[0].Name)
+		assert.Equal(t, "excluded", bom.Components[0].Scope)
+		assert.Equal(t, []map[string]any{{"expression": "MIT"}}, bom.Components[0].Licenses)
+	}
+	if assert.Len(t, bom.Dependencies, 2) {
+		assert.Equal(t, "pkg:brew/bar@1.0", bom.Dependencies[0].Ref)
+		assert.Equal(t, []string{"pkg:brew/foo@1.0"}, bom.Dependencies[0].DependsOn)
+	}
+
+	xmlPath := filepath.Join(t.TempDir(), "bom.xml")
+	assert.Equal(t, ExitOK, Run([]string{"export", "--format", "cyclonedx-xml", "--output", xmlPath, snapshots[0]}))
+	content, err = os.ReadFile(xmlPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Contains(t, string(content), `<bom xmlns="http://cyclonedx.org/schema/bom/1.5"`)
+	assert.Contains(t, string(content), `<dependency ref="pkg:brew/bar@1.0">`)
+
+	// Licenses which aren't on the SPDX License List are listed by their name, and the fallback license is omitted.
+	assert.Contains(t, string(content), "<name>pseudo</name>")
+	assert.NotContains(t, string(content), "<expression>pseudo</expression>")
+	assert.Equal(t, ExitOK, Run([]string{"export", "--config", configPath, "--format", "cyclonedx-xml", "--output", xmlPath, snapshots[0]}))
+	content, err = os.ReadFile(xmlPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.NotContains(t, string(content), "pseudo")
+	assert.Contains(t, string(content), "<expression>MIT</expression>")
+
+	spdxPath := filepath.Join(t.TempDir(), "bom.spdx")
+	assert.Equal(t, ExitOK, Run([]string{"export", "--format", "spdx-tag-value", "--formula", "bar", "--output", spdxPath, snapshots[0]}))
+	content, err = os.ReadFile(spdxPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Contains(t, string(content), "SPDXVersion: SPDX-2.3\n")
+	assert.Contains(t, string(content), "PackageName: foo\nSPDXID: SPDXRef-Package-foo\nPackageVersion: 1.0\nPackageDownloadLocation: https://example.com/foo-1.0.tar.gz\n")
+	assert.Contains(t, string(content), "PackageLicenseDeclared: MIT\n")
+	assert.Contains(t, string(content), "PackageName: bar\n")
+	assert.NotContains(t, string(content), "PackageLicenseDeclared: pseudo\n")
+	assert.Contains(t, string(content), "Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-Package-bar\n")
+	assert.Contains(t, string(content), "Relationship: SPDXRef-Package-foo BUILD_DEPENDENCY_OF SPDXRef-Package-bar\n")
+
+	spdxPath = filepath.Join(t.TempDir(), "bom.spdx.json")
+	assert.Equal(t, ExitOK, Run([]string{"export", "--format", "spdx-json", "--output", spdxPath, snapshots[0]}))
+	content, err = os.ReadFile(spdxPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var doc struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Packages    []struct {
+			Name            string `json:"name"`
+			LicenseDeclared string `json:"licenseDeclared"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, "SPDX-2.3", doc.SPDXVersion)
+	assert.Len(t, doc.Packages, 2)
+
+	// The fallback license of the config is no assertion.
+	assert.Equal(t, ExitOK, Run([]string{"export", "--config", configPath, "--format", "spdx-json", "--output", spdxPath, snapshots[0]}))
+	content, err = os.ReadFile(spdxPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		log.Fatal(err)
+	}
+	for _, pkg := range doc.Packages {
+		expected := map[string]string{"foo": "MIT", "bar": "NOASSERTION"}[pkg.Name]
+		assert.Equal(t, expected, pkg.LicenseDeclared, pkg.Name)
+	}
+
+	// The TSV output holds the versions, homepages and archives of the formulae as well.
+	tsv, _ := filepath.Glob(filepath.Join(outputDir, "deps-brew-*.tsv"))
+	if assert.Len(t, tsv, 1) {
+		spdxPath = filepath.Join(t.TempDir(), "bom.spdx")
+		assert.Equal(t, ExitOK, Run([]string{"export", "--format", "spdx-tag-value", "--formula", "bar", "--output", spdxPath, tsv[0]}))
+		content, err = os.ReadFile(spdxPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		assert.Contains(t, string(content), "PackageName: foo\nSPDXID: SPDXRef-Package-foo\nPackageVersion: 1.0\nPackageDownloadLocation: https://example.com/foo-1.0.tar.gz\n")
+		assert.Contains(t, string(content), "PackageChecksum: SHA256: "+fooChecksum+"\n")
+		assert.Contains(t, string(content), "PackageHomePage: https://example.com/foo\n")
+	}
+
+	assert.Equal(t, ExitUsage, Run([]string{"export", "--format", "csv", snapshots[0]}))
+	assert.Equal(t, ExitFailure, Run([]string{"export", "--formula", "baz", snapshots[0]}))
+}
+
+func TestGraph(t *testing.T) {
+	configPath, outputDir := setup(t)
+	writeFiles(filepath.Dir(configPath), map[string]string{
+		"repo/Formula/q/qux.rb": "class Qux < Formula\n  url \"https://example.com/qux-1.0.tar.gz\"\n  conflicts_with \"foo\", because: \"both install a foo binary\"\nend\n",
+	})
+	assert.Equal(t, ExitOK, Run([]string{"mine", "--config", configPath}))
+	snapshots, _ := filepath.Glob(filepath.Join(outputDir, "deps-brew-*.tsv"))
+	if !assert.Len(t, snapshots, 1) {
+		return
+	}
+
+	dir := t.TempDir()
+	dotPath := filepath.Join(dir, "graph.dot")
+	assert.Equal(t, ExitOK, Run([]string{"graph", "--format", "dot", "--output", dotPath, snapshots[0]}))
+	content, err := os.ReadFile(dotPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, `digraph brew {
+  "bar" [license="pseudo", repo_host="", system_requirements=""];
+  "foo" [license="MIT", repo_host="", system_requirements=""];
+  "qux" [license="pseudo", repo_host="", system_requirements=""];
+  "bar" -> "foo" [relation="DEPENDS_ON", type="build", restriction=""];
+  "qux" -> "foo" [relation="CONFLICTS_WITH", reason="both install a foo binary", style=dashed];
+}
+`, string(content))
+
+	graphMLPath := filepath.Join(dir, "graph.graphml")
+	assert.Equal(t, ExitOK, Run([]string{"graph", "--formula", "foo", "--depth", "1", "--output", graphMLPath, snapshots[0]}))
+	content, err = os.ReadFile(graphMLPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Contains(t, string(content), `<edge id="e0" source="bar" target="foo">`)
+	assert.NotContains(t, string(content), `source="qux"`)
+
+	graphMLPath = filepath.Join(dir, "conflicts.graphml")
+	assert.Equal(t, ExitOK, Run([]string{"graph", "--formula", "foo", "--formula", "qux", "--depth", "1", "--output", graphMLPath, snapshots[0]}))
+	content, err = os.ReadFile(graphMLPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Contains(t, string(content), `<edge id="e1" source="qux" target="foo">
+      <data key="relation">CONFLICTS_WITH</data>
+      <data key="reason">both install a foo binary</data>
+    </edge>`)
+
+	neo4jDir := filepath.Join(dir, "neo4j")
+	assert.Equal(t, ExitOK, Run([]string{"graph", "--format", "neo4j", "--formula", "bar", "--output", neo4jDir, snapshots[0]}))
+	nodes, _ := os.ReadFile(filepath.Join(neo4jDir, "nodes.csv"))
+	relationships, _ := os.ReadFile(filepath.Join(neo4jDir, "relationships.csv"))
+	assert.Equal(t, "name:ID(Formula),license,repo_host,system_requirements,:LABEL\nbar,pseudo,,,Formula\nfoo,MIT,,,Formula\n", string(nodes))
+	assert.Equal(t, ":START_ID(Formula),:END_ID(Formula),:TYPE,type,restriction,reason\nbar,foo,DEPENDS_ON,build,,\n", string(relationships))
+
+	assert.Equal(t, ExitOK, Run([]string{"graph", "--format", "neo4j", "--formula", "qux", "--formula", "foo", "--output", neo4jDir, snapshots[0]}))
+	relationships, _ = os.ReadFile(filepath.Join(neo4jDir, "relationships.csv"))
+	assert.Equal(t, ":START_ID(Formula),:END_ID(Formula),:TYPE,type,restriction,reason\nbar,foo,DEPENDS_ON,build,,\nqux,foo,CONFLICTS_WITH,,,both install a foo binary\n", string(relationships))
+
+	assert.Equal(t, ExitUsage, Run([]string{"graph", "--format", "neo4j", snapshots[0]}))
+	assert.Equal(t, ExitUsage, Run([]string{"graph", "--format", "gexf", snapshots[0]}))
+	assert.Equal(t, ExitFailure, Run([]string{"graph", "--formula", "baz", snapshots[0]}))
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"main/config"
+	"main/miner"
+	"main/miner/diff"
+	"main/miner/reader"
+	"main/miner/snapshot"
+	"main/miner/types"
+)
+
+var diffCommand = &command{
+	usage:   "<old> <new>",
+	summary: "compare two output files, or two revisions of the core repository",
+	run:     runDiff,
+}
+
+// runDiff compares two output files, or two revisions of the core repository if the commits flag is set.
+func runDiff(flags *flag.FlagSet, opts *options, args []string) error {
+	asJSON := flags.Bool("json", false, "print the differences as JSON")
+	commits := flags.Bool("commits", false, "compare two revisions of the core repository instead of two output files")
+	if err := parseFlags(flags, opts, args, 2, 2); err != nil {
+		return err
+	}
+
+	load := func(path string) (map[string]*types.Formula, error) {
+		formulae, err := snapshot.Load(path)
+		if err != nil {
+			return nil, exitError(ExitParse, err)
+		}
+		return formulae, nil
+	}
+	if *commits {
+		config, err := opts.loadConfig()
+		if err != nil {
+			return err
+		}
+		load = func(revision string) (map[string]*types.Formula, error) {
+			return mineRevision(config, revision)
+		}
+	}
+
+	old, err := load(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := load(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	report := diff.Compare(old, new)
+	if *asJSON {
+		return report.WriteJSON(os.Stdout)
+	}
+	return report.WriteText(os.Stdout)
+}
+
+// mineRevision reads all formulae from the core repository of the given config as of the given revision.
+func mineRevision(config *config.Config, revision string) (map[string]*types.Formula, error) {
+	source, err := reader.NewGitSource(config.CoreRepo.Dir, revision)
+	if err != nil {
+		return nil, exitError(ExitRepo, err)
+	}
+
+	m := miner.New(
+		miner.WithSource(source),
+		miner.WithMaxWorkers(config.Reader.MaxWorkers),
+		miner.WithParseTimeout(config.Reader.ParseTimeout),
+		miner.WithFallbackLicense(config.Reader.FallbackLicense),
+		miner.WithDeriveRepo(config.Reader.DeriveRepo),
+		miner.WithLegacyParser(config.Reader.LegacyParser),
+	)
+	result, err := m.Mine(context.Background())
+	if err != nil {
+		return nil, exitError(ExitParse, err)
+	}
+	return result.Formulae(), nil
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"errors"
+)
+
+// The exit codes of the CLI per class of failure.
+const (
+	// ExitOK is returned if the command succeeded.
+	ExitOK = 0
+
+	// ExitFailure is returned for failures which aren't classified otherwise.
+	ExitFailure = 1
+
+	// ExitUsage is returned if the command or its flags and arguments are invalid.
+	ExitUsage = 2
+
+	// ExitConfig is returned if the config file can't be read or is invalid.
+	ExitConfig = 3
+
+	// ExitRepo is returned if the core repository can't be cloned or opened.
+	ExitRepo = 4
+
+	// ExitParse is returned if the formulae can't be read.
+	ExitParse = 5
+
+	// ExitWrite is returned if the output files can't be written.
+	ExitWrite = 6
+
+	// ExitInterrupted is returned if mining has been interrupted by SIGINT or SIGTERM.
+	ExitInterrupted = 130
+)
+
+// exitErr is an error with the exit code of its failure class.
+type exitErr struct {
+	code int
+	err  error
+}
+
+func (e *exitErr) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitErr) Unwrap() error {
+	return e.err
+}
+
+// exitError returns the given error classified by the given exit code.
+func exitError(code int, err error) error {
+	return &exitErr{code: code, err: err}
+}
+
+// exitCode returns the exit code of the given error.
+// Errors which haven't been classified result in ExitFailure.
+func exitCode(err error) int {
+	var e *exitErr
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return ExitFailure
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"flag"
+	"os"
+
+	"main/miner"
+)
+
+var explainCommand = &command{
+	usage:   "<formula>",
+	summary: "print the values extracted from a formula next to their source lines",
+	run:     runExplain,
+}
+
+// runExplain explains a single formula of the existing core repository.
+// Neither the output directory is used nor the core repository is cloned.
+func runExplain(flags *flag.FlagSet, opts *options, args []string) error {
+	if err := parseFlags(flags, opts, args, 1, 1); err != nil {
+		return err
+	}
+
+	config, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := miner.NewMiner(config).ExplainFormula(flags.Arg(0), os.Stdout); err != nil {
+		return exitError(ExitParse, err)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"main/miner"
+	"main/miner/snapshot"
+	"main/miner/types"
+	"main/miner/writer"
+)
+
+var exportCommand = &command{
+	usage:   "<output-file>",
+	summary: "export the formulae of an output file as a software bill of materials",
+	run:     runExport,
+}
+
+// exportFormats holds the functions writing the formulae in each export format, where the key is the name of the format.
+var exportFormats = map[string]func(w io.Writer, formulae map[string]*types.Formula, opts *writer.BOMOptions) error{
+	"cyclonedx-json": func(w io.Writer, formulae map[string]*types.Formula, opts *writer.BOMOptions) error {
+		bom, err := writer.NewCycloneDX(formulae, opts)
+		if err != nil {
+			return err
+		}
+		return bom.WriteJSON(w)
+	},
+	"cyclonedx-xml": func(w io.Writer, formulae map[string]*types.Formula, opts *writer.BOMOptions) error {
+		bom, err := writer.NewCycloneDX(formulae, opts)
+		if err != nil {
+			return err
+		}
+		return bom.WriteXML(w)
+	},
+	"spdx-json": func(w io.Writer, formulae map[string]*types.Formula, opts *writer.BOMOptions) error {
+		doc, err := writer.NewSPDX(formulae, opts)
+		if err != nil {
+			return err
+		}
+		return doc.WriteJSON(w)
+	},
+	"spdx-tag-value": func(w io.Writer, formulae map[string]*types.Formula, opts *writer.BOMOptions) error {
+		doc, err := writer.NewSPDX(formulae, opts)
+		if err != nil {
+			return err
+		}
+		return doc.WriteTagValue(w)
+	},
+}
+
+// runExport exports the formulae of an output file, or the transitive dependencies of a single formula,
+// in one of the export formats.
+func runExport(flags *flag.FlagSet, opts *options, args []string) error {
+	format := flags.String("format", "cyclonedx-json", "export format, cyclonedx-json, cyclonedx-xml, spdx-json or spdx-tag-value")
+	root := flags.String("formula", "", "export the formula and its transitive dependencies only")
+	output := flags.String("output", "", "write the export to the given file instead of stdout")
+	if err := parseFlags(flags, opts, args, 1, 1); err != nil {
+		return err
+	}
+
+	export, ok := exportFormats[*format]
+	if !ok {
+		return exitError(ExitUsage, fmt.Errorf("unknown export format %s", *format))
+	}
+
+	formulae, err := snapshot.Load(flags.Arg(0))
+	if err != nil {
+		return exitError(ExitParse, err)
+	}
+	if _, ok := formulae[*root]; *root != "" && !ok {
+		return fmt.Errorf("formula %s not found in %s", *root, flags.Arg(0))
+	}
+
+	// The fallback license the formulae have been mined with is omitted, if a config file exists.
+	config, err := opts.loadConfigIfExists()
+	if err != nil {
+		return err
+	}
+
+	serial, err := newUUID()
+	if err != nil {
+		return err
+	}
+	bomOpts := &writer.BOMOptions{
+		Root:            *root,
+		SerialNumber:    "urn:uuid:" + serial,
+		Timestamp:       time.Now(),
+		ToolVersion:     miner.ToolVersion(),
+		FallbackLicense: config.Reader.FallbackLicense,
+	}
+
+	if *output == "" {
+		return export(os.Stdout, formulae, bomOpts)
+	}
+	return writeFile(*output, func(w io.Writer) error {
+		return export(w, formulae, bomOpts)
+	})
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
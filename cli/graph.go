@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"main/miner/snapshot"
+	"main/miner/writer"
+)
+
+// Names of the files written by the neo4j format of the graph command.
+const (
+	neo4jNodesFileName         = "nodes.csv"
+	neo4jRelationshipsFileName = "relationships.csv"
+)
+
+var graphCommand = &command{
+	usage:   "<output-file>",
+	summary: "export the dependency graph of an output file as GraphML, DOT or Neo4j CSV",
+	run:     runGraph,
+}
+
+// formulaNames is a repeatable flag holding names of formulae.
+type formulaNames []string
+
+func (n *formulaNames) String() string {
+	return strings.Join(*n, ",")
+}
+
+func (n *formulaNames) Set(value string) error {
+	*n = append(*n, value)
+	return nil
+}
+
+// runGraph exports the dependency graph of the formulae of an output file, or the subgraph around the given formulae.
+func runGraph(flags *flag.FlagSet, opts *options, args []string) error {
+	format := flags.String("format", "graphml", "graph format, graphml, dot or neo4j")
+	var roots formulaNames
+	flags.Var(&roots, "formula", "restrict the graph to the formula and its dependencies and dependents (repeatable)")
+	depth := flags.Int("depth", 0, "maximum number of edges between a formula given by --formula and the other formulae, unlimited if 0")
+	output := flags.String("output", "", "write the graph to the given file instead of stdout, or to the given directory for neo4j")
+	if err := parseFlags(flags, opts, args, 1, 1); err != nil {
+		return err
+	}
+
+	switch *format {
+	case "graphml", "dot":
+	case "neo4j":
+		if *output == "" {
+			return exitError(ExitUsage, fmt.Errorf("the neo4j format requires an output directory"))
+		}
+	default:
+		return exitError(ExitUsage, fmt.Errorf("unknown graph format %s", *format))
+	}
+
+	formulae, err := snapshot.Load(flags.Arg(0))
+	if err != nil {
+		return exitError(ExitParse, err)
+	}
+	g, err := writer.NewGraph(formulae, &writer.GraphOptions{Roots: roots, Depth: *depth})
+	if err != nil {
+		return fmt.Errorf("%w in %s", err, flags.Arg(0))
+	}
+
+	if *format == "neo4j" {
+		if err := os.MkdirAll(*output, 0755); err != nil {
+			return exitError(ExitWrite, err)
+		}
+		return writeFile(filepath.Join(*output, neo4jNodesFileName), func(nodes io.Writer) error {
+			return writeFile(filepath.Join(*output, neo4jRelationshipsFileName), func(relationships io.Writer) error {
+				return g.WriteNeo4j(nodes, relationships)
+			})
+		})
+	}
+
+	write := g.WriteGraphML
+	if *format == "dot" {
+		write = g.WriteDOT
+	}
+	if *output == "" {
+		return write(os.Stdout)
+	}
+	return writeFile(*output, write)
+}
+
+// writeFile creates the file at the given path and writes it by the given function.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return exitError(ExitWrite, err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return exitError(ExitWrite, err)
+	}
+	if err := f.Close(); err != nil {
+		return exitError(ExitWrite, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"main/miner/compat"
+	"main/miner/snapshot"
+)
+
+var checkLicensesCommand = &command{
+	usage:   "<output-file>",
+	summary: "check the licenses of the dependencies of an output file against a license policy",
+	run:     runCheckLicenses,
+}
+
+// runCheckLicenses checks the licenses of the formulae of an output file and writes the findings.
+// It fails if any finding has the error severity.
+func runCheckLicenses(flags *flag.FlagSet, opts *options, args []string) error {
+	policyPath := flags.String("policy", "", "path of the license policy, the default policy flagging the fallback license of the config, if any, is used if empty")
+	asJSON := flags.Bool("json", false, "write the findings as JSON")
+	output := flags.String("output", "", "write the findings to the given file instead of stdout")
+	if err := parseFlags(flags, opts, args, 1, 1); err != nil {
+		return err
+	}
+
+	var policy *compat.Policy
+	if *policyPath == "" {
+		// The default policy flags the fallback license the formulae have been mined with, if a config file exists.
+		config, err := opts.loadConfigIfExists()
+		if err != nil {
+			return err
+		}
+		policy = compat.DefaultPolicy(config.Reader.FallbackLicense)
+	} else {
+		var err error
+		if policy, err = compat.LoadPolicy(*policyPath); err != nil {
+			return exitError(ExitConfig, err)
+		}
+	}
+
+	formulae, err := snapshot.Load(flags.Arg(0))
+	if err != nil {
+		return exitError(ExitParse, err)
+	}
+
+	report := compat.Check(formulae, policy)
+	write := report.WriteText
+	if *asJSON {
+		write = report.WriteJSON
+	}
+	if *output == "" {
+		err = write(os.Stdout)
+	} else {
+		err = writeFile(*output, write)
+	}
+	if err != nil {
+		return err
+	}
+
+	if report.Errors > 0 {
+		return fmt.Errorf("%d license findings of severity error", report.Errors)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"main/config"
+	"main/miner"
+	"main/miner/repo"
+)
+
+var mineCommand = &command{
+	usage:   "",
+	summary: "clone the core repository, read all formulae and write the output files",
+	run:     runMine,
+}
+
+// runMine clones the core repository if configured, reads all formulae and writes them to the output files.
+// On SIGINT or SIGTERM, the formulae read so far are written to partial output files.
+func runMine(flags *flag.FlagSet, opts *options, args []string) error {
+	dryRun := flags.Bool("dry-run", false, "validate the config and read the formulae of an existing core repository without cloning it or writing any files")
+	if err := parseFlags(flags, opts, args, 0, 0); err != nil {
+		return err
+	}
+
+	config, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Successfully parsed the configuration file:")
+	config.Print()
+
+	if err := config.Validate(); err != nil {
+		return exitError(ExitConfig, err)
+	}
+
+	fmt.Println("Successfully validated the configuration")
+
+	if *dryRun {
+		return dryRunMine(config)
+	}
+
+	// Stop reading the formulae on SIGINT or SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	commit, err := repo.Sync(ctx, config.CoreRepo, os.Stdout)
+	if err != nil {
+		return exitError(ExitRepo, err)
+	}
+	if commit != "" {
+		fmt.Printf("Successfully synchronized the core repository at commit %s\n", commit)
+	}
+
+	// The formulae are written to the output files as soon as they have been read.
+	m := miner.NewMiner(config, miner.WithCoreRepoCommit(commit))
+	err = m.Run(ctx)
+	fmt.Printf("Peak heap memory: %.1f MiB\n", float64(m.PeakMemory())/(1<<20))
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		fmt.Println("Interrupted, successfully piped the partially parsed formulae to the output file")
+		return exitError(ExitInterrupted, err)
+	case errors.Is(err, miner.ErrWrite):
+		return exitError(ExitWrite, err)
+	case err != nil:
+		return exitError(ExitParse, err)
+	}
+
+	fmt.Println("Successfully piped all formulae from the core repository to the output file")
+	fmt.Printf("Output written to %s\n\n", m.OutputDir())
+	return m.Summary().WriteText(os.Stdout)
+}
+
+// dryRunMine reads the formulae of the core repository without writing any files.
+// If the core repository would be cloned, updated or checked out, it only reports the repository to synchronize.
+func dryRunMine(config *config.Config) error {
+	if config.CoreRepo.Clone || config.CoreRepo.Revision != "" {
+		revision := config.CoreRepo.Revision
+		if revision == "" {
+			revision = "branch " + config.CoreRepo.Branch
+		}
+		fmt.Printf("Dry run: would synchronize %s (%s) into %s\n", config.CoreRepo.URL, revision, config.CoreRepo.Dir)
+		return nil
+	}
+
+	result, err := miner.NewMiner(config).Mine(context.Background())
+	if err != nil {
+		return exitError(ExitParse, err)
+	}
+	fmt.Printf("Dry run: successfully parsed %d formulae, no files written to %s\n", result.Len(), config.OutputDir)
+	return nil
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// profiler writes the CPU profile, heap profile and execution trace of the miner.
+// Each output is only written if the path of its file is set.
+type profiler struct {
+	cpuProfile string
+	memProfile string
+	trace      string
+
+	// The files written until the profiler is stopped.
+	cpuFile   *os.File
+	traceFile *os.File
+
+	// A boolean flag indicating whether the profiler has been started, i.e. the command has parsed its arguments.
+	started bool
+}
+
+// start starts the CPU profile and the execution trace.
+func (p *profiler) start() error {
+	if p.cpuProfile != "" {
+		f, err := os.Create(p.cpuProfile)
+		if err != nil {
+			return fmt.Errorf("error creating CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return fmt.Errorf("error starting CPU profile: %w", err)
+		}
+		p.cpuFile = f
+	}
+
+	if p.trace != "" {
+		f, err := os.Create(p.trace)
+		if err != nil {
+			return fmt.Errorf("error creating trace: %w", err)
+		}
+		if err := trace.Start(f); err != nil {
+			f.Close()
+			return fmt.Errorf("error starting trace: %w", err)
+		}
+		p.traceFile = f
+	}
+
+	p.started = true
+	return nil
+}
+
+// stop stops the CPU profile and the execution trace and writes the heap profile.
+// The heap profile is only written if the profiler has been started, thus no profile is written for a command
+// failing to parse its arguments. It is safe to call stop multiple times.
+func (p *profiler) stop() error {
+	if p.cpuFile != nil {
+		pprof.StopCPUProfile()
+		if err := p.cpuFile.Close(); err != nil {
+			return err
+		}
+		p.cpuFile = nil
+	}
+
+	if p.traceFile != nil {
+		trace.Stop()
+		if err := p.traceFile.Close(); err != nil {
+			return err
+		}
+		p.traceFile = nil
+	}
+
+	if p.started && p.memProfile != "" {
+		f, err := os.Create(p.memProfile)
+		if err != nil {
+			return fmt.Errorf("error creating memory profile: %w", err)
+		}
+		defer f.Close()
+
+		// Get up-to-date statistics of the heap.
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return fmt.Errorf("error writing memory profile: %w", err)
+		}
+		p.started = false
+	}
+
+	return nil
+}
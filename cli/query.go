@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"main/miner/snapshot"
+	"main/miner/types"
+)
+
+var queryCommand = &command{
+	usage:   "<output-file> <formula>",
+	summary: "print a formula, or the formulae depending on it, from an output file",
+	run:     runQuery,
+}
+
+// dependent is a formula depending on the queried formula.
+type dependent struct {
+	Name        string   `json:"name"`
+	DepType     []string `json:"types"`
+	Restriction string   `json:"restriction"`
+}
+
+// runQuery prints a formula of an output file, or the formulae depending on it if the dependents flag is set.
+func runQuery(flags *flag.FlagSet, opts *options, args []string) error {
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	dependents := flags.Bool("dependents", false, "print the formulae depending on the formula instead of the formula itself")
+	if err := parseFlags(flags, opts, args, 2, 2); err != nil {
+		return err
+	}
+
+	formulae, err := snapshot.Load(flags.Arg(0))
+	if err != nil {
+		return exitError(ExitParse, err)
+	}
+
+	name := flags.Arg(1)
+	formula, ok := formulae[name]
+	if !ok {
+		return fmt.Errorf("formula %s not found in %s", name, flags.Arg(0))
+	}
+
+	if *dependents {
+		deps := findDependents(formulae, name)
+		if *asJSON {
+			return printJSON(deps)
+		}
+		for _, d := range deps {
+			fmt.Println((&types.Dependency{Name: d.Name, DepType: d.DepType, Restriction: d.Restriction}).String())
+		}
+		return nil
+	}
+
+	if *asJSON {
+		return printJSON(formula)
+	}
+	fmt.Print(formula)
+	return nil
+}
+
+// findDependents returns the formulae depending on the formula with the given name, sorted by their name.
+func findDependents(formulae map[string]*types.Formula, name string) []*dependent {
+	deps := make([]*dependent, 0)
+	for _, f := range formulae {
+		for _, dep := range f.Dependencies {
+			if dep.Name == name {
+				deps = append(deps, &dependent{Name: f.Name, DepType: dep.DepType, Restriction: dep.Restriction})
+			}
+		}
+	}
+	sort.SliceStable(deps, func(i, j int) bool {
+		return deps[i].Name < deps[j].Name
+	})
+	return deps
+}
+
+// printJSON prints the given value as indented JSON.
+func printJSON(v any) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"main/miner/snapshot"
+	"main/miner/stats"
+)
+
+var statsCommand = &command{
+	usage:   "<output-file>",
+	summary: "print summary statistics of an output file",
+	run:     runStats,
+}
+
+// summaryFormats holds the functions writing a summary in each output format, where the key is the name of the format.
+var summaryFormats = map[string]func(s *stats.Summary, w io.Writer) error{
+	"text":     (*stats.Summary).WriteText,
+	"json":     (*stats.Summary).WriteJSON,
+	"markdown": (*stats.Summary).WriteMarkdown,
+}
+
+// runStats prints summary statistics of the formulae of an output file.
+// Unless given by a flag, the fallback license is the one of the config the formulae have been mined with.
+// Without a config file, no fallback license is assumed.
+func runStats(flags *flag.FlagSet, opts *options, args []string) error {
+	format := flags.String("format", "text", "output format, text, json or markdown")
+	asJSON := flags.Bool("json", false, "print the statistics as JSON, same as --format json")
+	fallbackLicense := flags.String("fallback-license", "", "the fallback license of the mining run, whose formulae are counted, reader.fallback_license of the config, if any, if not given")
+	top := flags.Int("top", stats.DefaultTop, "number of most depended-upon formulae to list")
+	if err := parseFlags(flags, opts, args, 1, 1); err != nil {
+		return err
+	}
+	if *asJSON {
+		*format = "json"
+	}
+	write, ok := summaryFormats[*format]
+	if !ok {
+		return exitError(ExitUsage, fmt.Errorf("unknown output format %s", *format))
+	}
+	if !isFlagSet(flags, "fallback-license") {
+		config, err := opts.loadConfigIfExists()
+		if err != nil {
+			return err
+		}
+		*fallbackLicense = config.Reader.FallbackLicense
+	}
+
+	formulae, err := snapshot.Load(flags.Arg(0))
+	if err != nil {
+		return exitError(ExitParse, err)
+	}
+
+	return write(stats.Compute(formulae, *fallbackLicense, *top), os.Stdout)
+}
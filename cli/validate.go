@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+)
+
+var validateConfigCommand = &command{
+	usage:   "",
+	summary: "validate the config file, including the overrides of the environment and flags",
+	run:     runValidateConfig,
+}
+
+// runValidateConfig prints and validates the config.
+func runValidateConfig(flags *flag.FlagSet, opts *options, args []string) error {
+	if err := parseFlags(flags, opts, args, 0, 0); err != nil {
+		return err
+	}
+
+	config, err := opts.loadConfig()
+	if err != nil {
+		return err
+	}
+	config.Print()
+
+	if err := config.Validate(); err != nil {
+		return exitError(ExitConfig, err)
+	}
+	fmt.Println("Successfully validated the configuration")
+	return nil
+}
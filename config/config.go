@@ -1,9 +1,12 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,21 +16,60 @@ type Config struct {
 	// The directory where the extracted meta data will be stored.
 	OutputDir string `yaml:"output_dir"`
 
-	CoreRepo struct {
-		// The URL of the core repository.
-		URL string `yaml:"url"`
+	// The policy applied to the output directory, one of the OutputPolicy constants.
+	// The fail policy is used if no policy is specified.
+	OutputPolicy string `yaml:"output_policy"`
 
-		// The branch of the core repository.
-		Branch string `yaml:"branch"`
+	// The number of run directories kept by the versioned policy, where the oldest ones are removed.
+	// All run directories are kept if the number is zero.
+	KeepRuns int `yaml:"keep_runs"`
 
-		// The path to the core repository.
-		Dir string `yaml:"dir"`
-
-		// A boolean value indicating whether the core repository should be cloned or not.
-		Clone bool `yaml:"clone"`
-	} `yaml:"core_repo"`
+	CoreRepo CoreRepoConfig `yaml:"core_repo"`
 
 	Reader ReaderConfig `yaml:"reader"`
+
+	// A boolean flag indicating whether the formulae should additionally be written as JSON.
+	JSON bool `yaml:"json"`
+
+	// A boolean flag indicating whether the provenance of the extracted values should be written to a separate output file.
+	Provenance bool `yaml:"provenance"`
+
+	// A boolean flag indicating whether a report of the formula constructs unknown to the parser should be written.
+	Coverage bool `yaml:"coverage"`
+}
+
+const (
+	// OutputPolicyFail refuses to run unless the output directory is empty.
+	OutputPolicyFail = "fail"
+
+	// OutputPolicyOverwrite writes into the output directory, replacing output files of the same name.
+	OutputPolicyOverwrite = "overwrite"
+
+	// OutputPolicyVersioned writes each run into a new timestamped sub-directory of the output directory.
+	OutputPolicyVersioned = "versioned"
+)
+
+type CoreRepoConfig struct {
+	// The URL of the core repository.
+	URL string `yaml:"url"`
+
+	// The branch of the core repository.
+	Branch string `yaml:"branch"`
+
+	// The path to the core repository.
+	Dir string `yaml:"dir"`
+
+	// A boolean value indicating whether the core repository should be cloned, or updated if it has already been cloned.
+	Clone bool `yaml:"clone"`
+
+	// The number of commits fetched from the tip of the branch. The full history is fetched if zero.
+	Depth int `yaml:"depth"`
+
+	// A boolean value indicating whether only the branch should be fetched instead of all branches.
+	SingleBranch bool `yaml:"single_branch"`
+
+	// The commit SHA or tag checked out instead of the tip of the branch, if any.
+	Revision string `yaml:"revision"`
 }
 
 type ReaderConfig struct {
@@ -39,15 +81,34 @@ type ReaderConfig struct {
 
 	// The license to use when no license is specified.
 	FallbackLicense string `yaml:"fallback_license"`
+
+	// The path of a YAML file overriding the default categories of licenses, if any.
+	LicenseCategories string `yaml:"license_categories"`
+
+	// The maximum duration of parsing a single formula file, e.g. "30s".
+	// Parsing isn't limited if the timeout is zero.
+	ParseTimeout time.Duration `yaml:"parse_timeout"`
+
+	// A boolean flag indicating whether the fields should be matched line by line using regular expressions
+	// instead of being extracted from the syntax tree of the formula files.
+	LegacyParser bool `yaml:"legacy_parser"`
 }
 
 // Print prints the configuration to the console.
 func (c *Config) Print() {
 	fmt.Printf("OutputDir: %s\n", c.OutputDir)
+	fmt.Printf("OutputPolicy: %s\n", c.Policy())
+	fmt.Printf("KeepRuns: %d\n", c.KeepRuns)
 	fmt.Printf("CoreRepo.URL: %s\n", c.CoreRepo.URL)
 	fmt.Printf("CoreRepo.Branch: %s\n", c.CoreRepo.Branch)
 	fmt.Printf("CoreRepo.Dir: %s\n", c.CoreRepo.Dir)
 	fmt.Printf("CoreRepo.Clone: %t\n", c.CoreRepo.Clone)
+	fmt.Printf("CoreRepo.Depth: %d\n", c.CoreRepo.Depth)
+	fmt.Printf("CoreRepo.SingleBranch: %t\n", c.CoreRepo.SingleBranch)
+	fmt.Printf("CoreRepo.Revision: %s\n", c.CoreRepo.Revision)
+	fmt.Printf("JSON: %t\n", c.JSON)
+	fmt.Printf("Provenance: %t\n", c.Provenance)
+	fmt.Printf("Coverage: %t\n", c.Coverage)
 }
 
 // Validate validates the configuration and creates directories if needed.
@@ -59,21 +120,35 @@ func (c *Config) Validate() error {
 
 	// check if the output directory exists
 	s, err := os.Stat(c.OutputDir)
-	if err != nil && os.IsNotExist(err) {
+	if os.IsNotExist(err) {
 		// create the output directory
 		err = os.MkdirAll(c.OutputDir, 0755)
 		if err != nil {
 			return err
 		}
+	} else if err != nil {
+		return err
 	} else if !s.IsDir() {
 		return ErrNotADirectory(c.OutputDir)
 	}
 
-	// verify the output directory is empty
-	if empty, err := isEmpty(c.OutputDir); err != nil {
-		return err
-	} else if !empty {
-		return ErrDirectoryNotEmpty(c.OutputDir)
+	// verify the output policy is valid
+	switch c.Policy() {
+	case OutputPolicyFail:
+		// verify the output directory is empty
+		if empty, err := isEmpty(c.OutputDir); err != nil {
+			return err
+		} else if !empty {
+			return ErrDirectoryNotEmpty(c.OutputDir)
+		}
+	case OutputPolicyOverwrite, OutputPolicyVersioned:
+	default:
+		return ErrInvalidOutputPolicy(c.OutputPolicy)
+	}
+
+	// verify the number of kept runs is valid
+	if c.KeepRuns < 0 {
+		return ErrInvalidKeepRuns
 	}
 
 	// verify the repository directory is not empty
@@ -83,12 +158,14 @@ func (c *Config) Validate() error {
 
 	// check if the core repository directory exists
 	s, err = os.Stat(c.CoreRepo.Dir)
-	if err != nil && os.IsNotExist(err) && c.CoreRepo.Clone {
+	if os.IsNotExist(err) && c.CoreRepo.Clone {
 		// create the core repository directory
 		err = os.MkdirAll(c.CoreRepo.Dir, 0755)
 		if err != nil {
 			return err
 		}
+	} else if err != nil {
+		return err
 	} else if !s.IsDir() {
 		return ErrNotADirectory(c.CoreRepo.Dir)
 	}
@@ -112,14 +189,49 @@ func (c *Config) Validate() error {
 		return ErrEmptyCoreRepoBranch
 	}
 
+	// verify the clone depth is valid
+	if c.CoreRepo.Depth < 0 {
+		return ErrInvalidDepth
+	}
+
 	// verify the number of workers is valid
 	if c.Reader.MaxWorkers <= 0 {
 		return ErrInvalidMaxWorkers
 	}
 
+	// verify the parse timeout is valid
+	if c.Reader.ParseTimeout < 0 {
+		return ErrInvalidParseTimeout
+	}
+
+	// check if the license categories file exists
+	if c.Reader.LicenseCategories != "" {
+		if _, err := os.Stat(c.Reader.LicenseCategories); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Policy returns the output policy, which defaults to OutputPolicyFail.
+func (c *Config) Policy() string {
+	if c.OutputPolicy == "" {
+		return OutputPolicyFail
+	}
+	return c.OutputPolicy
+}
+
+// Hash returns the hex encoded SHA-256 hash of the configuration encoded as YAML.
+func (c *Config) Hash() (string, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 // NewConfig returns a new decoded Config struct from a given configPath.
 func NewConfig(configPath string) (*Config, error) {
 	config := &Config{}
@@ -2,8 +2,10 @@ package config
 
 import (
 	"errors"
+	"io/fs"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestValidate_EmptyOutputDir(t *testing.T) {
@@ -36,6 +38,60 @@ func TestValidate_OutputDirIsNotEmpty(t *testing.T) {
 	}
 }
 
+func TestValidate_OutputDirIsNotEmptyOverwrite(t *testing.T) {
+	c := &Config{
+		OutputDir:    "../config",
+		OutputPolicy: OutputPolicyOverwrite,
+	}
+	err := c.Validate()
+	if !errors.Is(err, ErrEmptyCoreRepoDir) {
+		t.Error("expected an ErrEmptyCoreRepoDir, got: ", err)
+	}
+}
+
+func TestValidate_InvalidOutputPolicy(t *testing.T) {
+	c := &Config{
+		OutputDir:    "../config",
+		OutputPolicy: "append",
+	}
+	err := c.Validate()
+	if err.Error() != ErrInvalidOutputPolicy(c.OutputPolicy).Error() {
+		t.Error("expected an ErrInvalidOutputPolicy, got: ", err)
+	}
+}
+
+func TestValidate_InvalidKeepRuns(t *testing.T) {
+	c := &Config{
+		OutputDir:    "../config",
+		OutputPolicy: OutputPolicyVersioned,
+		KeepRuns:     -1,
+	}
+	err := c.Validate()
+	if !errors.Is(err, ErrInvalidKeepRuns) {
+		t.Error("expected an ErrInvalidKeepRuns, got: ", err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	c, err := NewConfig("../config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash, err := c.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Reader.MaxWorkers++
+	changed, err := c.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hash) != 64 || hash == changed {
+		t.Errorf("expected distinct SHA-256 hashes, got %s and %s", hash, changed)
+	}
+}
+
 func TestValidate_EmptyCoreRepoDir(t *testing.T) {
 	c := &Config{
 		OutputDir: "./test_dir",
@@ -65,6 +121,22 @@ func TestValidate_CoreRepoDirIsNotADirectory(t *testing.T) {
 	}
 }
 
+func TestValidate_CoreRepoDirDoesNotExist(t *testing.T) {
+	c := &Config{
+		OutputDir: "./test_dir",
+	}
+	c.CoreRepo.Dir = "./missing_dir"
+	c.CoreRepo.Clone = false
+
+	// clean up
+	defer os.RemoveAll(c.OutputDir)
+
+	err := c.Validate()
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("expected an fs.ErrNotExist, got: ", err)
+	}
+}
+
 func TestValidate_CoreRepoURLIsEmpty(t *testing.T) {
 	c := &Config{
 		OutputDir: "./test_dir",
@@ -114,3 +186,52 @@ func TestValidate_CoreRepoBranchIsEmpty(t *testing.T) {
 		t.Error("expected an ErrEmptyCoreRepoBranch, got: ", err)
 	}
 }
+
+func TestValidate_InvalidParseTimeout(t *testing.T) {
+	c := &Config{
+		OutputDir: "./test_dir",
+	}
+	c.CoreRepo.Dir = c.OutputDir
+	c.CoreRepo.Clone = true
+	c.CoreRepo.URL = "https://github.com/Homebrew/homebrew-core.git"
+	c.CoreRepo.Branch = "master"
+	c.Reader.MaxWorkers = 1
+	c.Reader.ParseTimeout = -time.Second
+
+	// clean up
+	defer os.RemoveAll(c.OutputDir)
+
+	err := c.Validate()
+	if !errors.Is(err, ErrInvalidParseTimeout) {
+		t.Error("expected an ErrInvalidParseTimeout, got: ", err)
+	}
+}
+
+func TestValidate_InvalidDepth(t *testing.T) {
+	c := &Config{
+		OutputDir: "./test_dir",
+	}
+	c.CoreRepo.Dir = c.OutputDir
+	c.CoreRepo.Clone = true
+	c.CoreRepo.URL = "https://github.com/Homebrew/homebrew-core.git"
+	c.CoreRepo.Branch = "master"
+	c.CoreRepo.Depth = -1
+
+	// clean up
+	defer os.RemoveAll(c.OutputDir)
+
+	err := c.Validate()
+	if !errors.Is(err, ErrInvalidDepth) {
+		t.Error("expected an ErrInvalidDepth, got: ", err)
+	}
+}
+
+func TestNewConfig_ParseTimeout(t *testing.T) {
+	c, err := NewConfig("../config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Reader.ParseTimeout != 30*time.Second {
+		t.Error("expected a parse timeout of 30s, got: ", c.Reader.ParseTimeout)
+	}
+}
@@ -20,9 +20,22 @@ var (
 		return fmt.Errorf("%s is empty", dir)
 	}
 
+	// ErrInvalidOutputPolicy is returned when a given output policy doesn't exist.
+	ErrInvalidOutputPolicy = func(policy string) error {
+		return fmt.Errorf("invalid output policy %s", policy)
+	}
+
+	// ErrUnknownKey is returned when a given key doesn't exist in the configuration.
+	ErrUnknownKey = func(key string) error {
+		return fmt.Errorf("unknown config key %s", key)
+	}
+
 	// ErrEmptyOutputDir is returned when the output directory is empty.
 	ErrEmptyOutputDir = fmt.Errorf("the output directory is empty")
 
+	// ErrInvalidKeepRuns is returned when the number of kept runs is negative.
+	ErrInvalidKeepRuns = fmt.Errorf("invalid number of kept runs")
+
 	// ErrEmptyCoreRepoDir is returned when the core repository directory is empty.
 	ErrEmptyCoreRepoDir = fmt.Errorf("the core repository directory is empty")
 
@@ -32,6 +45,12 @@ var (
 	// ErrEmptyCoreRepoBranch is returned when the core repository branch is empty.
 	ErrEmptyCoreRepoBranch = fmt.Errorf("the core repository branch is empty")
 
+	// ErrInvalidDepth is returned when the clone depth of the core repository is negative.
+	ErrInvalidDepth = fmt.Errorf("invalid clone depth")
+
 	// ErrInvalidMaxWorkers is returned when the number of workers is invalid.
 	ErrInvalidMaxWorkers = fmt.Errorf("invalid number of workers")
+
+	// ErrInvalidParseTimeout is returned when the parse timeout is negative.
+	ErrInvalidParseTimeout = fmt.Errorf("invalid parse timeout")
 )
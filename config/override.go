@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix is the prefix of the environment variables overriding config keys.
+// The name of the variable is the prefix followed by the key in upper case,
+// where dots are replaced by underscores, e.g. BREW_MINER_READER_MAX_WORKERS for reader.max_workers.
+const EnvPrefix = "BREW_MINER_"
+
+// Keys returns the keys of all config values, where nested keys are joined by dots, e.g. "reader.max_workers".
+func Keys() []string {
+	return keys(reflect.TypeOf(Config{}), "")
+}
+
+// keys returns the keys of the values of the given struct type, prefixed by the given prefix.
+func keys(t reflect.Type, prefix string) []string {
+	res := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := yamlKey(field)
+		if key == "" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct && field.Type.String() != "time.Duration" {
+			res = append(res, keys(field.Type, prefix+key+".")...)
+			continue
+		}
+		res = append(res, prefix+key)
+	}
+	return res
+}
+
+// EnvName returns the name of the environment variable overriding the given key.
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Set sets the value of the given key, e.g. "reader.max_workers", to the given value.
+// The value is decoded like a YAML scalar of the config file, e.g. "true" or "30s".
+func (c *Config) Set(key, value string) error {
+	v := reflect.ValueOf(c).Elem()
+	for _, name := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return ErrUnknownKey(key)
+		}
+		field, ok := fieldByKey(v, name)
+		if !ok {
+			return ErrUnknownKey(key)
+		}
+		v = field
+	}
+	if v.Kind() == reflect.Struct && v.Type().String() != "time.Duration" {
+		return ErrUnknownKey(key)
+	}
+
+	if err := yaml.Unmarshal([]byte(value), v.Addr().Interface()); err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+	}
+	return nil
+}
+
+// ApplyEnv sets the values of all keys with a matching environment variable, which is looked up using lookup.
+func (c *Config) ApplyEnv(lookup func(string) (string, bool)) error {
+	for _, key := range Keys() {
+		if value, ok := lookup(EnvName(key)); ok {
+			if err := c.Set(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldByKey returns the field of the given struct value with the given YAML key.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		if yamlKey(v.Type().Field(i)) == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// yamlKey returns the YAML key of the given struct field.
+func yamlKey(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if tag == "-" {
+		return ""
+	}
+	return tag
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeys(t *testing.T) {
+	keys := Keys()
+	for _, key := range []string{"output_dir", "core_repo.clone", "reader.max_workers", "reader.parse_timeout", "json"} {
+		found := false
+		for _, k := range keys {
+			found = found || k == key
+		}
+		if !found {
+			t.Errorf("expected key %s in %v", key, keys)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	c := &Config{}
+	values := map[string]string{
+		"output_dir":              "./out",
+		"core_repo.clone":         "true",
+		"reader.max_workers":      "4",
+		"reader.parse_timeout":    "1m",
+		"reader.legacy_parser":    "yes",
+		"reader.fallback_license": "MIT",
+	}
+	for key, value := range values {
+		if err := c.Set(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if c.OutputDir != "./out" || !c.CoreRepo.Clone || c.Reader.MaxWorkers != 4 ||
+		c.Reader.ParseTimeout != time.Minute || !c.Reader.LegacyParser || c.Reader.FallbackLicense != "MIT" {
+		t.Errorf("unexpected config: %+v", c)
+	}
+
+	if err := c.Set("reader.unknown", "1"); err == nil {
+		t.Error("expected an error for an unknown key")
+	}
+	if err := c.Set("reader", "1"); err == nil {
+		t.Error("expected an error for a nested config")
+	}
+	if err := c.Set("reader.max_workers", "many"); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	env := map[string]string{
+		"BREW_MINER_READER_MAX_WORKERS": "3",
+		"BREW_MINER_CORE_REPO_DIR":      "./repo",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+
+	c := &Config{}
+	if err := c.ApplyEnv(lookup); err != nil {
+		t.Fatal(err)
+	}
+	if c.Reader.MaxWorkers != 3 || c.CoreRepo.Dir != "./repo" {
+		t.Errorf("unexpected config: %+v", c)
+	}
+
+	env["BREW_MINER_JSON"] = "maybe"
+	if err := c.ApplyEnv(lookup); err == nil {
+		t.Errorf("expected an error for an invalid value, got: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package compat
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"main/miner/license"
+	"main/miner/types"
+)
+
+// Report holds the findings of a license check.
+type Report struct {
+	// Errors is the number of findings of the error severity.
+	Errors int `json:"errors"`
+
+	// Warnings is the number of findings of the warning severity.
+	Warnings int `json:"warnings"`
+
+	// Findings are sorted by the rule, the formula and the dependency.
+	Findings []*Finding `json:"findings"`
+}
+
+// Finding is a dependency whose license violates a rule of the policy.
+type Finding struct {
+	// Rule is the name of the violated rule.
+	Rule string `json:"rule"`
+
+	// Severity of the rule.
+	Severity string `json:"severity"`
+
+	// Formula is the name of the formula the rule applies to.
+	Formula string `json:"formula"`
+
+	// License of the formula.
+	License string `json:"license"`
+
+	// Dependency is the name of the dependency violating the rule.
+	Dependency string `json:"dependency"`
+
+	// DependencyLicense is the license of the dependency.
+	DependencyLicense string `json:"dependency_license"`
+
+	// Path holds the names of the formulae from the formula to the dependency, both included.
+	Path []string `json:"path"`
+}
+
+// Check checks the licenses of the dependencies of the given formulae against the rules of the given policy,
+// where the key of the map is the name of the formula.
+// Dependencies on formulae which don't exist are ignored.
+func Check(formulae map[string]*types.Formula, policy *Policy) *Report {
+	names := make([]string, 0, len(formulae))
+	for name := range formulae {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := &Report{Findings: make([]*Finding, 0)}
+	for _, rule := range policy.Rules {
+		for _, name := range names {
+			f := formulae[name]
+			if len(rule.Licenses) > 0 && !matchExpression(f.License, func(id string) bool { return matchAny(rule.Licenses, id) }) {
+				continue
+			}
+			for _, finding := range checkFormula(formulae, f, rule) {
+				if finding.Severity == SeverityError {
+					report.Errors++
+				} else {
+					report.Warnings++
+				}
+				report.Findings = append(report.Findings, finding)
+			}
+		}
+	}
+	return report
+}
+
+// checkFormula checks the dependencies of the given formula the rule applies to, or its transitive dependencies
+// if the rule is transitive, and returns the findings sorted by the name of the dependency.
+// Each dependency is reported once, along the shortest path from the formula.
+func checkFormula(formulae map[string]*types.Formula, f *types.Formula, rule *Rule) []*Finding {
+	parents := map[string]string{f.Name: ""}
+	queue := []string{f.Name}
+	findings := make([]*Finding, 0)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, dep := range formulae[name].Dependencies {
+			d, ok := formulae[dep.Name]
+			if !ok || !rule.appliesTo(dep.DepType) {
+				continue
+			}
+			if _, seen := parents[dep.Name]; seen {
+				continue
+			}
+			parents[dep.Name] = name
+			if rule.Transitive {
+				queue = append(queue, dep.Name)
+			}
+			if !rule.permits(d.License) {
+				findings = append(findings, &Finding{
+					Rule:              rule.Name,
+					Severity:          rule.severity(),
+					Formula:           f.Name,
+					License:           f.License,
+					Dependency:        d.Name,
+					DependencyLicense: d.License,
+					Path:              pathTo(parents, d.Name),
+				})
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Dependency < findings[j].Dependency
+	})
+	return findings
+}
+
+// pathTo returns the names of the formulae from the root of the given parents to the given formula.
+func pathTo(parents map[string]string, name string) []string {
+	p := []string{name}
+	for parents[name] != "" {
+		name = parents[name]
+		p = append([]string{name}, p...)
+	}
+	return p
+}
+
+// matchExpression returns true if the given license expression matches, where each license is matched by match.
+// An OR expression matches if any of its choices matches and an AND expression matches if all of its operands match.
+// A license which can't be parsed is matched as a whole.
+func matchExpression(s string, match func(id string) bool) bool {
+	e, err := license.Parse(s)
+	if err != nil {
+		return match(s)
+	}
+	return matchParsed(e, match)
+}
+
+// matchParsed matches the parsed license expression, see matchExpression.
+func matchParsed(e *license.Expression, match func(id string) bool) bool {
+	switch e.Op {
+	case license.OpOr:
+		for _, a := range e.Args {
+			if matchParsed(a, match) {
+				return true
+			}
+		}
+		return false
+	case license.OpAnd:
+		for _, a := range e.Args {
+			if !matchParsed(a, match) {
+				return false
+			}
+		}
+		return true
+	default:
+		return match(e.License)
+	}
+}
+
+// WriteJSON writes the report as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteText writes the report in a human-readable form.
+func (r *Report) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%d findings: %d errors, %d warnings\n", len(r.Findings), r.Errors, r.Warnings)
+	for _, f := range r.Findings {
+		fmt.Fprintf(b, "%s [%s] %s (%s) depends on %s (%s)\n", f.Severity, f.Rule, f.Formula, f.License, f.Dependency, f.DependencyLicense)
+		if len(f.Path) > 2 {
+			fmt.Fprintf(b, "    via %s\n", strings.Join(f.Path, " -> "))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
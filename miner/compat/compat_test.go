@@ -0,0 +1,105 @@
+package compat
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"main/miner/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var formulae = map[string]*types.Formula{
+	"app": {Name: "app", License: "MIT", Dependencies: []*types.Dependency{
+		{Name: "lib"},
+		{Name: "tool", DepType: []string{"build"}},
+		{Name: "dual"},
+		{Name: "missing"},
+	}},
+	"lib":     {Name: "lib", License: "BSD-3-Clause", Dependencies: []*types.Dependency{{Name: "gpl"}, {Name: "unknown"}}},
+	"gpl":     {Name: "gpl", License: "GPL-3.0-only"},
+	"tool":    {Name: "tool", License: "GPL-2.0-or-later"},
+	"dual":    {Name: "dual", License: "MIT or GPL-2.0-only"},
+	"unknown": {Name: "unknown", License: "pseudo"},
+	"copy":    {Name: "copy", License: "GPL-2.0-only and MIT", Dependencies: []*types.Dependency{{Name: "gpl"}}},
+}
+
+func TestCheck(t *testing.T) {
+	report := Check(formulae, DefaultPolicy("pseudo"))
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, `4 findings: 2 errors, 2 warnings
+error [permissive-links-copyleft] app (MIT) depends on gpl (GPL-3.0-only)
+    via app -> lib -> gpl
+error [permissive-links-copyleft] lib (BSD-3-Clause) depends on gpl (GPL-3.0-only)
+warning [unknown-license] app (MIT) depends on unknown (pseudo)
+    via app -> lib -> unknown
+warning [unknown-license] lib (BSD-3-Clause) depends on unknown (pseudo)
+`, text.String())
+}
+
+func TestDefaultPolicy(t *testing.T) {
+	// Without a fallback license, only licenses which can't be represented are flagged.
+	report := Check(formulae, DefaultPolicy(""))
+	assert.Equal(t, 2, report.Errors)
+	assert.Equal(t, 0, report.Warnings)
+
+	// The fallback license is matched literally.
+	report = Check(map[string]*types.Formula{
+		"app":   {Name: "app", License: "MIT", Dependencies: []*types.Dependency{{Name: "other"}, {Name: "star"}}},
+		"other": {Name: "other", License: "GPL-2.0-only"},
+		"star":  {Name: "star", License: "*"},
+	}, DefaultPolicy("*"))
+	if assert.Len(t, report.Findings, 2) {
+		assert.Equal(t, "permissive-links-copyleft", report.Findings[0].Rule)
+		assert.Equal(t, "star", report.Findings[1].Dependency)
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yml")
+	if err := os.WriteFile(policyPath, []byte(`rules:
+  - name: build-tools
+    dependency_types: [build]
+    allow: [MIT, BSD-*]
+    severity: warning
+`), 0644); err != nil {
+		log.Fatal(err)
+	}
+	policy, err := LoadPolicy(policyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report := Check(formulae, policy)
+	assert.Equal(t, []*Finding{{
+		Rule:              "build-tools",
+		Severity:          SeverityWarning,
+		Formula:           "app",
+		License:           "MIT",
+		Dependency:        "tool",
+		DependencyLicense: "GPL-2.0-or-later",
+		Path:              []string{"app", "tool"},
+	}}, report.Findings)
+
+	for _, invalid := range []string{
+		"rules: []\n",
+		"rules:\n  - deny: [GPL-*]\n",
+		"rules:\n  - name: r\n",
+		"rules:\n  - name: r\n    deny: [GPL-*]\n    severity: fatal\n",
+		"rules:\n  - name: r\n    deny: [\"[\"]\n",
+	} {
+		if err := os.WriteFile(policyPath, []byte(invalid), 0644); err != nil {
+			log.Fatal(err)
+		}
+		_, err := LoadPolicy(policyPath)
+		assert.Error(t, err, invalid)
+	}
+}
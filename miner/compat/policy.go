@@ -0,0 +1,181 @@
+package compat
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severities of a rule.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// RuntimeType is the type of dependencies without an explicit type.
+const RuntimeType = "runtime"
+
+//go:embed default_policy.yml
+var defaultPolicy []byte
+
+// Policy holds the rules the licenses of the formulae are checked against.
+type Policy struct {
+	Rules []*Rule `yaml:"rules"`
+}
+
+// Rule restricts the licenses of the dependencies of formulae.
+// Licenses are matched by glob patterns, e.g. "GPL-*", ignoring case.
+// The licenses of an expression are matched individually, where an OR expression matches if any of its choices
+// matches and an AND expression matches if all of its operands match. Exceptions of licenses are ignored.
+type Rule struct {
+	// Name of the rule.
+	Name string `yaml:"name"`
+
+	// Description of the rule.
+	Description string `yaml:"description"`
+
+	// Licenses holds the patterns of the licenses of the formulae the rule applies to.
+	// The rule applies to all formulae if empty.
+	Licenses []string `yaml:"licenses"`
+
+	// DependencyTypes holds the types of the dependencies the rule applies to, e.g. "runtime" or "build".
+	// The rule applies to all dependencies if empty.
+	DependencyTypes []string `yaml:"dependency_types"`
+
+	// Transitive is true if the rule applies to the transitive dependencies, following the dependencies
+	// of the given types only.
+	Transitive bool `yaml:"transitive"`
+
+	// Allow holds the patterns of the licenses allowed for dependencies. All licenses are allowed if empty.
+	Allow []string `yaml:"allow"`
+
+	// Deny holds the patterns of the licenses denied for dependencies, even if they are allowed.
+	Deny []string `yaml:"deny"`
+
+	// Severity of the findings of the rule, one of the Severity constants. The error severity is used if empty.
+	Severity string `yaml:"severity"`
+}
+
+// unknownLicenseRule is the name of the rule of the default policy flagging unknown licenses.
+const unknownLicenseRule = "unknown-license"
+
+// DefaultPolicy returns the default policy, which flags permissively licensed formulae depending at runtime on
+// GPL-only code, and licenses which can't be represented or the given fallback license in the runtime closure.
+// The fallback license is the one the formulae have been mined with, it isn't flagged if empty.
+func DefaultPolicy(fallbackLicense string) *Policy {
+	p, err := parsePolicy(defaultPolicy)
+	if err != nil {
+		panic(err)
+	}
+	if fallbackLicense != "" {
+		for _, r := range p.Rules {
+			if r.Name == unknownLicenseRule {
+				r.Deny = append(r.Deny, escapePattern(fallbackLicense))
+			}
+		}
+	}
+	return p
+}
+
+// escapePattern escapes the special characters of a glob pattern in the given license, such that it matches itself only.
+func escapePattern(license string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`).Replace(license)
+}
+
+// LoadPolicy reads and validates the policy at the given path.
+func LoadPolicy(policyPath string) (*Policy, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	p, err := parsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid license policy %s: %w", policyPath, err)
+	}
+	return p, nil
+}
+
+// parsePolicy parses and validates the given YAML policy.
+func parsePolicy(data []byte) (*Policy, error) {
+	p := &Policy{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks the rules of the policy for missing names, invalid severities and malformed patterns.
+func (p *Policy) Validate() error {
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("no rules")
+	}
+	for i, r := range p.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule %d has no name", i+1)
+		}
+		if r.Severity != "" && r.Severity != SeverityError && r.Severity != SeverityWarning {
+			return fmt.Errorf("rule %s has invalid severity %s", r.Name, r.Severity)
+		}
+		if len(r.Allow) == 0 && len(r.Deny) == 0 {
+			return fmt.Errorf("rule %s neither allows nor denies licenses", r.Name)
+		}
+		for _, patterns := range [][]string{r.Licenses, r.Allow, r.Deny} {
+			for _, p := range patterns {
+				if _, err := path.Match(p, ""); err != nil {
+					return fmt.Errorf("rule %s has invalid pattern %s", r.Name, p)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// severity returns the severity of the rule's findings.
+func (r *Rule) severity() string {
+	if r.Severity == "" {
+		return SeverityError
+	}
+	return r.Severity
+}
+
+// appliesTo returns true if the rule applies to a dependency of the given types.
+func (r *Rule) appliesTo(depTypes []string) bool {
+	if len(r.DependencyTypes) == 0 {
+		return true
+	}
+	if len(depTypes) == 0 {
+		depTypes = []string{RuntimeType}
+	}
+	for _, t := range depTypes {
+		for _, allowed := range r.DependencyTypes {
+			if t == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// permits returns true if the given license of a dependency is allowed and not denied by the rule.
+func (r *Rule) permits(license string) bool {
+	return matchExpression(license, func(id string) bool {
+		return !matchAny(r.Deny, id) && (len(r.Allow) == 0 || matchAny(r.Allow, id))
+	})
+}
+
+// matchAny returns true if the given license matches any of the given patterns, ignoring case.
+func matchAny(patterns []string, license string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(license)); ok {
+			return true
+		}
+	}
+	return false
+}
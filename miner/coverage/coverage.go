@@ -0,0 +1,195 @@
+package coverage
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"main/miner/forge"
+	"main/miner/parser"
+	"main/miner/ruby"
+	"main/miner/setup"
+)
+
+// maxExamples is the maximum number of locations kept per unknown construct.
+const maxExamples = 3
+
+// Construct is an unknown top-level construct found in the formula files.
+type Construct struct {
+	// Name of the construct, e.g. "on_linux" or "def install".
+	Name string
+
+	// Count is the number of occurences of the construct.
+	Count int
+
+	// Examples holds the locations of the first occurences of the construct, e.g. "Formula/f/foo.rb:12".
+	Examples []string
+}
+
+// UnmappedHost is the host of archives whose URLs couldn't be mapped to the URL of a repository.
+type UnmappedHost struct {
+	// Domain of the host, e.g. "downloads.example.com".
+	Domain string
+
+	// Registry is true if the host is a package registry, whose archive URLs don't identify a repository.
+	Registry bool
+
+	// Count is the number of formulae with an archive of the host.
+	Count int
+
+	// Examples holds the names of the first formulae with an archive of the host.
+	Examples []string
+}
+
+// Report aggregates the coverage of the formula files read from the core repository.
+// It is safe for concurrent use.
+type Report struct {
+	mu sync.Mutex
+
+	// The number of files added to the report.
+	files int
+
+	// The files which could not be parsed into a syntax tree.
+	unparsed []string
+
+	// The number of lines per class.
+	lines map[parser.LineClass]int
+
+	// The unknown constructs, where the key is the name of the construct.
+	constructs map[string]*Construct
+
+	// The hosts of archives which couldn't be mapped to a repository, where the key is the domain.
+	unmapped map[string]*UnmappedHost
+}
+
+// NewReport creates a new empty coverage report.
+func NewReport() *Report {
+	return &Report{
+		unparsed:   make([]string, 0),
+		lines:      make(map[parser.LineClass]int),
+		constructs: make(map[string]*Construct),
+		unmapped:   make(map[string]*UnmappedHost),
+	}
+}
+
+// Add classifies the lines of the given content of the formula file at the given path and adds them to the report.
+// Files which can't be parsed into a syntax tree are recorded as unparsed.
+func (r *Report) Add(path string, src []byte) {
+	var cov *parser.Coverage
+	tree, err := ruby.Parse(string(src))
+	if err == nil {
+		treeParser := &parser.TreeParser{Tree: tree}
+		cov, err = treeParser.Coverage(setup.BuildNodeStrategies(), setup.IsIgnoredNode)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.files++
+	if err != nil {
+		r.unparsed = append(r.unparsed, fmt.Sprintf("%s: %v", path, err))
+		return
+	}
+
+	for _, lineClass := range []parser.LineClass{parser.Consumed, parser.Ignored, parser.Unknown} {
+		r.lines[lineClass] += cov.Count(lineClass)
+	}
+
+	for _, node := range cov.Unknown {
+		name := parser.ConstructName(node)
+		c, ok := r.constructs[name]
+		if !ok {
+			c = &Construct{Name: name, Examples: make([]string, 0, maxExamples)}
+			r.constructs[name] = c
+		}
+		c.Count++
+		if len(c.Examples) < maxExamples {
+			c.Examples = append(c.Examples, fmt.Sprintf("%s:%d", path, node.Line))
+		}
+	}
+}
+
+// AddUnmapped adds the host of the given archive URL of the formula with the given name to the report,
+// as the URL of the formula's repository couldn't be derived from it.
+func (r *Report) AddUnmapped(name, archiveURL string) {
+	domain := forge.Domain(archiveURL)
+	if domain == "" {
+		domain = archiveURL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.unmapped[domain]
+	if !ok {
+		host := forge.Lookup(archiveURL)
+		h = &UnmappedHost{Domain: domain, Registry: host != nil && host.Registry, Examples: make([]string, 0, maxExamples)}
+		r.unmapped[domain] = h
+	}
+	h.Count++
+	if len(h.Examples) < maxExamples {
+		h.Examples = append(h.Examples, name)
+	}
+}
+
+// Files returns the number of files added to the report.
+func (r *Report) Files() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.files
+}
+
+// Unparsed returns the files which could not be parsed into a syntax tree, along with the parse error.
+func (r *Report) Unparsed() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unparsed := append([]string{}, r.unparsed...)
+	sort.Strings(unparsed)
+	return unparsed
+}
+
+// Lines returns the number of lines of the given class across all files.
+func (r *Report) Lines(lineClass parser.LineClass) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lines[lineClass]
+}
+
+// Constructs returns the unknown constructs, the most frequent ones first.
+// Constructs with the same number of occurences are sorted by name.
+func (r *Report) Constructs() []*Construct {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	constructs := make([]*Construct, 0, len(r.constructs))
+	for _, c := range r.constructs {
+		constructs = append(constructs, c)
+	}
+	sort.Slice(constructs, func(i, j int) bool {
+		if constructs[i].Count != constructs[j].Count {
+			return constructs[i].Count > constructs[j].Count
+		}
+		return constructs[i].Name < constructs[j].Name
+	})
+	return constructs
+}
+
+// UnmappedHosts returns the hosts of archives which couldn't be mapped to a repository, the most frequent ones first.
+// Hosts with the same number of formulae are sorted by domain.
+func (r *Report) UnmappedHosts() []*UnmappedHost {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hosts := make([]*UnmappedHost, 0, len(r.unmapped))
+	for _, h := range r.unmapped {
+		hosts = append(hosts, h)
+	}
+	sort.Slice(hosts, func(i, j int) bool {
+		if hosts[i].Count != hosts[j].Count {
+			return hosts[i].Count > hosts[j].Count
+		}
+		return hosts[i].Domain < hosts[j].Domain
+	})
+	return hosts
+}
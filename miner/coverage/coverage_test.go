@@ -0,0 +1,49 @@
+package coverage
+
+import (
+	"testing"
+
+	"main/miner/parser"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	files := map[string]string{
+		"foo.rb": "class Foo < Formula\n  on_foo do\n    depends_on \"bar\"\n  end\n  uses_from_xyz \"baz\"\nend\n",
+		"bar.rb": "class Bar < Formula\n  on_foo { depends_on \"baz\" }\n  depends_on \"foo\"\nend\n",
+		"baz.rb": "class Baz < Formula\n  on_linux do\nend\n",
+	}
+
+	report := NewReport()
+	for _, name := range []string{"foo.rb", "bar.rb", "baz.rb"} {
+		report.Add(name, []byte(files[name]))
+	}
+
+	assert.Equal(t, 3, report.Files())
+	assert.Len(t, report.Unparsed(), 1)
+	assert.Equal(t, 1, report.Lines(parser.Consumed))
+	assert.Equal(t, 5, report.Lines(parser.Unknown))
+
+	constructs := report.Constructs()
+	if assert.Len(t, constructs, 2) {
+		assert.Equal(t, &Construct{
+			Name:     "on_foo",
+			Count:    2,
+			Examples: []string{"foo.rb:2", "bar.rb:2"},
+		}, constructs[0])
+		assert.Equal(t, "uses_from_xyz", constructs[1].Name)
+	}
+}
+
+func TestReportUnmapped(t *testing.T) {
+	report := NewReport()
+	report.AddUnmapped("foo", "https://downloads.example.com/foo-1.0.tar.gz")
+	report.AddUnmapped("bar", "https://files.pythonhosted.org/packages/bar-1.0.tar.gz")
+	report.AddUnmapped("baz", "https://downloads.example.com/baz-1.0.tar.gz")
+
+	assert.Equal(t, []*UnmappedHost{
+		{Domain: "downloads.example.com", Count: 2, Examples: []string{"foo", "baz"}},
+		{Domain: "files.pythonhosted.org", Registry: true, Count: 1, Examples: []string{"bar"}},
+	}, report.UnmappedHosts())
+}
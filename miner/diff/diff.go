@@ -0,0 +1,239 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"main/miner/types"
+)
+
+// Report holds the differences between two snapshots of the formulae.
+type Report struct {
+	// Added holds the names of the formulae only present in the new snapshot.
+	Added []string `json:"added"`
+
+	// Removed holds the names of the formulae only present in the old snapshot.
+	Removed []string `json:"removed"`
+
+	// Changed holds the formulae present in both snapshots which differ.
+	Changed []*FormulaDiff `json:"changed"`
+}
+
+// FormulaDiff holds the differences of a formula present in both snapshots.
+type FormulaDiff struct {
+	// Name of the formula.
+	Name string `json:"name"`
+
+	// License change of the formula, if any.
+	License *Change `json:"license,omitempty"`
+
+	// RepoURL change of the formula, if any.
+	RepoURL *Change `json:"repo_url,omitempty"`
+
+	// DependenciesAdded holds the dependency edges only present in the new snapshot.
+	DependenciesAdded []*types.Dependency `json:"dependencies_added,omitempty"`
+
+	// DependenciesRemoved holds the dependency edges only present in the old snapshot.
+	DependenciesRemoved []*types.Dependency `json:"dependencies_removed,omitempty"`
+
+	// DependenciesRetyped holds the dependencies whose types changed.
+	DependenciesRetyped []*DependencyChange `json:"dependencies_retyped,omitempty"`
+
+	// RestrictionsChanged holds the dependencies whose restriction changed.
+	RestrictionsChanged []*DependencyChange `json:"restrictions_changed,omitempty"`
+}
+
+// Change is a changed value.
+type Change struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// DependencyChange is a changed value of a dependency.
+type DependencyChange struct {
+	// Name of the dependency.
+	Name string `json:"name"`
+
+	// Type of the dependency, if the restriction changed.
+	Type string `json:"type,omitempty"`
+
+	Change
+}
+
+// empty returns true if the formula doesn't differ.
+func (d *FormulaDiff) empty() bool {
+	return d.License == nil && d.RepoURL == nil &&
+		len(d.DependenciesAdded) == 0 && len(d.DependenciesRemoved) == 0 &&
+		len(d.DependenciesRetyped) == 0 && len(d.RestrictionsChanged) == 0
+}
+
+// Compare returns the differences between the old and the new snapshot of the formulae,
+// where the key of each map is the name of the formula.
+// All lists of the report are sorted by the name of the formula or dependency.
+func Compare(old, new map[string]*types.Formula) *Report {
+	report := &Report{
+		Added:   make([]string, 0),
+		Removed: make([]string, 0),
+		Changed: make([]*FormulaDiff, 0),
+	}
+
+	for name := range new {
+		if _, ok := old[name]; !ok {
+			report.Added = append(report.Added, name)
+		}
+	}
+	for _, name := range sortedNames(old) {
+		n, ok := new[name]
+		if !ok {
+			report.Removed = append(report.Removed, name)
+			continue
+		}
+		if d := compareFormula(old[name], n); !d.empty() {
+			report.Changed = append(report.Changed, d)
+		}
+	}
+	sort.Strings(report.Added)
+
+	return report
+}
+
+// compareFormula returns the differences between the old and new version of a formula.
+func compareFormula(old, new *types.Formula) *FormulaDiff {
+	d := &FormulaDiff{Name: old.Name}
+	if old.License != new.License {
+		d.License = &Change{Old: old.License, New: new.License}
+	}
+	if old.RepoURL != new.RepoURL {
+		d.RepoURL = &Change{Old: old.RepoURL, New: new.RepoURL}
+	}
+
+	oldDeps, newDeps := groupDependencies(old.Dependencies), groupDependencies(new.Dependencies)
+	for _, name := range sortedNames(newDeps) {
+		if _, ok := oldDeps[name]; !ok {
+			d.DependenciesAdded = append(d.DependenciesAdded, newDeps[name]...)
+		}
+	}
+	for _, name := range sortedNames(oldDeps) {
+		n, ok := newDeps[name]
+		if !ok {
+			d.DependenciesRemoved = append(d.DependenciesRemoved, oldDeps[name]...)
+			continue
+		}
+
+		// A dependency may be declared multiple times with different types.
+		oldTypes, newTypes := depTypes(oldDeps[name]), depTypes(n)
+		if strings.Join(oldTypes, "; ") != strings.Join(newTypes, "; ") {
+			d.DependenciesRetyped = append(d.DependenciesRetyped, &DependencyChange{
+				Name:   name,
+				Change: Change{Old: strings.Join(oldTypes, "; "), New: strings.Join(newTypes, "; ")},
+			})
+		}
+
+		// Compare the restrictions of the types declared in both versions.
+		for _, o := range oldDeps[name] {
+			for _, nd := range n {
+				if depType(o) == depType(nd) && o.Restriction != nd.Restriction {
+					d.RestrictionsChanged = append(d.RestrictionsChanged, &DependencyChange{
+						Name:   name,
+						Type:   depType(o),
+						Change: Change{Old: o.Restriction, New: nd.Restriction},
+					})
+				}
+			}
+		}
+	}
+	return d
+}
+
+// groupDependencies returns the given dependencies grouped by their name.
+func groupDependencies(deps []*types.Dependency) map[string][]*types.Dependency {
+	groups := make(map[string][]*types.Dependency)
+	for _, dep := range deps {
+		groups[dep.Name] = append(groups[dep.Name], dep)
+	}
+	return groups
+}
+
+// depType returns the type of the given dependency as written to the TSV output.
+func depType(dep *types.Dependency) string {
+	if len(dep.DepType) == 0 {
+		return "runtime"
+	}
+	return strings.Join(dep.DepType, ", ")
+}
+
+// depTypes returns the sorted types of the given dependencies.
+func depTypes(deps []*types.Dependency) []string {
+	res := make([]string, 0, len(deps))
+	for _, dep := range deps {
+		res = append(res, depType(dep))
+	}
+	sort.Strings(res)
+	return res
+}
+
+// sortedNames returns the sorted keys of the given map.
+func sortedNames[T any](m map[string]T) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteJSON writes the report as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteText writes the report in a human-readable form.
+func (r *Report) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "%d added, %d removed, %d changed\n", len(r.Added), len(r.Removed), len(r.Changed))
+
+	for _, name := range r.Added {
+		fmt.Fprintf(b, "+ %s\n", name)
+	}
+	for _, name := range r.Removed {
+		fmt.Fprintf(b, "- %s\n", name)
+	}
+
+	for _, d := range r.Changed {
+		fmt.Fprintf(b, "~ %s\n", d.Name)
+		if d.License != nil {
+			fmt.Fprintf(b, "    license: %q -> %q\n", d.License.Old, d.License.New)
+		}
+		if d.RepoURL != nil {
+			fmt.Fprintf(b, "    repo: %q -> %q\n", d.RepoURL.Old, d.RepoURL.New)
+		}
+		for _, dep := range d.DependenciesAdded {
+			fmt.Fprintf(b, "    + dependency %s (%s)%s\n", dep.Name, depType(dep), formatRestriction(dep.Restriction))
+		}
+		for _, dep := range d.DependenciesRemoved {
+			fmt.Fprintf(b, "    - dependency %s (%s)%s\n", dep.Name, depType(dep), formatRestriction(dep.Restriction))
+		}
+		for _, c := range d.DependenciesRetyped {
+			fmt.Fprintf(b, "    ~ dependency %s: %s -> %s\n", c.Name, c.Old, c.New)
+		}
+		for _, c := range d.RestrictionsChanged {
+			fmt.Fprintf(b, "    ~ dependency %s (%s) restriction: %q -> %q\n", c.Name, c.Type, c.Old, c.New)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatRestriction formats the given restriction as suffix of a dependency.
+func formatRestriction(restriction string) string {
+	if restriction == "" {
+		return ""
+	}
+	return fmt.Sprintf(" [%s]", restriction)
+}
@@ -0,0 +1,74 @@
+package diff
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"main/miner/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var oldFormulae = map[string]*types.Formula{
+	"foo": {Name: "foo", License: "MIT", RepoURL: "https://github.com/example/foo.git", Dependencies: []*types.Dependency{
+		{Name: "bar", DepType: []string{}},
+		{Name: "baz", DepType: []string{"build"}},
+		{Name: "zlib", DepType: []string{}, Restriction: "linux"},
+	}},
+	"bar":  {Name: "bar", License: "MIT"},
+	"baz":  {Name: "baz", License: "MIT"},
+	"zlib": {Name: "zlib", License: "Zlib"},
+	"old":  {Name: "old", License: "MIT"},
+}
+
+var newFormulae = map[string]*types.Formula{
+	"foo": {Name: "foo", License: "Apache-2.0", RepoURL: "https://github.com/example/foo2.git", Dependencies: []*types.Dependency{
+		{Name: "baz", DepType: []string{}},
+		{Name: "new", DepType: []string{"test"}},
+		{Name: "zlib", DepType: []string{}, Restriction: "macos"},
+	}},
+	"bar":  {Name: "bar", License: "MIT"},
+	"baz":  {Name: "baz", License: "MIT"},
+	"zlib": {Name: "zlib", License: "Zlib"},
+	"new":  {Name: "new", License: "MIT"},
+}
+
+func TestCompare(t *testing.T) {
+	report := Compare(oldFormulae, newFormulae)
+
+	assert.Equal(t, []string{"new"}, report.Added)
+	assert.Equal(t, []string{"old"}, report.Removed)
+	assert.Equal(t, []*FormulaDiff{{
+		Name:                "foo",
+		License:             &Change{Old: "MIT", New: "Apache-2.0"},
+		RepoURL:             &Change{Old: "https://github.com/example/foo.git", New: "https://github.com/example/foo2.git"},
+		DependenciesAdded:   []*types.Dependency{{Name: "new", DepType: []string{"test"}}},
+		DependenciesRemoved: []*types.Dependency{{Name: "bar", DepType: []string{}}},
+		DependenciesRetyped: []*DependencyChange{{Name: "baz", Change: Change{Old: "build", New: "runtime"}}},
+		RestrictionsChanged: []*DependencyChange{{Name: "zlib", Type: "runtime", Change: Change{Old: "linux", New: "macos"}}},
+	}}, report.Changed)
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, `1 added, 1 removed, 1 changed
++ new
+- old
+~ foo
+    license: "MIT" -> "Apache-2.0"
+    repo: "https://github.com/example/foo.git" -> "https://github.com/example/foo2.git"
+    + dependency new (test)
+    - dependency bar (runtime)
+    ~ dependency baz: build -> runtime
+    ~ dependency zlib (runtime) restriction: "linux" -> "macos"
+`, text.String())
+}
+
+func TestCompareEqual(t *testing.T) {
+	report := Compare(oldFormulae, oldFormulae)
+	assert.Empty(t, report.Added)
+	assert.Empty(t, report.Removed)
+	assert.Empty(t, report.Changed)
+}
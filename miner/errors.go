@@ -0,0 +1,19 @@
+package miner
+
+import (
+	"fmt"
+)
+
+var (
+	// ErrNoSource is returned when no source of the formula files has been provided.
+	ErrNoSource = fmt.Errorf("no source of the formula files provided")
+
+	// ErrInvalidMaxWorkers is returned when the number of workers is invalid.
+	ErrInvalidMaxWorkers = fmt.Errorf("invalid number of workers")
+
+	// ErrWrite wraps the errors encountered while writing the output files.
+	ErrWrite = fmt.Errorf("error writing the output files")
+
+	// ErrNoConfig is returned when output files are written by a miner which hasn't been created from an application config.
+	ErrNoConfig = fmt.Errorf("no application config provided")
+)
@@ -0,0 +1,230 @@
+package forge
+
+import (
+	"net/url"
+	"strings"
+
+	"main/miner/pattern"
+)
+
+// Rule rewrites the URLs matching its pattern to the canonical clone URL of a repository.
+type Rule struct {
+	// Pattern matches the URL, where the submatches are referenced by the template.
+	Pattern string
+
+	// Template expands to the clone URL, e.g. "https://github.com/$1/$2.git".
+	Template string
+}
+
+// Host is a known host of repositories, release archives or packages.
+type Host struct {
+	// Name of the host, e.g. "GitHub".
+	Name string
+
+	// Domains of the host. A domain starting with "*." matches all of its sub-domains,
+	// and a domain ending with ".*" matches all domains starting with it.
+	Domains []string
+
+	// Repo holds the rules matching the URLs of repositories, such as the homepage of a formula.
+	Repo []Rule
+
+	// Archive holds the rules matching the URLs of release archives.
+	Archive []Rule
+
+	// Registry is true if the host is a package registry, whose archive URLs don't identify a repository.
+	Registry bool
+}
+
+// segment matches a single segment of a URL path.
+const segment = `[a-zA-Z0-9_.+-]+`
+
+// gitlabSegment matches a single segment of the path of a GitLab project.
+// It excludes the "-" separating the path of the project from the path of a page, e.g. "/-/archive".
+const gitlabSegment = `[a-zA-Z0-9_][a-zA-Z0-9_.+-]*`
+
+// Hosts holds the known hosts, which are tried in order.
+var Hosts = []*Host{
+	{
+		Name:    "GitHub",
+		Domains: []string{"github.com", "codeload.github.com"},
+		Repo: []Rule{
+			{`^https://github\.com/(` + segment + `)/(` + segment + `?)(?:\.git)?(?:/|\?.*)?$`, "https://github.com/$1/$2.git"},
+		},
+		Archive: []Rule{
+			{`^https://github\.com/(` + segment + `)/(` + segment + `)/(?:releases/download|archive)/`, "https://github.com/$1/$2.git"},
+			{`^https://codeload\.github\.com/(` + segment + `)/(` + segment + `)/`, "https://github.com/$1/$2.git"},
+		},
+	},
+	{
+		// GitLab projects may be nested within sub-groups.
+		Name:    "GitLab",
+		Domains: []string{"gitlab.com", "gitlab.freedesktop.org", "gitlab.gnome.org"},
+		Repo: []Rule{
+			{`^https://([a-z.]+)/(` + gitlabSegment + `(?:/` + gitlabSegment + `)+?)(?:\.git)?(?:/|\?.*)?$`, "https://$1/$2.git"},
+		},
+		Archive: []Rule{
+			{`^https://([a-z.]+)/(` + gitlabSegment + `(?:/` + gitlabSegment + `)+?)/(?:-/archive|-/releases|uploads)/`, "https://$1/$2.git"},
+		},
+	},
+	{
+		Name:    "Bitbucket",
+		Domains: []string{"bitbucket.org"},
+		Repo: []Rule{
+			{`^https://bitbucket\.org/(` + segment + `)/(` + segment + `?)(?:\.git)?(?:/|\?.*)?$`, "https://bitbucket.org/$1/$2.git"},
+		},
+		Archive: []Rule{
+			{`^https://bitbucket\.org/(` + segment + `)/(` + segment + `)/(?:downloads|get)/`, "https://bitbucket.org/$1/$2.git"},
+		},
+	},
+	{
+		// Codeberg runs Forgejo, which shares the URL layout of Gitea.
+		Name:    "Gitea/Forgejo",
+		Domains: []string{"codeberg.org", "gitea.com", "gitea.*", "forgejo.*"},
+		Repo: []Rule{
+			{`^https://([a-z0-9.-]+)/(` + segment + `)/(` + segment + `?)(?:\.git)?(?:/|\?.*)?$`, "https://$1/$2/$3.git"},
+		},
+		Archive: []Rule{
+			{`^https://([a-z0-9.-]+)/(` + segment + `)/(` + segment + `)/(?:archive|releases/download)/`, "https://$1/$2/$3.git"},
+		},
+	},
+	{
+		// The clone URLs of sourcehut have no .git suffix.
+		Name:    "sourcehut",
+		Domains: []string{"git.sr.ht"},
+		Repo: []Rule{
+			{`^https://git\.sr\.ht/(~` + segment + `)/(` + segment + `)/?$`, "https://git.sr.ht/$1/$2"},
+		},
+		Archive: []Rule{
+			{`^https://git\.sr\.ht/(~` + segment + `)/(` + segment + `)/(?:archive|refs/download)/`, "https://git.sr.ht/$1/$2"},
+		},
+	},
+	{
+		// The archives on the GNU FTP server and its mirrors are named after the package, which doesn't identify
+		// a Savannah repository, e.g. GCC or binutils aren't hosted on Savannah. Thus, only Savannah URLs are rewritten.
+		Name: "Savannah",
+		Domains: []string{
+			"savannah.gnu.org", "savannah.nongnu.org", "git.savannah.gnu.org", "git.savannah.nongnu.org",
+			"download.savannah.gnu.org", "download.savannah.nongnu.org",
+		},
+		Repo: []Rule{
+			{`^https://savannah\.(gnu|nongnu)\.org/projects/(` + segment + `?)/?$`, "https://git.savannah.$1.org/git/$2.git"},
+			{`^https://git\.savannah\.(gnu|nongnu)\.org/c?git/(` + segment + `?)(?:\.git)?/?$`, "https://git.savannah.$1.org/git/$2.git"},
+		},
+		Archive: []Rule{
+			{`^https://download\.savannah\.(gnu|nongnu)\.org/releases/(` + segment + `)/`, "https://git.savannah.$1.org/git/$2.git"},
+		},
+	},
+	{
+		// SourceForge projects name their repositories freely and may use other version control systems,
+		// thus project pages and downloads don't identify a repository. Only the URLs of git repositories are rewritten.
+		Name:    "SourceForge",
+		Domains: []string{"sourceforge.net", "*.sourceforge.net", "*.sourceforge.io", "git.code.sf.net"},
+		Repo: []Rule{
+			{`^https://git\.code\.sf\.net/p/(` + segment + `)/(` + segment + `)/?$`, "https://git.code.sf.net/p/$1/$2"},
+		},
+	},
+	{
+		// Releases are published below pub/linux, mirroring the path of the repository below pub/scm.
+		Name:    "kernel.org",
+		Domains: []string{"git.kernel.org", "kernel.org", "www.kernel.org", "cdn.kernel.org", "mirrors.edge.kernel.org"},
+		Repo: []Rule{
+			{`^https://git\.kernel\.org/pub/scm/((?:` + segment + `/)*` + segment + `?)(?:\.git)?/?$`, "https://git.kernel.org/pub/scm/$1.git"},
+		},
+		Archive: []Rule{
+			{`^https://(?:www\.|cdn\.|mirrors\.edge\.)?kernel\.org/pub/software/scm/([a-zA-Z0-9_+-]+)/[^/]+$`, "https://git.kernel.org/pub/scm/$1/$1.git"},
+			{`^https://(?:www\.|cdn\.|mirrors\.edge\.)?kernel\.org/pub/linux/((?:` + segment + `/)*)([a-zA-Z0-9_+-]+)/(?:v[0-9.]+/)?[^/]+$`, "https://git.kernel.org/pub/scm/$1$2/$2.git"},
+		},
+	},
+	{
+		Name:     "PyPI",
+		Domains:  []string{"files.pythonhosted.org", "pypi.org", "pypi.io", "pypi.python.org"},
+		Registry: true,
+	},
+	{
+		Name:     "crates.io",
+		Domains:  []string{"crates.io", "static.crates.io"},
+		Registry: true,
+	},
+	{
+		Name:     "npm",
+		Domains:  []string{"registry.npmjs.org", "www.npmjs.com"},
+		Registry: true,
+	},
+}
+
+// RepoURL returns the clone URL of the repository at the given URL, such as the homepage of a formula.
+// It returns false if the URL doesn't match the repository rules of any known host.
+func RepoURL(rawURL string) (string, bool) {
+	return rewrite(rawURL, func(h *Host) []Rule { return h.Repo })
+}
+
+// ArchiveRepoURL returns the clone URL of the repository the archive at the given URL has been released from.
+// Like the URL of an archive, the URL of a repository is accepted.
+// It returns false if the URL doesn't match the archive or repository rules of any known host.
+func ArchiveRepoURL(rawURL string) (string, bool) {
+	if repoURL, ok := rewrite(rawURL, func(h *Host) []Rule { return h.Archive }); ok {
+		return repoURL, true
+	}
+	return RepoURL(rawURL)
+}
+
+// Lookup returns the known host of the given URL, or nil if the host is unknown.
+func Lookup(rawURL string) *Host {
+	domain := Domain(rawURL)
+	for _, h := range Hosts {
+		if h.matches(domain) {
+			return h
+		}
+	}
+	return nil
+}
+
+// Domain returns the lower case domain of the given URL, or an empty string if the URL can't be parsed.
+func Domain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// rewrite rewrites the given URL by the first matching rule of the hosts of its domain.
+// The rules of a host are returned by rules.
+func rewrite(rawURL string, rules func(*Host) []Rule) (string, bool) {
+	domain := Domain(rawURL)
+	if domain == "" {
+		return "", false
+	}
+
+	for _, h := range Hosts {
+		if !h.matches(domain) {
+			continue
+		}
+		for _, r := range rules(h) {
+			re := pattern.Get(r.Pattern)
+			if m := re.FindStringSubmatchIndex(rawURL); m != nil {
+				return string(re.ExpandString(nil, r.Template, rawURL, m)), true
+			}
+		}
+	}
+	return "", false
+}
+
+// matches returns true if the given domain is one of the host's domains.
+func (h *Host) matches(domain string) bool {
+	for _, d := range h.Domains {
+		switch {
+		case strings.HasPrefix(d, "*."):
+			if strings.HasSuffix(domain, d[1:]) {
+				return true
+			}
+		case strings.HasSuffix(d, ".*"):
+			if strings.HasPrefix(domain, d[:len(d)-1]) {
+				return true
+			}
+		case d == domain:
+			return true
+		}
+	}
+	return false
+}
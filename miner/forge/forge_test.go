@@ -0,0 +1,85 @@
+package forge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var repoURLTests = []struct {
+	input    string
+	expected string
+}{
+	{"https://github.com/wireshark/wireshark", "https://github.com/wireshark/wireshark.git"},
+	{"https://github.com/wireshark/wireshark.git", "https://github.com/wireshark/wireshark.git"},
+	{"https://github.com/wireshark/wireshark/", "https://github.com/wireshark/wireshark.git"},
+	{"https://github.com/wireshark", ""},
+	{"https://gitlab.com/graphviz/graphviz", "https://gitlab.com/graphviz/graphviz.git"},
+	{"https://gitlab.freedesktop.org/xorg/lib/libx11", "https://gitlab.freedesktop.org/xorg/lib/libx11.git"},
+	{"https://bitbucket.org/multicoreware/x265_git", "https://bitbucket.org/multicoreware/x265_git.git"},
+	{"https://codeberg.org/dnkl/foot", "https://codeberg.org/dnkl/foot.git"},
+	{"https://gitea.example.org/owner/tool/", "https://gitea.example.org/owner/tool.git"},
+	{"https://git.sr.ht/~sircmpwn/scdoc", "https://git.sr.ht/~sircmpwn/scdoc"},
+	{"https://www.gnu.org/software/bash/", ""},
+	{"https://savannah.nongnu.org/projects/lzip", "https://git.savannah.nongnu.org/git/lzip.git"},
+	{"https://git.savannah.gnu.org/cgit/sed.git", "https://git.savannah.gnu.org/git/sed.git"},
+	{"https://srecord.sourceforge.net/", ""},
+	{"https://sourceforge.net/projects/srecord/", ""},
+	{"https://git.code.sf.net/p/srecord/code", "https://git.code.sf.net/p/srecord/code"},
+	{"https://git.kernel.org/pub/scm/utils/util-linux/util-linux.git", "https://git.kernel.org/pub/scm/utils/util-linux/util-linux.git"},
+	{"https://www.wireshark.org", ""},
+	{"https://pypi.org/project/requests/", ""},
+	{"not a url", ""},
+}
+
+func TestRepoURL(t *testing.T) {
+	for _, test := range repoURLTests {
+		repoURL, ok := RepoURL(test.input)
+		assert.Equal(t, test.expected != "", ok, test.input)
+		assert.Equal(t, test.expected, repoURL, test.input)
+	}
+}
+
+var archiveRepoURLTests = []struct {
+	input    string
+	expected string
+}{
+	{"https://github.com/wireshark/wireshark/archive/refs/tags/v4.2.3.tar.gz", "https://github.com/wireshark/wireshark.git"},
+	{"https://github.com/example/tool/releases/download/v1.4.2/tool-linux-amd64.tar.gz", "https://github.com/example/tool.git"},
+	{"https://codeload.github.com/example/tool/tar.gz/refs/tags/v1.0", "https://github.com/example/tool.git"},
+	{"https://gitlab.com/graphviz/graphviz/-/archive/9.0.0/graphviz-9.0.0.tar.bz2", "https://gitlab.com/graphviz/graphviz.git"},
+	{"https://gitlab.freedesktop.org/xorg/lib/libx11/-/archive/libX11-1.8.7/libx11-libX11-1.8.7.tar.bz2", "https://gitlab.freedesktop.org/xorg/lib/libx11.git"},
+	{"https://gitlab.gnome.org/GNOME/glib/-/releases/2.78.0/downloads/glib-2.78.0.tar.xz", "https://gitlab.gnome.org/GNOME/glib.git"},
+	{"https://bitbucket.org/multicoreware/x265_git/downloads/x265_3.5.tar.gz", "https://bitbucket.org/multicoreware/x265_git.git"},
+	{"https://codeberg.org/dnkl/foot/archive/1.16.2.tar.gz", "https://codeberg.org/dnkl/foot.git"},
+	{"https://gitea.com/owner/tool/releases/download/v1.0/tool-1.0.tar.gz", "https://gitea.com/owner/tool.git"},
+	{"https://git.sr.ht/~sircmpwn/scdoc/archive/1.11.2.tar.gz", "https://git.sr.ht/~sircmpwn/scdoc"},
+	{"https://ftp.gnu.org/gnu/gcc/gcc-13.2.0/gcc-13.2.0.tar.xz", ""},
+	{"https://ftpmirror.gnu.org/binutils/binutils-2.41.tar.bz2", ""},
+	{"https://mirrors.kernel.org/gnu/gmp/gmp-6.3.0.tar.xz", ""},
+	{"https://download.savannah.nongnu.org/releases/lzip/lzip-1.24.tar.gz", "https://git.savannah.nongnu.org/git/lzip.git"},
+	{"https://downloads.sourceforge.net/project/srecord/srecord/1.64/srecord-1.64.tar.gz", ""},
+	{"https://downloads.sourceforge.net/lzmautils/xz-5.4.5.tar.gz", ""},
+	{"https://mirrors.edge.kernel.org/pub/linux/utils/util-linux/v2.39/util-linux-2.39.3.tar.xz", "https://git.kernel.org/pub/scm/utils/util-linux/util-linux.git"},
+	{"https://mirrors.edge.kernel.org/pub/linux/utils/kernel/kmod/kmod-31.tar.xz", "https://git.kernel.org/pub/scm/utils/kernel/kmod/kmod.git"},
+	{"https://mirrors.edge.kernel.org/pub/software/scm/git/git-2.43.0.tar.xz", "https://git.kernel.org/pub/scm/git/git.git"},
+	{"https://files.pythonhosted.org/packages/9d/be/requests-2.31.0.tar.gz", ""},
+	{"https://static.crates.io/crates/ripgrep/ripgrep-14.0.3.crate", ""},
+	{"https://registry.npmjs.org/npm/-/npm-10.2.5.tgz", ""},
+	{"https://www.example.com/tool-1.0.tar.gz", ""},
+}
+
+func TestArchiveRepoURL(t *testing.T) {
+	for _, test := range archiveRepoURLTests {
+		repoURL, ok := ArchiveRepoURL(test.input)
+		assert.Equal(t, test.expected != "", ok, test.input)
+		assert.Equal(t, test.expected, repoURL, test.input)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	assert.Equal(t, "SourceForge", Lookup("https://srecord.sourceforge.net/").Name)
+	assert.Equal(t, "Gitea/Forgejo", Lookup("https://forgejo.example.org/a/b").Name)
+	assert.True(t, Lookup("https://files.pythonhosted.org/packages/requests-2.31.0.tar.gz").Registry)
+	assert.Nil(t, Lookup("https://www.example.com/tool-1.0.tar.gz"))
+}
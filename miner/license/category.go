@@ -0,0 +1,122 @@
+package license
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Categories of licenses, ordered from the most restrictive to the most permissive.
+// A license is of the unknown category if it isn't listed in the table of categories.
+const (
+	CategoryUnknown        = "unknown"
+	CategoryStrongCopyleft = "strong-copyleft"
+	CategoryWeakCopyleft   = "weak-copyleft"
+	CategoryPermissive     = "permissive"
+	CategoryPublicDomain   = "public-domain"
+)
+
+// categoryRanks holds the rank of each category, where a higher rank is more permissive.
+var categoryRanks = map[string]int{
+	CategoryUnknown:        0,
+	CategoryStrongCopyleft: 1,
+	CategoryWeakCopyleft:   2,
+	CategoryPermissive:     3,
+	CategoryPublicDomain:   4,
+}
+
+//go:embed categories.yml
+var defaultCategories []byte
+
+// Categories maps licenses to their category, where the key is the lower case license identifier.
+// A license with an exception, e.g. "GPL-2.0-only WITH Classpath-exception-2.0", may have a category of its own.
+type Categories map[string]string
+
+// DefaultCategories returns the embedded table of the categories of common SPDX license identifiers.
+func DefaultCategories() Categories {
+	c := make(Categories)
+	if err := c.parse(defaultCategories); err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// LoadCategories returns the default categories, overridden by the YAML file at the given path, if any.
+// The file lists the licenses by category, e.g. "permissive: [MIT, ISC]".
+func LoadCategories(path string) (Categories, error) {
+	c := DefaultCategories()
+	if path == "" {
+		return c, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.parse(data); err != nil {
+		return nil, fmt.Errorf("invalid license categories %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// parse adds the licenses of the given YAML table to the categories, replacing their existing category.
+func (c Categories) parse(data []byte) error {
+	table := make(map[string][]string)
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		return err
+	}
+	for category, licenses := range table {
+		if _, ok := categoryRanks[category]; !ok {
+			return fmt.Errorf("unknown license category %s", category)
+		}
+		for _, l := range licenses {
+			c[strings.ToLower(l)] = category
+		}
+	}
+	return nil
+}
+
+// Classify returns the category of the given license expression.
+// The category of an OR expression is the most permissive category of its choices, and the category of an AND
+// expression is the most restrictive category of its operands. A license with an exception has the category
+// of the license, unless the license with the exception is listed itself.
+// Licenses which can't be parsed are looked up as a whole.
+func (c Categories) Classify(s string) string {
+	e, err := Parse(s)
+	if err != nil {
+		return c.lookup(s)
+	}
+	return c.classify(e)
+}
+
+// classify returns the category of the given expression, see Classify.
+func (c Categories) classify(e *Expression) string {
+	switch e.Op {
+	case OpOr, OpAnd:
+		category := c.classify(e.Args[0])
+		for _, a := range e.Args[1:] {
+			other := c.classify(a)
+			if (e.Op == OpOr) == (categoryRanks[other] > categoryRanks[category]) {
+				category = other
+			}
+		}
+		return category
+	case OpWith:
+		if category, ok := c[strings.ToLower(e.License+" WITH "+e.Exception)]; ok {
+			return category
+		}
+		return c.lookup(e.License)
+	default:
+		return c.lookup(e.License)
+	}
+}
+
+// lookup returns the category of the given license identifier.
+func (c Categories) lookup(license string) string {
+	if category, ok := c[strings.ToLower(license)]; ok {
+		return category
+	}
+	return CategoryUnknown
+}
@@ -0,0 +1,62 @@
+package license
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var classifyTests = []struct {
+	license  string
+	expected string
+}{
+	{"MIT", CategoryPermissive},
+	{"mit", CategoryPermissive},
+	{"Public Domain", CategoryPublicDomain},
+	{"LGPL-2.1-or-later", CategoryWeakCopyleft},
+	{"GPL-3.0-only", CategoryStrongCopyleft},
+	{"pseudo", CategoryUnknown},
+	{"Cannot Represent", CategoryUnknown},
+	{"", CategoryUnknown},
+	{"GPL-2.0-only or MIT", CategoryPermissive},
+	{"GPL-2.0-only and MIT", CategoryStrongCopyleft},
+	{"MIT or Public Domain or (0BSD and Zlib and Artistic-1.0+)", CategoryPublicDomain},
+	{"(MIT or GPL-3.0-only) and (LGPL-2.1-only or GPL-2.0-only)", CategoryWeakCopyleft},
+	{"GPL-2.0-or-later with Classpath-exception-2.0", CategoryWeakCopyleft},
+	{"GPL-3.0-only with Qt-GPL-exception-1.0", CategoryStrongCopyleft},
+	{"MIT and Cannot Represent", CategoryUnknown},
+}
+
+func TestClassify(t *testing.T) {
+	categories := DefaultCategories()
+	for _, test := range classifyTests {
+		assert.Equal(t, test.expected, categories.Classify(test.license), test.license)
+	}
+}
+
+func TestLoadCategories(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "categories.yml")
+	if err := os.WriteFile(path, []byte("permissive: [pseudo]\nstrong-copyleft: [MIT]\n"), 0644); err != nil {
+		log.Fatal(err)
+	}
+	categories, err := LoadCategories(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, CategoryPermissive, categories.Classify("pseudo"))
+	assert.Equal(t, CategoryStrongCopyleft, categories.Classify("MIT"))
+	assert.Equal(t, CategoryPermissive, categories.Classify("ISC"))
+
+	if err := os.WriteFile(path, []byte("copyleft: [GPL-2.0-only]\n"), 0644); err != nil {
+		log.Fatal(err)
+	}
+	_, err = LoadCategories(path)
+	assert.Error(t, err)
+
+	_, err = LoadCategories(filepath.Join(dir, "missing.yml"))
+	assert.Error(t, err)
+}
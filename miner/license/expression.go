@@ -0,0 +1,252 @@
+package license
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Operators of license expressions.
+const (
+	OpAnd  = "AND"
+	OpOr   = "OR"
+	OpWith = "WITH"
+)
+
+// Terms of the license field of a formula which aren't SPDX license identifiers.
+const (
+	// PublicDomain is the license of formulae in the public domain.
+	PublicDomain = "Public Domain"
+
+	// CannotRepresent is the license of formulae whose license can't be represented by SPDX identifiers.
+	CannotRepresent = "Cannot Represent"
+)
+
+// PublicDomainRef is the SPDX license reference of PublicDomain, as it has no SPDX license identifier.
+const PublicDomainRef = "LicenseRef-Public-Domain"
+
+// Expression is a parsed license expression.
+// It is either a single license, a license with an exception, or a conjunction or disjunction of expressions.
+type Expression struct {
+	// Op is the operator of the expression, or empty for a single license.
+	Op string
+
+	// License is the identifier of a single license or the license of a WITH expression.
+	License string
+
+	// Exception is the identifier of the exception of a WITH expression.
+	Exception string
+
+	// Args holds the operands of an AND or OR expression.
+	Args []*Expression
+}
+
+// Parse parses the given license expression, either the license field of a formula, e.g. "MIT or (GPL-2.0-only with
+// Classpath-exception-2.0)", or an SPDX license expression. Operators are case insensitive, and AND binds stronger than OR.
+// The terms "Public Domain" and "Cannot Represent" are parsed as a single license.
+func Parse(s string) (*Expression, error) {
+	p := &exprParser{tokens: tokenize(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in license expression %q", p.tokens[p.pos], s)
+	}
+	return e, nil
+}
+
+// String formats the expression as an SPDX license expression, where nested expressions are parenthesized.
+// The term "Public Domain" is formatted as the license reference "LicenseRef-Public-Domain".
+func (e *Expression) String() string {
+	switch e.Op {
+	case OpWith:
+		return spdxID(e.License) + " WITH " + e.Exception
+	case OpAnd, OpOr:
+		args := make([]string, 0, len(e.Args))
+		for _, a := range e.Args {
+			if a.Op == OpAnd || a.Op == OpOr {
+				args = append(args, "("+a.String()+")")
+			} else {
+				args = append(args, a.String())
+			}
+		}
+		return strings.Join(args, " "+e.Op+" ")
+	default:
+		return spdxID(e.License)
+	}
+}
+
+// Licenses returns the sorted unique licenses of the expression, excluding exceptions.
+func (e *Expression) Licenses() []string {
+	seen := make(map[string]bool)
+	e.walk(func(l *Expression) {
+		seen[l.License] = true
+	})
+	licenses := make([]string, 0, len(seen))
+	for l := range seen {
+		licenses = append(licenses, l)
+	}
+	sort.Strings(licenses)
+	return licenses
+}
+
+// Representable returns true if the expression can be represented as an SPDX license expression,
+// i.e. none of its licenses is "Cannot Represent".
+func (e *Expression) Representable() bool {
+	ok := true
+	e.walk(func(l *Expression) {
+		if l.License == CannotRepresent {
+			ok = false
+		}
+	})
+	return ok
+}
+
+// walk calls fn with each single license and WITH expression of the expression.
+func (e *Expression) walk(fn func(*Expression)) {
+	if e.Op == OpAnd || e.Op == OpOr {
+		for _, a := range e.Args {
+			a.walk(fn)
+		}
+		return
+	}
+	fn(e)
+}
+
+// spdxID returns the SPDX identifier of the given license.
+func spdxID(license string) string {
+	if license == PublicDomain {
+		return PublicDomainRef
+	}
+	return license
+}
+
+// tokenize splits the given expression into parentheses, operators and licenses.
+func tokenize(s string) []string {
+	s = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(s)
+	fields := strings.Fields(s)
+
+	tokens := make([]string, 0, len(fields))
+	for i := 0; i < len(fields); i++ {
+		// Join the two words of the terms which aren't SPDX license identifiers.
+		if i+1 < len(fields) {
+			term := fields[i] + " " + fields[i+1]
+			if term == PublicDomain || term == CannotRepresent {
+				tokens = append(tokens, term)
+				i++
+				continue
+			}
+		}
+		tokens = append(tokens, fields[i])
+	}
+	return tokens
+}
+
+// exprParser is a recursive descent parser of license expressions.
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+// peekOp returns the upper case operator at the current position, or an empty string if there is none.
+func (p *exprParser) peekOp() string {
+	if p.pos >= len(p.tokens) {
+		return ""
+	}
+	switch op := strings.ToUpper(p.tokens[p.pos]); op {
+	case OpAnd, OpOr, OpWith:
+		return op
+	}
+	return ""
+}
+
+// parseOr parses a disjunction of conjunctions.
+func (p *exprParser) parseOr() (*Expression, error) {
+	return p.parseBinary(OpOr, p.parseAnd)
+}
+
+// parseAnd parses a conjunction of licenses.
+func (p *exprParser) parseAnd() (*Expression, error) {
+	return p.parseBinary(OpAnd, p.parseWith)
+}
+
+// parseBinary parses the operands joined by the given operator, where each operand is parsed by parseOperand.
+// Nested operands with the same operator are flattened.
+func (p *exprParser) parseBinary(op string, parseOperand func() (*Expression, error)) (*Expression, error) {
+	first, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	args := []*Expression{first}
+	for p.peekOp() == op {
+		p.pos++
+		next, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, next)
+	}
+	if len(args) == 1 {
+		return first, nil
+	}
+
+	flat := make([]*Expression, 0, len(args))
+	for _, a := range args {
+		if a.Op == op {
+			flat = append(flat, a.Args...)
+		} else {
+			flat = append(flat, a)
+		}
+	}
+	return &Expression{Op: op, Args: flat}, nil
+}
+
+// parseWith parses a license, optionally followed by an exception.
+func (p *exprParser) parseWith() (*Expression, error) {
+	e, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if p.peekOp() != OpWith {
+		return e, nil
+	}
+	if e.Op != "" {
+		return nil, fmt.Errorf("exception of a compound license expression")
+	}
+	p.pos++
+	if p.pos >= len(p.tokens) || p.peekOp() != "" || p.tokens[p.pos] == "(" || p.tokens[p.pos] == ")" {
+		return nil, fmt.Errorf("missing license exception")
+	}
+	e = &Expression{Op: OpWith, License: e.License, Exception: p.tokens[p.pos]}
+	p.pos++
+	return e, nil
+}
+
+// parseAtom parses a single license or a parenthesized expression.
+func (p *exprParser) parseAtom() (*Expression, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of license expression")
+	}
+	token := p.tokens[p.pos]
+	switch {
+	case token == "(":
+		p.pos++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos] != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return e, nil
+	case token == ")" || p.peekOp() != "":
+		return nil, fmt.Errorf("unexpected %q in license expression", token)
+	}
+	p.pos++
+	return &Expression{License: token}, nil
+}
@@ -0,0 +1,50 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var parseTests = []struct {
+	input    string
+	expected string
+	licenses []string
+}{
+	{"MIT", "MIT", []string{"MIT"}},
+	{"GPL-2.0-or-later or LGPL-2.1-or-later or MIT", "GPL-2.0-or-later OR LGPL-2.1-or-later OR MIT", []string{"GPL-2.0-or-later", "LGPL-2.1-or-later", "MIT"}},
+	{
+		"BSD-2-Clause and LGPL-2.0-only and LGPL-2.0-or-later and (LGPL-2.0-only or LGPL-3.0-only)",
+		"BSD-2-Clause AND LGPL-2.0-only AND LGPL-2.0-or-later AND (LGPL-2.0-only OR LGPL-3.0-only)",
+		[]string{"BSD-2-Clause", "LGPL-2.0-only", "LGPL-2.0-or-later", "LGPL-3.0-only"},
+	},
+	{"GPL-2.0-or-later with Classpath-exception-2.0", "GPL-2.0-or-later WITH Classpath-exception-2.0", []string{"GPL-2.0-or-later"}},
+	{
+		"MIT or Public Domain or (0BSD and Zlib and Artistic-1.0+) or (Apache-2.0 with LLVM-exception)",
+		"MIT OR LicenseRef-Public-Domain OR (0BSD AND Zlib AND Artistic-1.0+) OR Apache-2.0 WITH LLVM-exception",
+		[]string{"0BSD", "Apache-2.0", "Artistic-1.0+", "MIT", "Public Domain", "Zlib"},
+	},
+	{"MIT AND Zlib OR Apache-2.0", "(MIT AND Zlib) OR Apache-2.0", []string{"Apache-2.0", "MIT", "Zlib"}},
+	{"((MIT))", "MIT", []string{"MIT"}},
+}
+
+func TestParse(t *testing.T) {
+	for _, test := range parseTests {
+		e, err := Parse(test.input)
+		if assert.NoError(t, err, test.input) {
+			assert.Equal(t, test.expected, e.String(), test.input)
+			assert.Equal(t, test.licenses, e.Licenses(), test.input)
+			assert.True(t, e.Representable(), test.input)
+		}
+	}
+
+	e, err := Parse("Public Domain or Cannot Represent")
+	if assert.NoError(t, err) {
+		assert.False(t, e.Representable())
+	}
+
+	for _, invalid := range []string{"", "MIT or", "(MIT", "MIT)", "and MIT", "MIT with", "(MIT or Zlib) with LLVM-exception"} {
+		_, err := Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,59 @@
+package license
+
+import (
+	_ "embed"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed spdx.yml
+var spdxListData []byte
+
+// spdxList holds the identifiers of the SPDX License List.
+type spdxList struct {
+	// Licenses holds the lower case license identifiers.
+	Licenses map[string]bool
+
+	// Exceptions holds the lower case exception identifiers.
+	Exceptions map[string]bool
+}
+
+// listed holds the embedded SPDX License List.
+var listed = parseSPDXList(spdxListData)
+
+// parseSPDXList parses the given YAML list of license and exception identifiers.
+func parseSPDXList(data []byte) *spdxList {
+	var table struct {
+		Licenses   []string `yaml:"licenses"`
+		Exceptions []string `yaml:"exceptions"`
+	}
+	if err := yaml.Unmarshal(data, &table); err != nil {
+		panic(err)
+	}
+
+	l := &spdxList{Licenses: make(map[string]bool), Exceptions: make(map[string]bool)}
+	for _, id := range table.Licenses {
+		l.Licenses[strings.ToLower(id)] = true
+	}
+	for _, id := range table.Exceptions {
+		l.Exceptions[strings.ToLower(id)] = true
+	}
+	return l
+}
+
+// Listed returns true if each license and exception of the expression is on the SPDX License List,
+// i.e. the expression is a valid SPDX license expression. Identifiers are case insensitive and a license
+// may be followed by "+". The term "Public Domain" is listed, as it is formatted as its license reference.
+func (e *Expression) Listed() bool {
+	ok := true
+	e.walk(func(l *Expression) {
+		if l.Exception != "" && !listed.Exceptions[strings.ToLower(l.Exception)] {
+			ok = false
+		}
+		if l.License != PublicDomain && !listed.Licenses[strings.ToLower(strings.TrimSuffix(l.License, "+"))] {
+			ok = false
+		}
+	})
+	return ok
+}
@@ -0,0 +1,38 @@
+package license
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var listedTests = []struct {
+	input    string
+	expected bool
+}{
+	{"MIT", true},
+	{"mit", true},
+	{"GPL-2.0-or-later with Classpath-exception-2.0", true},
+	{"MIT or Public Domain or (0BSD and Zlib and Artistic-1.0+)", true},
+	{"GPL-2.0", true}, // deprecated
+	{"pseudo", false},
+	{"MIT or pseudo", false},
+	{"Apache-2.0 with pseudo-exception", false},
+	{"Cannot Represent", false},
+}
+
+func TestListed(t *testing.T) {
+	for _, test := range listedTests {
+		e, err := Parse(test.input)
+		if assert.NoError(t, err, test.input) {
+			assert.Equal(t, test.expected, e.Listed(), test.input)
+		}
+	}
+
+	// Each license of the default categories is listed.
+	for l := range DefaultCategories() {
+		if e, err := Parse(l); err == nil {
+			assert.True(t, e.Listed(), l)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package miner
+
+import (
+	"runtime/debug"
+	"time"
+
+	"main/config"
+	"main/miner/writer"
+)
+
+// Version is the version of the miner written to the manifest of each run.
+// It may be set at build time, e.g. -ldflags "-X main/miner.Version=v1.2.0".
+// If it is empty, the version is taken from the build information of the binary.
+var Version = ""
+
+// ToolVersion returns the version of the miner, which is written to the manifest of each run and to exported BOMs.
+// Without an explicitly set Version, it is the VCS revision the binary has been built from, if known.
+func ToolVersion() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return info.Main.Version
+}
+
+// newManifest creates the manifest of a run of the given config started at the given time,
+// mining the given commit of the core repository.
+func newManifest(config *config.Config, commit string, start time.Time) (*writer.Manifest, error) {
+	hash, err := config.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return &writer.Manifest{
+		ToolVersion:    ToolVersion(),
+		ConfigHash:     hash,
+		CoreRepoCommit: commit,
+		StartedAt:      start,
+		Files:          make([]*writer.ManifestFile, 0),
+	}, nil
+}
@@ -0,0 +1,64 @@
+package miner
+
+import (
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// memorySampleInterval is the interval at which the heap memory in use is sampled.
+const memorySampleInterval = 50 * time.Millisecond
+
+// heapObjectsMetric is the metric of the heap memory occupied by live and not yet swept objects.
+const heapObjectsMetric = "/memory/classes/heap/objects:bytes"
+
+// memorySampler periodically samples the heap memory in use and keeps its peak.
+type memorySampler struct {
+	done chan struct{}
+	wg   sync.WaitGroup
+
+	// The peak heap memory in bytes.
+	peak uint64
+}
+
+// startMemorySampler starts sampling the heap memory in use until the sampler is stopped.
+func startMemorySampler() *memorySampler {
+	s := &memorySampler{done: make(chan struct{})}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(memorySampleInterval)
+		defer ticker.Stop()
+
+		sample := []metrics.Sample{{Name: heapObjectsMetric}}
+		for {
+			s.sample(sample)
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return s
+}
+
+// sample reads the heap memory in use and updates the peak.
+func (s *memorySampler) sample(sample []metrics.Sample) {
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return
+	}
+	if v := sample[0].Value.Uint64(); v > s.peak {
+		s.peak = v
+	}
+}
+
+// stop stops sampling and returns the peak heap memory in bytes.
+func (s *memorySampler) stop() uint64 {
+	close(s.done)
+	s.wg.Wait()
+	return s.peak
+}
@@ -1,35 +1,369 @@
 package miner
 
 import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
 	"main/config"
+	"main/miner/coverage"
+	"main/miner/license"
 	"main/miner/reader"
+	"main/miner/stats"
 	"main/miner/types"
 	"main/miner/writer"
 )
 
-type miner struct {
+// Miner mines the formulae of a Homebrew core repository.
+// It is created with New and configured by options.
+type Miner struct {
+	// The source of the formula files.
+	source reader.Source
+
+	// The configuration of the reader.
+	readerConfig config.ReaderConfig
+
+	// A boolean flag indicating whether a coverage report is created.
+	coverage bool
+
+	// The hooks called with each formula once it has been read.
+	hooks []func(*types.Formula)
+
+	// The categories the licenses of the formulae are classified by, loaded once the miner is validated.
+	categories license.Categories
+
+	// The SHA of the commit checked out in the core repository, which is written to the manifest.
+	commit string
+
+	// The application config, if the miner has been created by NewMiner.
 	config *config.Config
 
 	// A map of formulae, where the key is the name of the formula.
 	formulae map[string]*types.Formula
+
+	// The coverage report of the formula files, if enabled.
+	report *coverage.Report
+
+	// A boolean flag indicating whether reading the formulae has been interrupted.
+	partial bool
+
+	// The peak heap memory in bytes sampled during the last run.
+	peakMemory uint64
+
+	// The directory the output files of the last run have been written to.
+	outputDir string
+
+	// The summary statistics of the formulae written by the last run.
+	summary *stats.Summary
 }
 
-// NewMiner creates a new parser.
-func NewMiner(config *config.Config) *miner {
-	return &miner{
-		config:   config,
+// New creates a new Miner configured by the given options.
+// By default, the miner uses 10 workers, derives no repository URLs and uses no fallback license.
+// A source must be provided using either WithSource or WithCoreRepo.
+func New(opts ...Option) *Miner {
+	m := &Miner{
+		readerConfig: config.ReaderConfig{
+			MaxWorkers: defaultMaxWorkers,
+		},
+		hooks:    make([]func(*types.Formula), 0),
 		formulae: make(map[string]*types.Formula),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewMiner creates a new Miner from the given application config.
+// The given options are applied after the ones derived from the config.
+func NewMiner(config *config.Config, opts ...Option) *Miner {
+	m := New(append([]Option{
+		WithCoreRepo(config.CoreRepo.Dir),
+		WithMaxWorkers(config.Reader.MaxWorkers),
+		WithParseTimeout(config.Reader.ParseTimeout),
+		WithFallbackLicense(config.Reader.FallbackLicense),
+		WithLicenseCategories(config.Reader.LicenseCategories),
+		WithDeriveRepo(config.Reader.DeriveRepo),
+		WithLegacyParser(config.Reader.LegacyParser),
+		WithCoverage(config.Coverage),
+	}, opts...)...)
+	m.config = config
+	return m
+}
+
+// Mine reads all formulae from the source without writing any files.
+// It returns the result and the first encountered error.
+// Mining stops as soon as the given context is canceled. In this case,
+// the result holds the formulae read so far and the context's error is returned.
+func (m *Miner) Mine(ctx context.Context) (*Result, error) {
+	if err := m.validate(); err != nil {
+		return nil, err
+	}
+
+	var report *coverage.Report
+	if m.coverage {
+		report = coverage.NewReport()
+	}
+
+	formulae, err := reader.ReadFormulae(ctx, m.source, m.readerConfig, report, m.process)
+	if formulae == nil {
+		return nil, err
+	}
+
+	return &Result{
+		formulae: formulae,
+		coverage: report,
+	}, err
+}
+
+// Run reads all formulae from the source and streams them to the output files of the application config.
+// Each formula is written as soon as it has been read, such that the formulae are never held in memory at once.
+// Summary statistics of the formulae are collected along the way, see Summary.
+// If reading fails or the given context is canceled, the formulae read so far are written to partial output files
+// and the error is returned. Errors writing the output files wrap ErrWrite.
+//
+// The output files are written to the directory chosen by the output policy of the application config,
+// along with a manifest describing the run. Once the run has completed, the versioned policy removes the
+// oldest run directories exceeding the number of kept runs.
+func (m *Miner) Run(ctx context.Context) error {
+	if m.config == nil {
+		return ErrNoConfig
+	}
+	if err := m.validate(); err != nil {
+		return err
+	}
+
+	sampler := startMemorySampler()
+	defer func() { m.peakMemory = sampler.stop() }()
+
+	start := time.Now()
+	manifest, err := newManifest(m.config, m.commit, start)
+	if err != nil {
+		return err
+	}
+
+	m.outputDir = m.config.OutputDir
+	if m.config.Policy() == config.OutputPolicyVersioned {
+		if m.outputDir, err = writer.NewRunDir(m.config.OutputDir, start); err != nil {
+			return fmt.Errorf("%w: %w", ErrWrite, err)
+		}
+	}
+
+	streams, err := m.openStreams()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, err)
+	}
+
+	var report *coverage.Report
+	if m.coverage {
+		report = coverage.NewReport()
+	}
+
+	// Reading is canceled if a formula can't be written.
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	collector := stats.NewCollector(m.readerConfig.FallbackLicense, stats.DefaultTop)
+	defer func() { m.summary = collector.Summary() }()
+
+	out := make(chan *types.Formula)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- reader.StreamFormulae(readCtx, m.source, m.readerConfig, report, out)
+	}()
+
+	var writeErr error
+	for formula := range out {
+		m.process(formula)
+		for _, s := range streams {
+			if writeErr != nil {
+				break
+			}
+			if writeErr = s.Write(formula); writeErr != nil {
+				cancel()
+			}
+		}
+		collector.Add(formula)
+		manifest.Counts.Formulae++
+		manifest.Counts.Dependencies += len(formula.Dependencies)
+		manifest.Counts.Conflicts += len(formula.Conflicts)
+	}
+
+	readErr := <-errCh
+
+	// Complete the output files, which are marked as partial if reading has failed or been interrupted.
+	m.partial = readErr != nil || writeErr != nil
+	for _, s := range streams {
+		if err := s.Close(m.partial); err != nil && writeErr == nil {
+			writeErr = err
+		}
+		if writeErr == nil {
+			writeErr = manifest.AddFile(s.Path())
+		}
+	}
+	if writeErr == nil && readErr == nil && report != nil {
+		var path string
+		if path, writeErr = writer.WriteCoverage(m.outputDir, report); writeErr == nil {
+			writeErr = manifest.AddFile(path)
+		}
+	}
+
+	// The manifest is only written if all output files have been completed.
+	if writeErr == nil {
+		manifest.FinishedAt = time.Now()
+		manifest.Partial = m.partial
+		writeErr = writer.WriteManifest(m.outputDir, manifest)
+	}
+	if writeErr != nil {
+		return fmt.Errorf("%w: %w", ErrWrite, writeErr)
+	}
+	if readErr != nil {
+		return readErr
+	}
+
+	if m.config.Policy() == config.OutputPolicyVersioned && m.config.KeepRuns > 0 {
+		if _, err := writer.PruneRunDirs(m.config.OutputDir, m.config.KeepRuns); err != nil {
+			return fmt.Errorf("%w: %w", ErrWrite, err)
+		}
+	}
+	return nil
+}
+
+// openStreams opens the streams of the output files enabled by the application config.
+func (m *Miner) openStreams() ([]writer.Stream, error) {
+	open := []func(string) (writer.Stream, error){
+		func(dir string) (writer.Stream, error) { return writer.NewTSVStream(dir) },
+	}
+	if m.config.JSON {
+		open = append(open, func(dir string) (writer.Stream, error) { return writer.NewJSONStream(dir) })
+	}
+	if m.config.Provenance {
+		open = append(open, func(dir string) (writer.Stream, error) { return writer.NewProvenanceStream(dir) })
+	}
+
+	streams := make([]writer.Stream, 0, len(open))
+	for _, o := range open {
+		s, err := o(m.outputDir)
+		if err != nil {
+			// Abort the streams opened so far, such that no output file is written.
+			for _, s := range streams {
+				s.Abort()
+			}
+			return nil, err
+		}
+		streams = append(streams, s)
+	}
+	return streams, nil
+}
+
+// OutputDir returns the directory the output files of the last run have been written to.
+func (m *Miner) OutputDir() string {
+	return m.outputDir
+}
+
+// Summary returns the summary statistics of the formulae read by the last run, or nil if no run has been started.
+func (m *Miner) Summary() *stats.Summary {
+	return m.summary
+}
+
+// PeakMemory returns the peak heap memory in bytes sampled during the last run.
+func (m *Miner) PeakMemory() uint64 {
+	return m.peakMemory
+}
+
+// validate returns an error if the miner can't read any formulae.
+func (m *Miner) validate() error {
+	if m.source == nil {
+		return ErrNoSource
+	}
+	if m.readerConfig.MaxWorkers <= 0 {
+		return ErrInvalidMaxWorkers
+	}
+	return m.loadCategories()
+}
+
+// loadCategories loads the license categories, unless they have already been loaded.
+func (m *Miner) loadCategories() error {
+	if m.categories != nil {
+		return nil
+	}
+	categories, err := license.LoadCategories(m.readerConfig.LicenseCategories)
+	if err != nil {
+		return err
+	}
+	m.categories = categories
+	return nil
+}
+
+// MineFormula reads the formula with the given name from the source.
+func (m *Miner) MineFormula(name string) (*types.Formula, error) {
+	if m.source == nil {
+		return nil, ErrNoSource
+	}
+	if err := m.loadCategories(); err != nil {
+		return nil, err
+	}
+	formula, err := reader.ReadFormula(m.source, name, m.readerConfig)
+	if err != nil {
+		return nil, err
+	}
+	formula.LicenseCategory = m.categories.Classify(formula.License)
+	return formula, nil
 }
 
-// ReadFormaulas reads all formulae from the core repository into the formulas map.
-func (m *miner) ReadFormulae() error {
-	f, err := reader.ReadFormulae(m.config.CoreRepo.Dir, m.config.Reader)
-	m.formulae = f
+// process classifies the license of the given formula and calls the hooks with it.
+func (m *Miner) process(formula *types.Formula) {
+	formula.LicenseCategory = m.categories.Classify(formula.License)
+	for _, hook := range m.hooks {
+		hook(formula)
+	}
+}
+
+// ReadFormulae reads all formulae from the core repository into the formulae map.
+// If the given context is canceled, the formulae read so far are kept, such that they can still be written,
+// and the context's error is returned.
+func (m *Miner) ReadFormulae(ctx context.Context) error {
+	result, err := m.Mine(ctx)
+	if result == nil {
+		return err
+	}
+	m.formulae = result.formulae
+	m.report = result.coverage
+	m.partial = err != nil
 	return err
 }
 
-// WriteFormulae writes the formulae to the output file.
-func (m *miner) WriteFormulae() error {
-	return writer.WriteFormulae(m.config.OutputDir, m.formulae)
+// WriteFormulae writes the formulae to the output file of the application config.
+// If enabled, the formulae are additionally written as JSON, and the provenance of the formulae's values
+// and the coverage report are written to separate output files.
+func (m *Miner) WriteFormulae() error {
+	if err := writer.WriteFormulae(m.config.OutputDir, m.formulae, m.partial); err != nil {
+		return err
+	}
+	if m.config.JSON {
+		if err := writer.WriteJSON(m.config.OutputDir, m.formulae); err != nil {
+			return err
+		}
+	}
+	if m.config.Provenance {
+		if err := writer.WriteProvenance(m.config.OutputDir, m.formulae); err != nil {
+			return err
+		}
+	}
+	if m.report != nil {
+		_, err := writer.WriteCoverage(m.config.OutputDir, m.report)
+		return err
+	}
+	return nil
+}
+
+// ExplainFormula reads the formula with the given name from the core repository
+// and writes each of its values next to the source lines it has been extracted from.
+func (m *Miner) ExplainFormula(name string, w io.Writer) error {
+	formula, err := m.MineFormula(name)
+	if err != nil {
+		return err
+	}
+	return writer.Explain(w, formula)
 }
@@ -1,17 +1,28 @@
 package miner
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/fstest"
 
 	"main/config"
+	"main/miner/parser"
+	"main/miner/reader"
+	"main/miner/ruby"
+	"main/miner/setup"
 	"main/miner/types"
+	"main/miner/writer"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -23,7 +34,7 @@ func TestReadFormulae(t *testing.T) {
 
 	parser := NewMiner(config)
 
-	if err := parser.ReadFormulae(); err != nil {
+	if err := parser.ReadFormulae(context.Background()); err != nil {
 		log.Fatal(err)
 	}
 
@@ -60,7 +71,11 @@ func TestReadFormulae(t *testing.T) {
 				archiveUrl = strings.TrimRight(archiveUrl, "/")
 				archiveUrl += "/tree/" + tag
 			}
-			assert.Equal(t, archiveUrl, formula.ArchiveURL, "expected: %s as archive url of %s, got: %s", archiveUrl, name, formula.ArchiveURL)
+			archiveURLs := make([]string, 0)
+			for _, archive := range formula.ArchiveURL {
+				archiveURLs = append(archiveURLs, archive.URL)
+			}
+			assert.Contains(t, archiveURLs, archiveUrl, "expected: %s as archive url of %s, got: %v", archiveUrl, name, archiveURLs)
 		}
 
 		// Assert heads are equal.
@@ -95,6 +110,360 @@ func TestReadFormulae(t *testing.T) {
 	}
 }
 
+func TestMine(t *testing.T) {
+	source := reader.NewFSSource(fstest.MapFS{
+		"Formula/f/foo.rb": {Data: []byte(`class Foo < Formula
+  homepage "https://github.com/example/foo"
+  url "https://github.com/example/foo/archive/refs/tags/v1.0.tar.gz"
+  license "MIT"
+end`)},
+		"Formula/b/bar.rb": {Data: []byte(`class Bar < Formula
+  url "https://example.com/bar-2.0.tar.gz"
+  depends_on "foo"
+end`)},
+		"Aliases/baz": {Data: []byte(`class Bar < Formula
+  url "https://example.com/bar-2.0.tar.gz"
+end`)},
+	})
+
+	var hooked atomic.Int32
+	m := New(
+		WithSource(source),
+		WithMaxWorkers(2),
+		WithFallbackLicense("pseudo"),
+		WithDeriveRepo(true),
+		WithHook(func(*types.Formula) { hooked.Add(1) }),
+	)
+
+	result, err := m.Mine(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	assert.Equal(t, 3, result.Len())
+	assert.Equal(t, int32(3), hooked.Load())
+	assert.Equal(t, []string{"bar", "baz", "foo"}, result.Names())
+	assert.Nil(t, result.Coverage())
+
+	foo, ok := result.Formula("foo")
+	if assert.True(t, ok) {
+		assert.Equal(t, "MIT", foo.License)
+		assert.Equal(t, "permissive", foo.LicenseCategory)
+		assert.Equal(t, "https://github.com/example/foo.git", foo.RepoURL)
+	}
+	bar, ok := result.Formula("bar")
+	if assert.True(t, ok) {
+		assert.Equal(t, "pseudo", bar.License)
+		assert.Equal(t, "unknown", bar.LicenseCategory)
+		assert.Equal(t, []*types.Dependency{{Name: "foo", DepType: []string{}, Source: &types.Source{
+			Path: "Formula/b/bar.rb", Line: 3, EndLine: 3, Raw: `depends_on "foo"`,
+		}}}, bar.Dependencies)
+	}
+}
+
+func TestMineExtra(t *testing.T) {
+	err := setup.Register("livecheck", func() parser.NodeStrategy {
+		return parser.NewNM[string]("livecheck", func(node *ruby.Node) bool {
+			return node.IsCall("livecheck")
+		}, func(nodes []*ruby.Node) (string, error) {
+			return nodes[0].Body[0].ArgsRaw(), nil
+		})
+	}, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	source := reader.NewFSSource(fstest.MapFS{
+		"Formula/f/foo.rb": {Data: []byte(`class Foo < Formula
+  url "https://example.com/foo-1.0.tar.gz"
+  livecheck do
+    url :stable
+  end
+end`)},
+	})
+
+	result, err := New(WithSource(source)).Mine(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	foo, _ := result.Formula("foo")
+	assert.Equal(t, map[string]any{"livecheck": ":stable"}, foo.Extra)
+
+	encoded, err := json.Marshal(foo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Contains(t, string(encoded), `"extra":{"livecheck":":stable"}`)
+}
+
+// coreRepoFiles are the formula files of the core repository created by writeCoreRepo.
+var coreRepoFiles = map[string]string{
+	"Formula/f/foo.rb": `class Foo < Formula
+  url "https://example.com/foo-1.0.tar.gz"
+  license "MIT"
+end`,
+	"Formula/b/bar.rb": `class Bar < Formula
+  url "https://example.com/bar-2.0.tar.gz"
+  depends_on "foo"
+  conflicts_with "baz", because: "both install a bar binary"
+end`,
+}
+
+// writeCoreRepo writes the given formula files to a temporary core repository and returns its directory.
+func writeCoreRepo(t *testing.T, files map[string]string) string {
+	repoDir := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(repoDir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			log.Fatal(err)
+		}
+	}
+	return repoDir
+}
+
+func TestRun(t *testing.T) {
+	repoDir, outputDir := writeCoreRepo(t, coreRepoFiles), t.TempDir()
+
+	config := &config.Config{OutputDir: outputDir, JSON: true}
+	config.CoreRepo.Dir = repoDir
+	config.Reader.MaxWorkers = 2
+	config.Reader.FallbackLicense = "pseudo"
+
+	m := NewMiner(config)
+	if err := m.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	assert.Greater(t, m.PeakMemory(), uint64(0))
+	if assert.NotNil(t, m.Summary()) {
+		assert.Equal(t, 2, m.Summary().Formulae)
+		assert.Equal(t, 1, m.Summary().Dependencies)
+	}
+
+	// The spool file has been removed.
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Len(t, entries, 3)
+	assert.Equal(t, outputDir, m.OutputDir())
+
+	tsv, err := filepath.Glob(filepath.Join(outputDir, "deps-brew-*.tsv"))
+	if err != nil || len(tsv) != 1 {
+		log.Fatalf("expected a single TSV file, got %v: %v", tsv, err)
+	}
+	content, err := os.ReadFile(tsv[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 4)
+	assert.Contains(t, lines, "1\t\"brew\"\t\"foo\"\t\"MIT\"\t\"runtime\"\t\"\"")
+	assert.Contains(t, lines, "2\t\"brew\"\t\"baz\"\t\"\"\t\"conflict\"\t\"both install a bar binary\"")
+
+	jsonFiles, err := filepath.Glob(filepath.Join(outputDir, "deps-brew-*.json"))
+	if err != nil || len(jsonFiles) != 1 {
+		log.Fatalf("expected a single JSON file, got %v: %v", jsonFiles, err)
+	}
+	content, err = os.ReadFile(jsonFiles[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	var formulae map[string]*types.Formula
+	assert.NoError(t, json.Unmarshal(content, &formulae))
+	assert.Len(t, formulae, 2)
+}
+
+func TestRunDeterministic(t *testing.T) {
+	files := map[string]string{
+		"Formula/q/qux.rb": `class Qux < Formula
+  url "https://example.com/qux-1.0.tar.gz"
+  depends_on "zlib"
+  depends_on "pkg-config" => :build
+  depends_on "bar"
+  uses_from_macos "curl"
+  on_linux do
+    depends_on "acl"
+  end
+  conflicts_with "quux", because: "both install qux"
+  conflicts_with "corge", because: "both install qux"
+end`,
+		"Formula/z/zlib.rb":       `class Zlib < Formula` + "\n" + `  license "Zlib"` + "\n" + `end`,
+		"Formula/p/pkg-config.rb": `class PkgConfig < Formula` + "\n" + `end`,
+		"Formula/c/curl.rb":       `class Curl < Formula` + "\n" + `  depends_on "zlib"` + "\n" + `end`,
+		"Formula/a/acl.rb":        `class Acl < Formula` + "\n" + `  license any_of: ["MIT", "Apache-2.0"]` + "\n" + `end`,
+	}
+	for path, content := range coreRepoFiles {
+		files[path] = content
+	}
+	repoDir := writeCoreRepo(t, files)
+
+	// Run the miner twice over the same core repository.
+	outputs := make([]map[string][]byte, 2)
+	for i := range outputs {
+		config := &config.Config{OutputDir: t.TempDir(), JSON: true, Provenance: true}
+		config.CoreRepo.Dir = repoDir
+		config.Reader.MaxWorkers = 4
+
+		if err := NewMiner(config).Run(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+
+		outputs[i] = make(map[string][]byte)
+		entries, err := os.ReadDir(config.OutputDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, entry := range entries {
+			// The manifest holds the times of the run.
+			if entry.Name() == writer.ManifestFileName {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(config.OutputDir, entry.Name()))
+			if err != nil {
+				log.Fatal(err)
+			}
+			outputs[i][entry.Name()] = content
+		}
+	}
+
+	assert.Len(t, outputs[0], 3)
+	assert.Equal(t, outputs[0], outputs[1])
+
+	// Each value of the provenance is quoted, such that quotes within the values don't break the columns.
+	for name, content := range outputs[0] {
+		if !strings.HasPrefix(name, "provenance") {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			columns := strings.Split(line, "\t")
+			if assert.Len(t, columns, 5, line) {
+				for _, c := range columns {
+					_, err := strconv.Unquote(c)
+					assert.NoError(t, err, line)
+				}
+			}
+		}
+		assert.Contains(t, string(content), `"acl"`+"\t"+`"license"`+"\t"+`"any_of: [\"MIT\", \"Apache-2.0\"]"`)
+	}
+
+	// The formulae are sorted by their name, followed by their sorted dependencies.
+	for name, content := range outputs[0] {
+		if !strings.HasSuffix(name, ".tsv") || strings.HasPrefix(name, "provenance") {
+			continue
+		}
+		names := make([]string, 0)
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			names = append(names, strings.Split(line, "\t")[0]+" "+strings.Split(line, "\t")[2])
+		}
+		assert.Equal(t, []string{
+			`0 "acl"`, `0 "bar"`, `1 "foo"`, `2 "baz"`, `0 "curl"`, `1 "zlib"`, `0 "foo"`, `0 "pkg-config"`,
+			`0 "qux"`, `1 "acl"`, `1 "bar"`, `1 "curl"`, `1 "pkg-config"`, `1 "zlib"`, `2 "corge"`, `2 "quux"`, `0 "zlib"`,
+		}, names)
+	}
+}
+
+func TestRunOutputPolicy(t *testing.T) {
+	repoDir, outputDir := writeCoreRepo(t, coreRepoFiles), t.TempDir()
+
+	config := &config.Config{OutputDir: outputDir, OutputPolicy: config.OutputPolicyOverwrite}
+	config.CoreRepo.Dir = repoDir
+	config.Reader.MaxWorkers = 2
+
+	// Rerunning the overwrite policy replaces the output file.
+	for i := 0; i < 2; i++ {
+		if err := NewMiner(config, WithCoreRepoCommit("0123abcd")).Run(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+	}
+	tsv, err := filepath.Glob(filepath.Join(outputDir, "deps-brew-*.tsv"))
+	if err != nil || len(tsv) != 1 {
+		log.Fatalf("expected a single TSV file, got %v: %v", tsv, err)
+	}
+	content, err := os.ReadFile(tsv[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Len(t, strings.Split(strings.TrimSpace(string(content)), "\n"), 4)
+
+	// The manifest lists the checksum of the output file.
+	content, err = os.ReadFile(filepath.Join(outputDir, writer.ManifestFileName))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var manifest writer.Manifest
+	assert.NoError(t, json.Unmarshal(content, &manifest))
+	assert.Equal(t, writer.ManifestCounts{Formulae: 2, Dependencies: 1, Conflicts: 1}, manifest.Counts)
+	assert.False(t, manifest.Partial)
+	assert.Equal(t, "0123abcd", manifest.CoreRepoCommit)
+	assert.Len(t, manifest.ConfigHash, 64)
+	if assert.Len(t, manifest.Files, 1) {
+		assert.Equal(t, filepath.Base(tsv[0]), manifest.Files[0].Name)
+		assert.Len(t, manifest.Files[0].SHA256, 64)
+	}
+
+	// The versioned policy writes each run into a new directory, keeping the last two.
+	config.OutputDir = t.TempDir()
+	config.OutputPolicy = "versioned"
+	config.KeepRuns = 2
+	// Directories not created by the miner are never pruned.
+	foreign := []string{"run-backup", "run-20000101T000000Z", "run-20000101T000000Z-x"}
+	for _, name := range foreign {
+		if err := os.Mkdir(filepath.Join(config.OutputDir, name), 0755); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(config.OutputDir, "run-backup", writer.ManifestFileName), []byte("{}"), 0644); err != nil {
+		log.Fatal(err)
+	}
+	dirs := make([]string, 0)
+	for _, name := range foreign {
+		dirs = append(dirs, filepath.Join(config.OutputDir, name))
+	}
+	for i := 0; i < 3; i++ {
+		m := NewMiner(config)
+		if err := m.Run(context.Background()); err != nil {
+			log.Fatal(err)
+		}
+		dirs = append(dirs, m.OutputDir())
+	}
+	newest := dirs[len(dirs)-1]
+	dirs = append(dirs[:len(foreign)], dirs[len(foreign)+1:]...)
+	slices.Sort(dirs)
+	entries, err := os.ReadDir(config.OutputDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, filepath.Join(config.OutputDir, entry.Name()))
+	}
+	assert.Equal(t, dirs, names)
+	assert.FileExists(t, filepath.Join(newest, writer.ManifestFileName))
+}
+
+func TestMineErrors(t *testing.T) {
+	_, err := New().Mine(context.Background())
+	assert.ErrorIs(t, err, ErrNoSource)
+
+	_, err = New(WithCoreRepo("."), WithMaxWorkers(0)).Mine(context.Background())
+	assert.ErrorIs(t, err, ErrInvalidMaxWorkers)
+
+	_, err = New(WithCoreRepo("."), WithLicenseCategories("missing.yml")).Mine(context.Background())
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = New(WithCoreRepo("../test-data")).Mine(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = New(WithCoreRepo("../test-data")).Run(context.Background())
+	assert.ErrorIs(t, err, ErrNoConfig)
+}
+
 // getJSONFromAPI returns a list of all formulas from the homebrew API.
 func getJSONFromAPI() []map[string]interface{} {
 	resp, err := http.Get("https://formulae.brew.sh/api/formula.json")
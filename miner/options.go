@@ -0,0 +1,92 @@
+package miner
+
+import (
+	"time"
+
+	"main/miner/reader"
+	"main/miner/types"
+)
+
+// defaultMaxWorkers is the default number of concurrent workers reading the formulae.
+const defaultMaxWorkers = 10
+
+// Option configures a Miner.
+type Option func(*Miner)
+
+// WithSource sets the source the formula files are read from.
+func WithSource(source reader.Source) Option {
+	return func(m *Miner) {
+		m.source = source
+	}
+}
+
+// WithCoreRepo reads the formula files from the core repository at the given directory.
+func WithCoreRepo(dir string) Option {
+	return WithSource(reader.NewDirSource(dir))
+}
+
+// WithCoreRepoCommit sets the SHA of the commit checked out in the core repository, which is written to the manifest.
+func WithCoreRepoCommit(commit string) Option {
+	return func(m *Miner) {
+		m.commit = commit
+	}
+}
+
+// WithMaxWorkers sets the maximum number of concurrent workers reading the formulae.
+func WithMaxWorkers(n int) Option {
+	return func(m *Miner) {
+		m.readerConfig.MaxWorkers = n
+	}
+}
+
+// WithParseTimeout sets the maximum duration of parsing a single formula file.
+// Parsing isn't limited if the timeout is zero.
+func WithParseTimeout(timeout time.Duration) Option {
+	return func(m *Miner) {
+		m.readerConfig.ParseTimeout = timeout
+	}
+}
+
+// WithFallbackLicense sets the license used for formulae which don't specify a license.
+func WithFallbackLicense(license string) Option {
+	return func(m *Miner) {
+		m.readerConfig.FallbackLicense = license
+	}
+}
+
+// WithLicenseCategories sets the path of a YAML file overriding the default categories of licenses.
+func WithLicenseCategories(path string) Option {
+	return func(m *Miner) {
+		m.readerConfig.LicenseCategories = path
+	}
+}
+
+// WithDeriveRepo sets whether the repository URL of a formula is derived if no head is specified.
+func WithDeriveRepo(deriveRepo bool) Option {
+	return func(m *Miner) {
+		m.readerConfig.DeriveRepo = deriveRepo
+	}
+}
+
+// WithLegacyParser sets whether the fields are matched line by line instead of being extracted from the syntax tree.
+func WithLegacyParser(legacyParser bool) Option {
+	return func(m *Miner) {
+		m.readerConfig.LegacyParser = legacyParser
+	}
+}
+
+// WithCoverage sets whether a coverage report of the formula files is created.
+func WithCoverage(coverage bool) Option {
+	return func(m *Miner) {
+		m.coverage = coverage
+	}
+}
+
+// WithHook adds a hook, which is called with each formula once it has been read.
+// Hooks are called sequentially from the goroutine consuming the formulae read by the workers,
+// thus they are never called concurrently by a single Mine or Run call.
+func WithHook(hook func(*types.Formula)) Option {
+	return func(m *Miner) {
+		m.hooks = append(m.hooks, hook)
+	}
+}
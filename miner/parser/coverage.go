@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"main/miner/ruby"
+)
+
+// LineClass classifies a line of a formula file by how it is handled by the parser.
+type LineClass int
+
+const (
+	// Ignored lines are intentionally not parsed, e.g. the body of the install method,
+	// blank lines, comments and the definition of the formula class itself.
+	Ignored LineClass = iota
+
+	// Consumed lines belong to a statement matched by a field.
+	Consumed
+
+	// Unknown lines belong to a statement which is neither matched by a field nor intentionally ignored.
+	Unknown
+)
+
+var lineClassNames = map[LineClass]string{
+	Ignored:  "ignored",
+	Consumed: "consumed",
+	Unknown:  "unknown",
+}
+
+func (c LineClass) String() string {
+	return lineClassNames[c]
+}
+
+// Coverage holds the classification of the lines of a formula file.
+type Coverage struct {
+	// Lines holds the class of each line of the file, starting with the first line at index 0.
+	Lines []LineClass
+
+	// Unknown holds the top-level statements of the formula class which are unknown.
+	Unknown []*ruby.Node
+}
+
+// Coverage classifies the lines of the formula file by the top-level statement of the formula class they belong to.
+// A statement is consumed if it matches one of the provided fields, and ignored if isIgnored returns true for it.
+// Any other statement is unknown.
+func (tp *TreeParser) Coverage(fields []NodeStrategy, isIgnored func(node *ruby.Node) bool) (*Coverage, error) {
+	class := tp.Tree.FormulaClass()
+	if class == nil {
+		return nil, fmt.Errorf("no formula class found")
+	}
+
+	coverage := &Coverage{
+		Lines:   make([]LineClass, tp.Tree.EndLine),
+		Unknown: make([]*ruby.Node, 0),
+	}
+
+	for _, node := range class.Body {
+		lineClass := Unknown
+		if isIgnored(node) {
+			lineClass = Ignored
+		}
+		for _, f := range fields {
+			if f.MatchesNode(node) {
+				lineClass = Consumed
+				break
+			}
+		}
+
+		if lineClass == Unknown {
+			coverage.Unknown = append(coverage.Unknown, node)
+		}
+		for line := node.Line; line <= node.EndLine && line <= len(coverage.Lines); line++ {
+			coverage.Lines[line-1] = lineClass
+		}
+	}
+
+	return coverage, nil
+}
+
+// Count returns the number of lines of the given class.
+func (c *Coverage) Count(lineClass LineClass) int {
+	count := 0
+	for _, l := range c.Lines {
+		if l == lineClass {
+			count++
+		}
+	}
+	return count
+}
+
+// ConstructName returns the name of the construct the given statement is an instance of,
+// e.g. "on_linux" for an `on_linux do ... end` block or "def install" for the install method.
+func ConstructName(node *ruby.Node) string {
+	switch node.Kind {
+	case ruby.Call:
+		if node.Receiver != "" {
+			return node.Receiver + "." + node.Name
+		}
+		return node.Name
+	case ruby.Def:
+		return "def " + node.Name
+	case ruby.Class, ruby.Module:
+		return strings.ToLower(node.Kind.String()) + " " + node.Name
+	case ruby.Assign:
+		return "assignment"
+	case ruby.Control:
+		return node.Name
+	}
+
+	// Use the first word of the expression.
+	fields := strings.Fields(node.Raw)
+	if len(fields) == 0 {
+		return node.Kind.String()
+	}
+	return fields[0]
+}
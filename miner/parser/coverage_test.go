@@ -0,0 +1,60 @@
+package parser_test
+
+import (
+	"log"
+	"testing"
+
+	"main/miner/parser"
+	"main/miner/ruby"
+	"main/miner/setup"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverage(t *testing.T) {
+	tree, err := ruby.Parse(`class Foo < Formula
+  desc "Formula using an unknown block"
+  homepage "https://example.com/foo"
+
+  on_foo do
+    depends_on "bar"
+  end
+  depends_on "baz"
+
+  def install
+    system "make"
+  end
+end`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	treeParser := &parser.TreeParser{Tree: tree}
+	coverage, err := treeParser.Coverage(setup.BuildNodeStrategies(), setup.IsIgnoredNode)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	expected := []parser.LineClass{
+		parser.Ignored,  // class Foo < Formula
+		parser.Ignored,  // desc
+		parser.Consumed, // homepage
+		parser.Ignored,  //
+		parser.Unknown,  // on_foo do
+		parser.Unknown,  // depends_on "bar"
+		parser.Unknown,  // end
+		parser.Consumed, // depends_on "baz"
+		parser.Ignored,  //
+		parser.Ignored,  // def install
+		parser.Ignored,  // system "make"
+		parser.Ignored,  // end
+		parser.Ignored,  // end
+	}
+	assert.Equal(t, expected, coverage.Lines)
+	assert.Equal(t, 3, coverage.Count(parser.Unknown))
+
+	if assert.Len(t, coverage.Unknown, 1) {
+		assert.Equal(t, "on_foo", parser.ConstructName(coverage.Unknown[0]))
+		assert.Equal(t, 5, coverage.Unknown[0].Line)
+	}
+}
@@ -2,38 +2,90 @@ package parser
 
 import (
 	"bufio"
+	"strings"
+
+	"main/miner/types"
 )
 
 // FormulaParser acts as context for parsing fields.
 type FormulaParser struct {
 	// Scanner is used to read the file line by line.
 	Scanner *bufio.Scanner
+
+	// Sources holds the lines each field has been parsed from, where the key is the name of the field.
+	// It is populated by ParseFields.
+	Sources map[string][]*types.Source
+
+	// The number of the line currently parsed.
+	lineNo int
 }
 
 // ParseFields parses the provided fields from a file.
 // It returns a map of field names to their values.
 func (fp *FormulaParser) ParseFields(fields []ParseStrategy) (map[string]interface{}, error) {
 	results := make(map[string]interface{})
+	fp.Sources = make(map[string][]*types.Source)
+	fp.lineNo = 0
 
 	for fp.Scanner.Scan() {
 		line := fp.Scanner.Text()
+		fp.lineNo++
+
+		if err := fp.parseLine(line, fields, results); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
 
-		for _, f := range fields {
-			// Skip field if it has already been matched.
-			if _, ok := results[f.getName()]; ok {
-				continue
+// parseLine matches the given line against the provided fields and
+// stores the value of the first matching field in the results map.
+// If the matching field hands back the line which ended its sequence,
+// that line is parsed as well.
+func (fp *FormulaParser) parseLine(line string, fields []ParseStrategy, results map[string]interface{}) error {
+	for _, f := range fields {
+		// Skip field if it has already been matched.
+		if _, ok := results[f.Name()]; ok {
+			continue
+		}
+
+		if f.MatchesLine(line) {
+			fieldValue, err := f.ExtractFromLine(line)
+			if err != nil {
+				return err
 			}
+			results[f.Name()] = fieldValue
+			fp.addSource(f, line)
 
-			if f.MatchesLine(line) {
-				fieldValue, err := f.ExtractFromLine(line)
-				if err != nil {
-					return nil, err
+			// Check for a line read past the end of the field's sequence.
+			if r, ok := f.(remainder); ok {
+				if next, found := r.remainder(); found {
+					fp.lineNo++
+					return fp.parseLine(next, fields, results)
 				}
-				results[f.getName()] = fieldValue
-				break
 			}
+			return nil
 		}
 	}
+	return nil
+}
 
-	return results, nil
+// addSource adds the source of the given field, which has been matched at the current line.
+// If the field matched a sequence of lines, the current line is advanced to the end of the sequence.
+func (fp *FormulaParser) addSource(f ParseStrategy, line string) {
+	lines := []string{line}
+	if s, ok := f.(sequencer); ok {
+		if seq := s.sequence(); len(seq) > 0 {
+			lines = seq
+		}
+	}
+
+	source := &types.Source{
+		Line:    fp.lineNo,
+		EndLine: fp.lineNo + len(lines) - 1,
+		Raw:     strings.Join(lines, "\n"),
+	}
+	fp.Sources[f.Name()] = append(fp.Sources[f.Name()], source)
+	fp.lineNo = source.EndLine
 }
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"main/miner/ruby"
+)
+
+// NodeStrategy represents a strategy for matching statements of a formula's syntax tree
+// and extracting relevant information. It declares all methods the TreeParser uses to execute a strategy.
+type NodeStrategy interface {
+	// MatchesNode checks if the strategy matches the given statement of the formula class.
+	MatchesNode(node *ruby.Node) bool
+
+	// ExtractFromNodes extracts relevant information from all matched statements in source order.
+	// It returns the extracted information and an error if any.
+	ExtractFromNodes(nodes []*ruby.Node) (interface{}, error)
+
+	// Name returns the name of the field.
+	Name() string
+}
+
+// NodeMatcher acts as a concrete strategy for statements of a syntax tree.
+type NodeMatcher[T any] struct {
+	// name of the field.
+	name string
+
+	// isMatchingNode checks if a statement belongs to the field.
+	isMatchingNode func(node *ruby.Node) bool
+
+	// extract extracts the field from the matched statements.
+	extract func(nodes []*ruby.Node) (T, error)
+}
+
+// NewNM creates a new instance of NodeMatcher.
+func NewNM[T any](name string, isMatchingNode func(*ruby.Node) bool, extract func([]*ruby.Node) (T, error)) *NodeMatcher[T] {
+	return &NodeMatcher[T]{
+		name:           name,
+		isMatchingNode: isMatchingNode,
+		extract:        extract,
+	}
+}
+
+// Name returns the name of the field.
+func (f *NodeMatcher[T]) Name() string {
+	return f.name
+}
+
+// MatchesNode checks if the given statement belongs to the field.
+func (f *NodeMatcher[T]) MatchesNode(node *ruby.Node) bool {
+	return f.isMatchingNode(node)
+}
+
+// ExtractFromNodes extracts the field from the given matched statements.
+func (f *NodeMatcher[T]) ExtractFromNodes(nodes []*ruby.Node) (interface{}, error) {
+	return f.extract(nodes)
+}
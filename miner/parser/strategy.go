@@ -14,8 +14,20 @@ type ParseStrategy interface {
 	// It returns the extracted information and an error if any.
 	ExtractFromLine(line string) (interface{}, error)
 
-	// getName returns the name of the field.
-	getName() string
+	// Name returns the name of the field.
+	Name() string
+}
+
+// remainder is implemented by strategies which read one line past the end of their sequence.
+type remainder interface {
+	// remainder returns the line which ended the sequence, if it is not part of the sequence.
+	remainder() (string, bool)
+}
+
+// sequencer is implemented by strategies which match a sequence of lines.
+type sequencer interface {
+	// sequence returns the lines of the matched sequence, if a sequence has been matched.
+	sequence() []string
 }
 
 // SingleLineMatcher acts as a concrete strategy.
@@ -43,7 +55,8 @@ func NewSLM[T any](name string, isDefaultPattern func(string) (bool, []string),
 	}
 }
 
-func (f *SingleLineMatcher[T]) getName() string {
+// Name returns the name of the field.
+func (f *SingleLineMatcher[T]) Name() string {
 	return f.name
 }
 
@@ -82,6 +95,12 @@ type MultiLineMatcher[T any] struct {
 
 	// Flag to indicate if a begin sequence has been matched.
 	opened bool
+
+	// Flag to indicate if the line ending a sequence is excluded from the sequence.
+	excludeEnd bool
+
+	// The line which ended the sequence, if it is excluded from the sequence.
+	pending *string
 }
 
 // NewMLM creates a new instance of multiLineMatcher.
@@ -95,6 +114,31 @@ func NewMLM[T any](name string, isDefaultPattern func(string) (bool, []string),
 	}
 }
 
+// ExcludeEnd excludes the line ending a sequence from the sequence.
+// The line is instead handed back to the FormulaParser, such that other fields can match it.
+func (f *MultiLineMatcher[T]) ExcludeEnd() *MultiLineMatcher[T] {
+	f.excludeEnd = true
+	return f
+}
+
+// remainder returns the line which ended the sequence if it is excluded from the sequence.
+func (f *MultiLineMatcher[T]) remainder() (string, bool) {
+	if f.pending == nil {
+		return "", false
+	}
+	line := *f.pending
+	f.pending = nil
+	return line, true
+}
+
+// sequence returns the lines of the matched sequence if the sequence has been opened.
+func (f *MultiLineMatcher[T]) sequence() []string {
+	if !f.opened {
+		return nil
+	}
+	return f.matches
+}
+
 // MatchesLine checks if the given line contains a begin sequence.
 // If a begin sequence is found, the sequence is opened and the line is appended to the matches slice.
 // Else the line is checked against the default pattern of the field using the singleLineMatcher.
@@ -131,7 +175,11 @@ func (f *MultiLineMatcher[T]) ExtractFromLine(line string) (interface{}, error)
 		line := f.FormulaParser.Scanner.Text()
 
 		if f.isEndSequence(line) {
-			f.matches = append(f.matches, line)
+			if f.excludeEnd {
+				f.pending = &line
+			} else {
+				f.matches = append(f.matches, line)
+			}
 			cleaned := f.cleanSequence(f.matches)
 			return cleaned, nil
 		}
@@ -39,6 +39,7 @@ var mlmUrlTests = []struct {
 			Dependencies: &types.Dependencies{
 				Lst:                []*types.Dependency{},
 				SystemRequirements: "",
+				Archives:           []*types.Archive{},
 			},
 		},
 	},
@@ -709,7 +710,7 @@ var mlmHeadTests = []struct {
 	},
 	{
 		input:    `  head "http://hg.code.sf.net/p/optipng/mercurial", using: :hg`, // optipng.rb
-		expected: &types.Head{URL: "http://hg.code.sf.net/p/optipng/mercurial"},
+		expected: &types.Head{URL: "http://hg.code.sf.net/p/optipng/mercurial", Using: "hg"},
 	},
 	{
 		input: `  license "BSD-3-Clause"
@@ -744,3 +745,98 @@ func TestMultiLineMatcherHead(t *testing.T) {
 		}
 	}
 }
+
+var mlmConflictTests = []struct {
+	input    string
+	expected []*types.Conflict
+}{
+	{
+		input: `  depends_on "pkg-config" => :build
+
+  conflicts_with "mariadb", "percona-server",
+    because: "mysql, mariadb, and percona install the same binaries"
+  conflicts_with "mysql-client", because: "both install MySQL client libraries"
+
+  def install`,
+		expected: []*types.Conflict{
+			{Name: "mariadb", Reason: "mysql, mariadb, and percona install the same binaries"},
+			{Name: "percona-server", Reason: "mysql, mariadb, and percona install the same binaries"},
+			{Name: "mysql-client", Reason: "both install MySQL client libraries"},
+		},
+	},
+	{
+		input: `  # Both install a "dep" binary
+  conflicts_with "dep"`,
+		expected: []*types.Conflict{
+			{Name: "dep", Reason: ""},
+		},
+	},
+	{
+		input: `  conflicts_with cask: "docker"
+  conflicts_with "podman", cask: ["podman-desktop", "podman-app"],
+    because: "both install a podman binary"`,
+		expected: []*types.Conflict{
+			{Name: "podman", Reason: "both install a podman binary"},
+		},
+	},
+}
+
+func TestMultiLineMatcherConflicts(t *testing.T) {
+	for _, test := range mlmConflictTests {
+		formulaParser := &parser.FormulaParser{
+			Scanner: bufio.NewScanner(strings.NewReader(test.input + "\nend")),
+		}
+
+		results, err := formulaParser.ParseFields([]parser.ParseStrategy{
+			setup.BuildDependencyMatcher(*formulaParser),
+			setup.BuildConflictMatcher(*formulaParser),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Println("Matched: ", results["conflict"])
+
+		assert.Equal(t, test.expected, results["conflict"], "expected: %v, got: %v", test.expected, results["conflict"])
+	}
+}
+
+var mlmLinkOverwriteTests = []struct {
+	input    string
+	expected []string
+}{
+	{
+		input: `  conflicts_with "python-setuptools", because: "both install setuptools"
+
+  link_overwrite "bin/2to3"
+  link_overwrite "lib/python3.12/site-packages/setuptools",
+                 "lib/python3.12/site-packages/pkg_resources"
+
+  def install`,
+		expected: []string{
+			"bin/2to3",
+			"lib/python3.12/site-packages/setuptools",
+			"lib/python3.12/site-packages/pkg_resources",
+		},
+	},
+}
+
+func TestMultiLineMatcherLinkOverwrite(t *testing.T) {
+	for _, test := range mlmLinkOverwriteTests {
+		formulaParser := &parser.FormulaParser{
+			Scanner: bufio.NewScanner(strings.NewReader(test.input + "\nend")),
+		}
+
+		results, err := formulaParser.ParseFields([]parser.ParseStrategy{
+			setup.BuildConflictMatcher(*formulaParser),
+			setup.BuildLinkOverwriteMatcher(*formulaParser),
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Println("Matched: ", results["link_overwrite"])
+
+		assert.Equal(t, test.expected, results["link_overwrite"], "expected: %v, got: %v", test.expected, results["link_overwrite"])
+	}
+}
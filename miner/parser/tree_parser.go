@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"fmt"
+
+	"main/miner/ruby"
+	"main/miner/types"
+)
+
+// TreeParser acts as context for parsing fields from the syntax tree of a formula.
+type TreeParser struct {
+	// Tree is the parsed source of the formula file.
+	Tree *ruby.Node
+
+	// Sources holds the statements each field has been extracted from, where the key is the name of the field.
+	// It is populated by ParseFields.
+	Sources map[string][]*types.Source
+}
+
+// ParseFields parses the provided fields from the statements of the formula class.
+// Each statement is assigned to the first field matching it.
+// It returns a map of field names to their values.
+func (tp *TreeParser) ParseFields(fields []NodeStrategy) (map[string]interface{}, error) {
+	class := tp.Tree.FormulaClass()
+	if class == nil {
+		return nil, fmt.Errorf("no formula class found")
+	}
+
+	matched := make(map[string][]*ruby.Node)
+	tp.Sources = make(map[string][]*types.Source)
+	for _, node := range class.Body {
+		for _, f := range fields {
+			if f.MatchesNode(node) {
+				matched[f.Name()] = append(matched[f.Name()], node)
+				tp.Sources[f.Name()] = append(tp.Sources[f.Name()], &types.Source{
+					Line:    node.Line,
+					EndLine: node.EndLine,
+					Raw:     node.Raw,
+				})
+				break
+			}
+		}
+	}
+
+	results := make(map[string]interface{})
+	for _, f := range fields {
+		nodes, ok := matched[f.Name()]
+		if !ok {
+			continue
+		}
+
+		fieldValue, err := f.ExtractFromNodes(nodes)
+		if err != nil {
+			return nil, err
+		}
+		results[f.Name()] = fieldValue
+	}
+
+	return results, nil
+}
@@ -0,0 +1,137 @@
+package parser_test
+
+import (
+	"log"
+	"testing"
+
+	"main/miner/parser"
+	"main/miner/ruby"
+	"main/miner/setup"
+	"main/miner/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var treeParserTests = []struct {
+	input    string
+	expected map[string]interface{}
+}{
+	{
+		input: `class Foo < Formula
+	desc "Formula indented by tabs"
+	homepage "https://example.com/foo"
+	url "https://github.com/example/foo.git",
+	  tag: "v1.2.3", revision: "0123456789abcdef0123456789abcdef01234567"
+	license any_of: [
+	  "MIT", # comment
+	  "Apache-2.0",
+	]
+
+	on_linux { depends_on "zlib" }
+	depends_on "gcc" if DevelopmentTools.clang_build_version <= 1403
+	on_sonoma(:or_newer) do depends_on "ghostscript" => :build end
+
+	conflicts_with "bar", "baz", because: "both install a foo binary"
+	link_overwrite "bin/foo"
+
+	test do
+	  (testpath/"Formula.rb").write <<~EOS
+	    depends_on "heredoc"
+	    end
+	  EOS
+	end
+end`,
+		expected: map[string]interface{}{
+			"homepage": "https://example.com/foo",
+			"url": &types.Stable{
+				URL: "https://github.com/example/foo/tree/v1.2.3",
+			},
+			"license": `any_of: ["MIT","Apache-2.0",]`,
+			"dependency": &types.Dependencies{
+				Lst: []*types.Dependency{
+					{Name: "zlib", DepType: []string{}, Restriction: "linux"},
+					{Name: "gcc", DepType: []string{}, Restriction: "clang version <= 1403"},
+					{Name: "ghostscript", DepType: []string{"build"}, Restriction: "macos: >= sonoma"},
+				},
+				SystemRequirements: "",
+				Archives:           []*types.Archive{},
+			},
+			"conflict": []*types.Conflict{
+				{Name: "bar", Reason: "both install a foo binary"},
+				{Name: "baz", Reason: "both install a foo binary"},
+			},
+			"link_overwrite": []string{"bin/foo"},
+		},
+	},
+	{
+		input: `class Bar < Formula
+  homepage "https://example.com/bar"
+  head do
+    url "https://github.com/example/bar.git", branch: "main"
+    depends_on "autoconf" => :build
+  end
+  stable do
+    url "https://example.com/bar-2.0.tar.gz"
+    sha256 "1111111111111111111111111111111111111111111111111111111111111111"
+    depends_on "libtool" => :build
+    resource "extra" do
+      url "https://example.com/extra.tar.gz"
+      depends_on "ignored"
+    end
+  end
+  depends_on :linux
+  depends_on macos: :ventura
+end`,
+		expected: map[string]interface{}{
+			"homepage": "https://example.com/bar",
+			"head": &types.Head{
+				URL: "https://github.com/example/bar.git",
+				Dependencies: []*types.Dependency{
+					{Name: "autoconf", DepType: []string{"build"}},
+				},
+			},
+			"url": &types.Stable{
+				URL:      "https://example.com/bar-2.0.tar.gz",
+				Checksum: "1111111111111111111111111111111111111111111111111111111111111111",
+				Dependencies: &types.Dependencies{
+					Lst: []*types.Dependency{
+						{Name: "libtool", DepType: []string{"build"}},
+					},
+					SystemRequirements: "",
+					Archives:           []*types.Archive{},
+				},
+			},
+			"dependency": &types.Dependencies{
+				Lst:                []*types.Dependency{},
+				SystemRequirements: "linux, macos >= ventura (or linux)",
+				Archives:           []*types.Archive{},
+			},
+		},
+	},
+}
+
+func TestTreeParser(t *testing.T) {
+	for _, test := range treeParserTests {
+		tree, err := ruby.Parse(test.input)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		treeParser := &parser.TreeParser{Tree: tree}
+		results, err := treeParser.ParseFields(setup.BuildNodeStrategies())
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		log.Println("Matched: ", results)
+
+		// Dependencies are collected in a set, thus their order is not deterministic.
+		if expected, ok := test.expected["dependency"].(*types.Dependencies); ok {
+			actual := results["dependency"].(*types.Dependencies)
+			assert.ElementsMatch(t, expected.Lst, actual.Lst, "expected: %v, got: %v", expected.Lst, actual.Lst)
+			actual.Lst = expected.Lst
+		}
+
+		assert.Equal(t, test.expected, results, "expected: %v, got: %v", test.expected, results)
+	}
+}
@@ -0,0 +1,29 @@
+package pattern
+
+import (
+	"regexp"
+	"sync"
+)
+
+// registry holds the compiled regular expressions, where the key is the pattern.
+var registry sync.Map
+
+// Get returns the compiled regular expression of the given pattern.
+// Each pattern is compiled once, subsequent calls return the registered regular expression.
+// Like regexp.MustCompile, it panics if the pattern can't be compiled.
+func Get(pattern string) *regexp.Regexp {
+	if re, ok := registry.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	re, _ := registry.LoadOrStore(pattern, regexp.MustCompile(pattern))
+	return re.(*regexp.Regexp)
+}
+
+// MustRegister compiles the given patterns and adds them to the registry.
+// The setup package registers the patterns of its strategies at init, such that an invalid pattern fails on start.
+func MustRegister(patterns ...string) {
+	for _, pattern := range patterns {
+		Get(pattern)
+	}
+}
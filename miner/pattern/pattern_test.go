@@ -0,0 +1,24 @@
+package pattern
+
+import "testing"
+
+func TestGet(t *testing.T) {
+	re := Get(`^\s{2}url\s+"([^"]+)"`)
+	if !re.MatchString(`  url "https://example.com"`) {
+		t.Errorf("Expected pattern to match")
+	}
+
+	// The same pattern returns the same compiled regular expression.
+	if Get(`^\s{2}url\s+"([^"]+)"`) != re {
+		t.Errorf("Expected pattern to be compiled once")
+	}
+}
+
+func TestMustRegister_InvalidPattern(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic for invalid pattern")
+		}
+	}()
+	MustRegister(`(`)
+}
@@ -0,0 +1,188 @@
+package purl
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"main/miner/forge"
+	"main/miner/pattern"
+)
+
+// PURL is a package URL identifying a package across ecosystems, as specified by https://github.com/package-url/purl-spec.
+type PURL struct {
+	// Type of the package, e.g. "brew", "github" or "pypi".
+	Type string
+
+	// Namespace of the package, e.g. the owner of a GitHub repository. It may hold multiple segments separated by "/".
+	Namespace string
+
+	// Name of the package.
+	Name string
+
+	// Version of the package, if any.
+	Version string
+
+	// Qualifiers of the package, e.g. "download_url", where the key is the name of the qualifier.
+	Qualifiers map[string]string
+}
+
+// String formats the package URL in its canonical form, e.g. "pkg:github/owner/repo@v1.0".
+// The qualifiers are sorted by their key, and qualifiers without a value are omitted.
+func (p *PURL) String() string {
+	var b strings.Builder
+	b.WriteString("pkg:")
+	b.WriteString(p.Type)
+	b.WriteByte('/')
+	if p.Namespace != "" {
+		for _, s := range strings.Split(p.Namespace, "/") {
+			b.WriteString(escape(s))
+			b.WriteByte('/')
+		}
+	}
+	b.WriteString(escape(p.Name))
+	if p.Version != "" {
+		b.WriteByte('@')
+		b.WriteString(escape(p.Version))
+	}
+
+	keys := make([]string, 0, len(p.Qualifiers))
+	for key, value := range p.Qualifiers {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(escape(p.Qualifiers[key]))
+	}
+	return b.String()
+}
+
+// escape percent-encodes all characters of the given string except unreserved characters and colons.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~:", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte("0123456789ABCDEF"[c>>4])
+		b.WriteByte("0123456789ABCDEF"[c&15])
+	}
+	return b.String()
+}
+
+// Brew returns the package URL of the formula with the given name and version.
+func Brew(name, version string) *PURL {
+	return &PURL{Type: "brew", Name: name, Version: version}
+}
+
+// Patterns of the archive URLs identifying an upstream package.
+const (
+	// githubArchivePattern matches the URL of a GitHub archive or release, where the tag is the third submatch.
+	githubArchivePattern = `^https://github\.com/([a-zA-Z0-9_.-]+)/([a-zA-Z0-9_.-]+)/(?:archive/(?:refs/tags/)?(.+?)\.(?:tar\.gz|tar\.bz2|tar\.xz|zip)|releases/download/([^/]+)/)`
+
+	// bitbucketArchivePattern matches the URL of a Bitbucket archive, where the tag is the third submatch.
+	bitbucketArchivePattern = `^https://bitbucket\.org/([a-zA-Z0-9_.-]+)/([a-zA-Z0-9_.-]+)/get/(.+?)\.(?:tar\.gz|tar\.bz2|zip)$`
+
+	// pypiArchivePattern matches the URL of a PyPI source distribution.
+	pypiArchivePattern = `^https://(?:files\.pythonhosted\.org|pypi\.io|pypi\.python\.org)/packages/.*/([a-zA-Z0-9_.-]+?)-([0-9][a-zA-Z0-9_.!+]*)\.(?:tar\.gz|tar\.bz2|zip)$`
+
+	// cratesArchivePattern matches the URL of a crate on crates.io.
+	cratesArchivePattern = `^https://(?:static\.crates\.io/crates/([a-zA-Z0-9_-]+)/[a-zA-Z0-9_-]+-(.+)\.crate|crates\.io/api/v1/crates/([a-zA-Z0-9_-]+)/([^/]+)/download)$`
+
+	// npmArchivePattern matches the URL of a package tarball on the npm registry, including its scope, if any.
+	npmArchivePattern = `^https://registry\.npmjs\.org/(?:(@[a-zA-Z0-9_.-]+)/)?([a-zA-Z0-9_.-]+)/-/[a-zA-Z0-9_.-]+?-([0-9][a-zA-Z0-9_.+-]*)\.tgz$`
+)
+
+// Upstream returns the package URL of the upstream source of the formula with the given name and version,
+// derived from the URL of its stable archive and its repository URL, or nil if neither identifies the source.
+// The vcs is the version control system of the repository, e.g. "git" or "hg", or empty if it is unknown.
+//
+// Archives of GitHub and Bitbucket identify a repository and tag, and archives of PyPI, crates.io and npm a package
+// of the registry. Any other archive results in a generic package URL with the archive as download URL
+// and the repository as VCS URL. Only git repositories are given as VCS URL.
+func Upstream(name, version, archiveURL, repoURL, vcs string) *PURL {
+	if p := fromArchive(archiveURL); p != nil {
+		return p
+	}
+	if vcs != "git" {
+		repoURL = ""
+	}
+	if archiveURL == "" && repoURL == "" {
+		return nil
+	}
+
+	p := &PURL{Type: "generic", Name: name, Version: version, Qualifiers: map[string]string{}}
+	if archiveURL != "" {
+		p.Qualifiers["download_url"] = archiveURL
+	}
+	if repoURL != "" {
+		p.Qualifiers["vcs_url"] = vcsURL(repoURL)
+	}
+	return p
+}
+
+// fromArchive returns the package URL identified by the given archive URL, or nil if the URL doesn't identify one.
+func fromArchive(archiveURL string) *PURL {
+	switch host := forge.Lookup(archiveURL); {
+	case host == nil:
+		return nil
+	case host.Name == "GitHub":
+		if m := pattern.Get(githubArchivePattern).FindStringSubmatch(archiveURL); m != nil {
+			tag := m[3]
+			if tag == "" {
+				tag = m[4]
+			}
+			return &PURL{Type: "github", Namespace: strings.ToLower(m[1]), Name: strings.ToLower(m[2]), Version: unescape(tag)}
+		}
+	case host.Name == "Bitbucket":
+		if m := pattern.Get(bitbucketArchivePattern).FindStringSubmatch(archiveURL); m != nil {
+			return &PURL{Type: "bitbucket", Namespace: strings.ToLower(m[1]), Name: strings.ToLower(m[2]), Version: unescape(m[3])}
+		}
+	case host.Name == "PyPI":
+		if m := pattern.Get(pypiArchivePattern).FindStringSubmatch(archiveURL); m != nil {
+			// Names of PyPI packages are case insensitive and treat underscores, dots and dashes alike.
+			name := strings.ToLower(pattern.Get(`[-_.]+`).ReplaceAllString(m[1], "-"))
+			return &PURL{Type: "pypi", Name: name, Version: m[2]}
+		}
+	case host.Name == "crates.io":
+		if m := pattern.Get(cratesArchivePattern).FindStringSubmatch(archiveURL); m != nil {
+			if m[1] != "" {
+				return &PURL{Type: "cargo", Name: m[1], Version: m[2]}
+			}
+			return &PURL{Type: "cargo", Name: m[3], Version: m[4]}
+		}
+	case host.Name == "npm":
+		if m := pattern.Get(npmArchivePattern).FindStringSubmatch(archiveURL); m != nil {
+			return &PURL{Type: "npm", Namespace: m[1], Name: m[2], Version: m[3]}
+		}
+	}
+	return nil
+}
+
+// vcsURL returns the given URL of a git repository in the form of a VCS URL, e.g. "git+https://github.com/owner/repo.git".
+func vcsURL(repoURL string) string {
+	if strings.HasPrefix(repoURL, "git+") || strings.HasPrefix(repoURL, "git://") {
+		return repoURL
+	}
+	return "git+" + repoURL
+}
+
+// unescape returns the given URL path segment with its percent-encoded characters decoded.
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
@@ -0,0 +1,59 @@
+package purl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestString(t *testing.T) {
+	assert.Equal(t, "pkg:brew/openssl%403@3.2.0", Brew("openssl@3", "3.2.0").String())
+	assert.Equal(t, "pkg:brew/foo", Brew("foo", "").String())
+	assert.Equal(t, "pkg:npm/%40angular/cli@17.0.0", (&PURL{Type: "npm", Namespace: "@angular", Name: "cli", Version: "17.0.0"}).String())
+	assert.Equal(t, "pkg:generic/foo@1.0%2Bbuild?download_url=https:%2F%2Fexample.com%2Ffoo-1.0.tar.gz&vcs_url=git%2Bhttps:%2F%2Fexample.com%2Ffoo.git", (&PURL{
+		Type:    "generic",
+		Name:    "foo",
+		Version: "1.0+build",
+		Qualifiers: map[string]string{
+			"vcs_url":      "git+https://example.com/foo.git",
+			"download_url": "https://example.com/foo-1.0.tar.gz",
+			"checksum":     "",
+		},
+	}).String())
+}
+
+var upstreamTests = []struct {
+	archiveURL string
+	repoURL    string
+	expected   string
+}{
+	{"https://github.com/wireshark/wireshark/archive/refs/tags/v4.2.3.tar.gz", "", "pkg:github/wireshark/wireshark@v4.2.3"},
+	{"https://github.com/Example/Tool/archive/1.0.zip", "", "pkg:github/example/tool@1.0"},
+	{"https://github.com/example/tool/releases/download/v1.4.2/tool-linux-amd64.tar.gz", "", "pkg:github/example/tool@v1.4.2"},
+	{"https://bitbucket.org/multicoreware/x265_git/get/3.5.tar.gz", "", "pkg:bitbucket/multicoreware/x265_git@3.5"},
+	{"https://files.pythonhosted.org/packages/9d/be/10918a2eac4ae9f02f6cfe6414b7a155ccd8f7f9d4380d62fd5b955065c3/Requests_OAuthlib-1.3.1.tar.gz", "", "pkg:pypi/requests-oauthlib@1.3.1"},
+	{"https://static.crates.io/crates/ripgrep/ripgrep-14.0.3.crate", "", "pkg:cargo/ripgrep@14.0.3"},
+	{"https://crates.io/api/v1/crates/ripgrep/14.0.3/download", "", "pkg:cargo/ripgrep@14.0.3"},
+	{"https://registry.npmjs.org/npm/-/npm-10.2.5.tgz", "", "pkg:npm/npm@10.2.5"},
+	{"https://registry.npmjs.org/@angular/cli/-/cli-17.0.0.tgz", "", "pkg:npm/%40angular/cli@17.0.0"},
+	{"https://ftp.gnu.org/gnu/sed/sed-4.9.tar.xz", "https://git.savannah.gnu.org/git/sed.git", "pkg:generic/foo@1.0?download_url=https:%2F%2Fftp.gnu.org%2Fgnu%2Fsed%2Fsed-4.9.tar.xz&vcs_url=git%2Bhttps:%2F%2Fgit.savannah.gnu.org%2Fgit%2Fsed.git"},
+	{"", "https://github.com/example/foo.git", "pkg:generic/foo@1.0?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Fexample%2Ffoo.git"},
+}
+
+func TestUpstream(t *testing.T) {
+	for _, test := range upstreamTests {
+		p := Upstream("foo", "1.0", test.archiveURL, test.repoURL, "git")
+		if assert.NotNil(t, p, test.archiveURL) {
+			assert.Equal(t, test.expected, p.String(), test.archiveURL)
+		}
+	}
+	assert.Nil(t, Upstream("foo", "1.0", "", "", "git"))
+
+	// Repositories of other or unknown version control systems are omitted.
+	p := Upstream("foo", "1.0", "https://example.com/foo-1.0.tar.gz", "https://hg.example.com/foo/", "hg")
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "pkg:generic/foo@1.0?download_url=https:%2F%2Fexample.com%2Ffoo-1.0.tar.gz", p.String())
+	}
+	assert.Nil(t, Upstream("foo", "1.0", "", "https://hg.example.com/foo/", "hg"))
+	assert.Nil(t, Upstream("foo", "1.0", "", "https://example.com/foo", ""))
+}
@@ -0,0 +1,16 @@
+package reader
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	// errTimeout is returned when a call exceeds its timeout.
+	errTimeout = fmt.Errorf("timed out")
+
+	// ErrParseTimeout is returned when parsing the formula file at the given path exceeds the parse timeout.
+	ErrParseTimeout = func(path string, timeout time.Duration) error {
+		return fmt.Errorf("parsing formula file %s timed out after %s", path, timeout)
+	}
+)
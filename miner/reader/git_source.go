@@ -0,0 +1,93 @@
+package reader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// gitSource provides the formula files of a core repository at a given revision.
+// The files are read from the git objects, thus the working tree isn't checked out.
+type gitSource struct {
+	tree *object.Tree
+
+	// The git objects are not read concurrently.
+	mu sync.Mutex
+}
+
+// NewGitSource returns a Source reading the formula files from the core repository at the given directory
+// as of the given revision, e.g. a commit hash, branch or tag.
+func NewGitSource(dir, revision string) (Source, error) {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, fmt.Errorf("error resolving revision %s: %w", revision, err)
+	}
+
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+	return &gitSource{tree: tree}, nil
+}
+
+// Formulae returns the paths of the formula files within the "Formula" directory
+// followed by the alias formula files within the "Aliases" directory.
+func (s *gitSource) Formulae() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matches := make([]string, 0)
+	aliasMatches := make([]string, 0)
+	err := s.tree.Files().ForEach(func(f *object.File) error {
+		if ok, _ := path.Match("Formula/*/*.rb", f.Name); ok {
+			matches = append(matches, f.Name)
+		} else if ok, _ := path.Match("Aliases/*", f.Name); ok {
+			aliasMatches = append(aliasMatches, f.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(matches, aliasMatches...), nil
+}
+
+// Open opens the formula file at the given path.
+func (s *gitSource) Open(path string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := s.tree.File(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := f.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	src, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(src)), nil
+}
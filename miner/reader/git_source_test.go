@@ -0,0 +1,86 @@
+package reader
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// commitFile writes the given content to the file at the given path within the work tree and commits it.
+func commitFile(w *git.Worktree, dir, path, content string) string {
+	if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, path), []byte(content), 0644); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := w.Add(path); err != nil {
+		log.Fatal(err)
+	}
+	hash, err := w.Commit("Update "+path, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	return hash.String()
+}
+
+func TestGitSource(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	first := commitFile(w, dir, "Formula/f/foo.rb", "class Foo < Formula\nend\n")
+	commitFile(w, dir, "Formula/f/foo.rb", "class Foo < Formula\n  license \"MIT\"\nend\n")
+	commitFile(w, dir, "Aliases/bar", "class Foo < Formula\nend\n")
+
+	// The first commit holds the first version of the formula file only.
+	source, err := NewGitSource(dir, first)
+	if err != nil {
+		log.Fatal(err)
+	}
+	paths, err := source.Formulae()
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, []string{"Formula/f/foo.rb"}, paths)
+
+	file, err := source.Open("Formula/f/foo.rb")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, "class Foo < Formula\nend\n", string(content))
+
+	// The head of the repository holds the alias formula file as well.
+	source, err = NewGitSource(dir, "HEAD")
+	if err != nil {
+		log.Fatal(err)
+	}
+	paths, err = source.Formulae()
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, []string{"Formula/f/foo.rb", "Aliases/bar"}, paths)
+
+	_, err = NewGitSource(dir, "unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,277 @@
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"main/miner/pattern"
+	"main/miner/setup"
+)
+
+// maxResolveDepth is the maximum depth of nested interpolations,
+// e.g. a constant which itself interpolates another constant.
+const maxResolveDepth = 8
+
+// resolver resolves Ruby string interpolations within the strings of a formula.
+type resolver struct {
+	// Name of the formula.
+	name string
+
+	// Version of the formula.
+	version string
+
+	// Map of variable and constant names to their assigned string values.
+	vars map[string]string
+}
+
+// newResolver creates a new resolver for the formula with the given name.
+// It collects all string assignments and the explicit version of the formula from the given reader.
+func newResolver(name string, r io.Reader) (*resolver, error) {
+	res := &resolver{
+		name: name,
+		vars: make(map[string]string),
+	}
+
+	assignmentRe := pattern.Get(setup.AssignmentPattern)
+	versionRe := pattern.Get(setup.VersionPattern)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Check if the line contains a variable or constant assignment.
+		// The first assignment of a name takes precedence.
+		// The substring checks skip the regular expressions for most lines.
+		if strings.Contains(line, "=") {
+			if matches := assignmentRe.FindStringSubmatch(line); len(matches) >= 3 {
+				if _, ok := res.vars[matches[1]]; !ok {
+					res.vars[matches[1]] = matches[2]
+				}
+				continue
+			}
+		}
+
+		// Check if the line contains the formula's version.
+		if res.version != "" || !strings.Contains(line, "version") {
+			continue
+		}
+		if matches := versionRe.FindStringSubmatch(line); len(matches) >= 2 {
+			res.version = matches[1]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// resolve resolves all interpolations within the given string.
+// Interpolations which can't be resolved are kept as is and reported as an error.
+func (r *resolver) resolve(s string) (string, error) {
+	return r.resolveDepth(s, 0)
+}
+
+func (r *resolver) resolveDepth(s string, depth int) (string, error) {
+	regex := pattern.Get(setup.InterpolationPattern)
+
+	unresolved := make([]string, 0)
+	resolved := regex.ReplaceAllStringFunc(s, func(match string) string {
+		expr := regex.FindStringSubmatch(match)[1]
+
+		value, ok := r.evaluate(expr)
+		if !ok {
+			unresolved = append(unresolved, match)
+			return match
+		}
+
+		// The value may contain interpolations itself.
+		if depth < maxResolveDepth && regex.MatchString(value) {
+			v, err := r.resolveDepth(value, depth+1)
+			if err != nil {
+				unresolved = append(unresolved, match)
+				return match
+			}
+			value = v
+		}
+		return value
+	})
+
+	if len(unresolved) > 0 {
+		return resolved, fmt.Errorf("could not resolve interpolation %s in: %s", strings.Join(unresolved, ", "), s)
+	}
+	return resolved, nil
+}
+
+// evaluate evaluates the given interpolated expression.
+// Supported are variables, constants, the "name" and "version" helpers
+// and a chain of common method calls on the result, e.g. "version.major_minor".
+// It returns the value and a boolean indicating if the expression could be evaluated.
+func (r *resolver) evaluate(expr string) (string, bool) {
+	calls, ok := splitCalls(strings.TrimSpace(expr))
+	if !ok || len(calls) == 0 {
+		return "", false
+	}
+
+	var value string
+	switch receiver := calls[0]; {
+	case receiver.name == "version" && len(receiver.args) == 0:
+		if r.version == "" {
+			return "", false
+		}
+		value = r.version
+	case receiver.name == "name" && len(receiver.args) == 0:
+		value = r.name
+	default:
+		v, found := r.vars[receiver.name]
+		if !found || len(receiver.args) > 0 {
+			return "", false
+		}
+		value = v
+	}
+
+	for _, c := range calls[1:] {
+		if value, ok = applyCall(value, c); !ok {
+			return "", false
+		}
+	}
+	return value, true
+}
+
+// call represents a method call within an interpolated expression.
+type call struct {
+	// Name of the method.
+	name string
+
+	// String arguments of the method.
+	args []string
+}
+
+// splitCalls splits the given expression into a chain of method calls.
+// Only string literals are supported as arguments.
+// Example:
+// `version.major_minor.tr(".", "")` => [version, major_minor, tr(".", "")]
+func splitCalls(expr string) ([]call, bool) {
+	calls := make([]call, 0)
+	for i := 0; i < len(expr); {
+		// Read the method name.
+		j := i
+		for j < len(expr) && (expr[j] == '_' || isAlphaNumeric(expr[j])) {
+			j++
+		}
+		if j == i {
+			return nil, false
+		}
+		c := call{name: expr[i:j]}
+
+		// Read the arguments.
+		if j < len(expr) && expr[j] == '(' {
+			end := strings.IndexByte(expr[j:], ')')
+			if end == -1 {
+				return nil, false
+			}
+			for _, arg := range strings.Split(expr[j+1:j+end], ",") {
+				arg = strings.TrimSpace(arg)
+				if arg == "" {
+					continue
+				}
+				unquoted, err := strconv.Unquote(arg)
+				if err != nil {
+					return nil, false
+				}
+				c.args = append(c.args, unquoted)
+			}
+			j += end + 1
+		}
+		calls = append(calls, c)
+
+		if j == len(expr) {
+			break
+		}
+		if expr[j] != '.' {
+			return nil, false
+		}
+		i = j + 1
+	}
+	return calls, true
+}
+
+// applyCall applies the given method call to the given string value.
+// It returns the result and a boolean indicating if the method is supported.
+func applyCall(value string, c call) (string, bool) {
+	parts := strings.Split(value, ".")
+	switch {
+	case c.name == "to_s" || c.name == "to_str" || c.name == "freeze":
+		return value, true
+	case c.name == "major":
+		return parts[0], true
+	case c.name == "minor" && len(parts) >= 2:
+		return parts[1], true
+	case c.name == "patch" && len(parts) >= 3:
+		return parts[2], true
+	case c.name == "major_minor":
+		return strings.Join(parts[:min(2, len(parts))], "."), true
+	case c.name == "major_minor_patch":
+		return strings.Join(parts[:min(3, len(parts))], "."), true
+	case c.name == "downcase":
+		return strings.ToLower(value), true
+	case c.name == "upcase":
+		return strings.ToUpper(value), true
+	case c.name == "delete" && len(c.args) == 1:
+		return translate(value, c.args[0], ""), true
+	case c.name == "tr" && len(c.args) == 2:
+		return translate(value, c.args[0], c.args[1]), true
+	case (c.name == "sub" || c.name == "gsub") && len(c.args) == 2:
+		n := -1
+		if c.name == "sub" {
+			n = 1
+		}
+		return strings.Replace(value, c.args[0], c.args[1], n), true
+	}
+	return "", false
+}
+
+// translate replaces each character of the given value contained in from
+// with the character at the same position in to, similar to Ruby's String#tr.
+// If to is shorter than from, its last character is used. If to is empty, the characters are deleted.
+func translate(value, from, to string) string {
+	var sb strings.Builder
+	for _, r := range value {
+		i := strings.IndexRune(from, r)
+		switch {
+		case i == -1:
+			sb.WriteRune(r)
+		case to == "":
+			// Delete the character.
+		case i < len(to):
+			sb.WriteByte(to[i])
+		default:
+			sb.WriteByte(to[len(to)-1])
+		}
+	}
+	return sb.String()
+}
+
+// isAlphaNumeric returns true if the given byte is an ASCII letter or digit.
+func isAlphaNumeric(b byte) bool {
+	return ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
+
+// versionFromURL derives a version from the file name of the given archive URL.
+// If no version is found, an empty string is returned.
+// Example:
+// "https://ftp.gnu.org/gnu/gcc/gcc-13.2.0/gcc-13.2.0.tar.xz" => "13.2.0"
+func versionFromURL(url string) string {
+	base := path.Base(strings.TrimRight(url, "/"))
+
+	regex := pattern.Get(setup.URLVersionPattern)
+	if matches := regex.FindStringSubmatch(base); len(matches) >= 2 {
+		return matches[1]
+	}
+	return ""
+}
@@ -2,65 +2,79 @@ package reader
 
 import (
 	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"os"
-	"path/filepath"
-	"regexp"
-	"strings"
 	"sync"
+	"time"
 
 	"main/config"
+	"main/miner/coverage"
 	"main/miner/parser"
+	"main/miner/ruby"
 	"main/miner/setup"
 	"main/miner/types"
 )
 
-type reader struct {
-	formulae map[string]*types.Formula
-	mu       sync.Mutex
-}
-
-func (p *reader) addFormula(formula *types.Formula) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	p.formulae[formula.Name] = formula
-}
-
-// ReadFormulae reads all formulae from the core repository in parallel
+// ReadFormulae reads all formulae from the given source in parallel
 // using the given number of workers. It returns a map of formulae where
 // the key is the name of the formula and the first encountered error.
-func ReadFormulae(coreRepoPath string, readerConfig config.ReaderConfig) (map[string]*types.Formula, error) {
-	// Create a new reader.
-	r := &reader{
-		formulae: make(map[string]*types.Formula),
+// Reading stops as soon as the given context is canceled. In this case,
+// the formulae read so far are returned along with the context's error.
+// If a coverage report is given, the lines of each formula file are classified and added to it.
+// If a hook is given, it is called with each formula once it has been read.
+func ReadFormulae(ctx context.Context, source Source, readerConfig config.ReaderConfig, report *coverage.Report, hook func(*types.Formula)) (map[string]*types.Formula, error) {
+	formulae := make(map[string]*types.Formula)
+
+	out := make(chan *types.Formula)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- StreamFormulae(ctx, source, readerConfig, report, out)
+	}()
+
+	for formula := range out {
+		formulae[formula.Name] = formula
+		if hook != nil {
+			hook(formula)
+		}
 	}
 
-	// Match the fomula files.
-	matches, err := filepath.Glob(coreRepoPath + "/Formula/**/*.rb")
-	if err != nil {
+	err := <-errCh
+	if err != nil && ctx.Err() == nil {
 		return nil, err
 	}
+	return formulae, err
+}
 
-	// Match alias formula files.
-	aliasMatches, err := filepath.Glob(coreRepoPath + "/Aliases/*")
+// StreamFormulae reads all formulae from the given source in parallel using the given number
+// of workers and sends each formula to the given channel as soon as it has been read.
+// The formulae are not retained, thus the memory used is bounded by the number of workers
+// rather than the number of formulae. The channel is closed once all formulae have been sent.
+// It returns the first encountered error. Reading stops as soon as the given context is canceled,
+// in this case the context's error is returned.
+// If a coverage report is given, the lines of each formula file are classified and added to it.
+func StreamFormulae(ctx context.Context, source Source, readerConfig config.ReaderConfig, report *coverage.Report, out chan<- *types.Formula) error {
+	defer close(out)
+
+	matches, err := source.Formulae()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	matches = append(matches, aliasMatches...)
-
 	// Create a context with cancellation capability.
-	ctx, cancel := context.WithCancel(context.Background())
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel() // Ensure cancellation happens when function returns.
 
 	// Create a channel to enqueue the files to be processed.
 	taskCh := make(chan string)
 
 	// Create channel to communicate errors.
-	errCh := make(chan error)
+	// It is buffered, such that no worker blocks when sending an error.
+	errCh := make(chan error, readerConfig.MaxWorkers)
 
 	var wg sync.WaitGroup
 
@@ -74,10 +88,20 @@ func ReadFormulae(coreRepoPath string, readerConfig config.ReaderConfig) (map[st
 				case <-ctx.Done():
 					return
 				default:
-					if err := r.processFile(path, readerConfig.FallbackLicense, readerConfig.DeriveRepo); err != nil {
-						cancel()
-						errCh <- err
+					formula, err := processFile(ctx, source, path, readerConfig, report)
+					if err != nil {
+						// Errors caused by the cancellation of the context are not reported.
+						if ctx.Err() == nil {
+							cancel()
+							errCh <- err
+						}
+						return
+					}
+
+					select {
+					case <-ctx.Done():
 						return
+					case out <- formula:
 					}
 				}
 			}
@@ -86,11 +110,15 @@ func ReadFormulae(coreRepoPath string, readerConfig config.ReaderConfig) (map[st
 
 	// Enqueue the files to be processed.
 	go func() {
+		// Close the channel after all files have been enqueued.
+		defer close(taskCh)
 		for _, path := range matches {
-			taskCh <- path
+			select {
+			case <-ctx.Done():
+				return
+			case taskCh <- path:
+			}
 		}
-		// Close the channel after all files have been enqueued.
-		close(taskCh)
 	}()
 
 	go func() {
@@ -98,53 +126,170 @@ func ReadFormulae(coreRepoPath string, readerConfig config.ReaderConfig) (map[st
 		close(errCh)
 	}()
 
-	// Return the first encountered error.
+	// Wait for all workers to return before the output channel is closed
+	// and return the first encountered error.
+	var firstErr error
 	for err := range errCh {
-		return nil, err
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr != nil {
+		return firstErr
 	}
 
-	return r.formulae, nil
+	// Check whether reading has been canceled by the caller.
+	return parent.Err()
 }
 
-func (p *reader) processFile(path string, fallbackLicense string, deriveRepo bool) error {
-	file, err := os.Open(path)
+// processFile reads and parses the formula file at the given path from the given source.
+func processFile(ctx context.Context, source Source, path string, readerConfig config.ReaderConfig, report *coverage.Report) (*types.Formula, error) {
+	src, err := readSource(source, path)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer file.Close()
 
-	// Parse Formula from file.
-	sourceFormula, err := extractFromFile(file)
+	formula, err := parseFile(ctx, path, src, readerConfig, report)
 	if err != nil {
-		log.Printf("Error parsing file %s: %v\n", path, err)
-		return err
+		return nil, err
 	}
 
-	formula := types.FromSourceFormula(sourceFormula, fallbackLicense, deriveRepo)
-	p.addFormula(formula)
-
 	log.Println("Successfully parsed formula:", formula)
-	return nil
+	return formula, nil
 }
 
-// extractFromFile extracts a formula from a file and returns it as a Formula struct.
-func extractFromFile(file *os.File) (*types.SourceFormula, error) {
-	scanner := bufio.NewScanner(file)
-	formulaParser := &parser.FormulaParser{Scanner: scanner}
+// parseFile parses the formula from the given content of the file at the given path
+// and adds the file to the coverage report, if any. If the repository URL of the formula
+// should have been derived but couldn't, the host of its archive is added to the report as well.
+// It returns an error if parsing exceeds the parse timeout or the context is canceled.
+func parseFile(ctx context.Context, path string, src []byte, readerConfig config.ReaderConfig, report *coverage.Report) (*types.Formula, error) {
+	formula, err := withTimeout(ctx, readerConfig.ParseTimeout, func() (*types.Formula, error) {
+		return readFormula(path, src, readerConfig)
+	})
+	if errors.Is(err, errTimeout) {
+		log.Printf("Error parsing file %s: timed out after %s\n", path, readerConfig.ParseTimeout)
+		return nil, ErrParseTimeout(path, readerConfig.ParseTimeout)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	base := filepath.Base(file.Name())
-	name := strings.TrimSuffix(base, ".rb")
+	// The report is only updated once parsing has finished, such that an abandoned parse never adds to it.
+	if report != nil {
+		report.Add(path, src)
+		if readerConfig.DeriveRepo && formula.RepoURL == "" && len(formula.ArchiveURL) > 0 {
+			report.AddUnmapped(formula.Name, formula.ArchiveURL[0].URL)
+		}
+	}
+	return formula, nil
+}
 
-	formula := &types.SourceFormula{Name: name}
+// withTimeout calls fn and returns its result, unless fn exceeds the given timeout or the context is canceled.
+// In this case, the call of fn is abandoned and errTimeout or the context's error is returned.
+// No timeout applies if it is zero.
+func withTimeout[T any](ctx context.Context, timeout time.Duration, fn func() (T, error)) (T, error) {
+	type result struct {
+		value T
+		err   error
+	}
 
-	fields := setup.BuildStrategies(*formulaParser)
+	// The channel is buffered, such that an abandoned call doesn't block forever.
+	done := make(chan result, 1)
+	go func() {
+		value, err := fn()
+		done <- result{value, err}
+	}()
 
-	results, err := formulaParser.ParseFields(fields)
+	// A nil channel never receives, thus no timeout applies if it is disabled.
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	var zero T
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-timeoutCh:
+		return zero, errTimeout
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// ReadFormula reads the formula with the given name from the given source.
+// The formula is looked up among the formula files first and the alias formula files second.
+func ReadFormula(source Source, name string, readerConfig config.ReaderConfig) (*types.Formula, error) {
+	matches, err := source.Formulae()
 	if err != nil {
-		log.Println("Error parsing fields:", err)
 		return nil, err
 	}
 
+	for _, path := range matches {
+		if formulaName(path) != name {
+			continue
+		}
+
+		src, err := readSource(source, path)
+		if err != nil {
+			return nil, err
+		}
+		return readFormula(path, src, readerConfig)
+	}
+	return nil, fmt.Errorf("formula %s not found", name)
+}
+
+// readSource reads the content of the formula file at the given path from the given source.
+func readSource(source Source, path string) ([]byte, error) {
+	file, err := source.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// readFormula reads the formula from the given content of the file at the given path.
+func readFormula(path string, src []byte, readerConfig config.ReaderConfig) (*types.Formula, error) {
+	// Parse Formula from file.
+	sourceFormula, err := extractFromFile(path, bytes.NewReader(src), readerConfig.LegacyParser)
+	if err != nil {
+		log.Printf("Error parsing file %s: %v\n", path, err)
+		return nil, err
+	}
+
+	return types.FromSourceFormula(sourceFormula, readerConfig.FallbackLicense, readerConfig.DeriveRepo), nil
+}
+
+// extractFromFile extracts a formula from a file and returns it as a Formula struct.
+// By default the fields are extracted from the syntax tree of the file.
+// If legacyParser is true, or the file can't be parsed into a syntax tree,
+// the fields are matched line by line instead.
+func extractFromFile(path string, file io.ReadSeeker, legacyParser bool) (*types.SourceFormula, error) {
+	name := formulaName(path)
+
+	var results map[string]interface{}
+	var sources map[string][]*types.Source
+	var err error
+	if !legacyParser {
+		if results, sources, err = parseTree(file); err != nil {
+			log.Printf("Warning: falling back to line parser for formula %s: %v\n", name, err)
+		}
+	}
+	if legacyParser || err != nil {
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		if results, sources, err = parseLines(file); err != nil {
+			return nil, err
+		}
+	}
+
+	formula := &types.SourceFormula{Name: name}
+
 	// Set the fields of the formula.
 	if results["homepage"] != nil {
 		formula.Homepage = results["homepage"].(string)
@@ -152,6 +297,9 @@ func extractFromFile(file *os.File) (*types.SourceFormula, error) {
 	if results["url"] != nil {
 		formula.Stable = results["url"].(*types.Stable)
 	}
+	if results["sha256"] != nil && formula.Stable != nil {
+		formula.Stable.Checksum = results["sha256"].(string)
+	}
 	if results["mirror"] != nil {
 		formula.Mirror = results["mirror"].(string)
 	}
@@ -164,61 +312,124 @@ func extractFromFile(file *os.File) (*types.SourceFormula, error) {
 	if results["dependency"] != nil {
 		formula.Dependencies = results["dependency"].(*types.Dependencies)
 	}
+	if results["conflict"] != nil {
+		formula.Conflicts = results["conflict"].([]*types.Conflict)
+	}
+	if results["link_overwrite"] != nil {
+		formula.LinkOverwrite = results["link_overwrite"].([]string)
+	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	// Set the values of the custom fields.
+	for _, name := range setup.CustomFields() {
+		if value, ok := results[name]; ok {
+			if formula.Extra == nil {
+				formula.Extra = make(map[string]any)
+			}
+			formula.Extra[name] = value
+		}
 	}
+	formula.SetSources(path, sources)
 
-	// Check if the stable url contains a Ruby string interpolation and resolve it.
-	// This is done here rather then in the cleanURLSequence function because the
-	// variable used for the interpolation can have a global scope in the formula file.
-	// The cleanURLSequence function could only resolve interpolations with a scope within the stable do block.
-	found, resolved, err := checkForInterpolation(formula.Stable.URL, file)
-	if err != nil {
+	// Resolve Ruby string interpolations within the formula's URLs.
+	// This is done here rather then in the clean functions of the strategies because the
+	// variables used for interpolations can have a global scope in the formula file.
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
 		return nil, err
 	}
-	if found {
-		formula.Stable.URL = resolved
+	r, err := newResolver(name, file)
+	if err != nil {
+		return nil, err
 	}
+	resolveInterpolations(formula, r)
 
 	return formula, nil
 }
 
-// checkForInterpolation checks if the given url contains a Ruby string interpolation.
-// If it does, the interpolation is resolved using the given file.
-// The function returns a boolean indicating if an interpolation was found and the resolved string.
-func checkForInterpolation(url string, file *os.File) (bool, string, error) {
-	regex := regexp.MustCompile(setup.InterpolationPattern)
-	matches := regex.FindStringSubmatch(url)
-	if len(matches) < 2 {
-		return false, "", nil
+// parseLines matches the fields of the formula line by line using regular expressions.
+// It returns the fields and the lines they have been matched at.
+func parseLines(file io.Reader) (map[string]interface{}, map[string][]*types.Source, error) {
+	scanner := bufio.NewScanner(file)
+	formulaParser := &parser.FormulaParser{Scanner: scanner}
+
+	fields := setup.BuildStrategies(*formulaParser)
+
+	results, err := formulaParser.ParseFields(fields)
+	if err != nil {
+		log.Println("Error parsing fields:", err)
+		return nil, nil, err
 	}
 
-	varName := matches[1]
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return results, formulaParser.Sources, nil
+}
 
-	// Seek the beginning of the file.
-	if _, err := file.Seek(0, io.SeekStart); err != nil {
-		return true, "", err
+// parseTree extracts the fields of the formula from the syntax tree of the file.
+// It returns the fields and the statements they have been extracted from.
+func parseTree(file io.Reader) (map[string]interface{}, map[string][]*types.Source, error) {
+	src, err := io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	scanner := bufio.NewScanner(file)
+	tree, err := ruby.Parse(string(src))
+	if err != nil {
+		return nil, nil, err
+	}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+	treeParser := &parser.TreeParser{Tree: tree}
+	results, err := treeParser.ParseFields(setup.BuildNodeStrategies())
+	if err != nil {
+		return nil, nil, err
+	}
+	return results, treeParser.Sources, nil
+}
 
-		// Check if the line contains the variable assignment.
-		regex := regexp.MustCompile(setup.VarAssignmentPattern(varName))
-		varMatches := regex.FindStringSubmatch(line)
-		if len(varMatches) >= 2 {
-			// Replace the interpolation with the variable value.
-			resolved := strings.Replace(url, fmt.Sprintf("#{%s}", varName), varMatches[1], 1)
-			return true, resolved, nil
+// resolveInterpolations resolves the Ruby string interpolations within the formula's
+// homepage, mirror, stable, archive and head URLs using the given resolver.
+// If the formula has no explicit version, it is derived from its stable URL.
+// Interpolations which can't be resolved are kept and logged as warnings.
+func resolveInterpolations(formula *types.SourceFormula, r *resolver) {
+	resolve := func(field string, s *string) {
+		resolved, err := r.resolve(*s)
+		if err != nil {
+			log.Printf("Warning: %s of formula %s: %v\n", field, formula.Name, err)
 		}
+		*s = resolved
 	}
 
-	if err := scanner.Err(); err != nil {
-		return true, "", err
+	archives := make([]*types.Archive, 0)
+	if formula.Stable != nil && formula.Stable.Dependencies != nil {
+		archives = append(archives, formula.Stable.Dependencies.Archives...)
+	}
+	if formula.Dependencies != nil {
+		archives = append(archives, formula.Dependencies.Archives...)
 	}
 
-	return true, "", fmt.Errorf("could not resolve interpolation in URL: %s", url)
+	// Derive the version from the stable URL if it is not explicitly defined.
+	if r.version == "" {
+		var url string
+		if formula.Stable != nil && formula.Stable.URL != "" {
+			url = formula.Stable.URL
+		} else if len(archives) > 0 {
+			url = archives[0].URL
+		}
+		url, _ = r.resolve(url)
+		r.version = versionFromURL(url)
+	}
+	formula.Version = r.version
+
+	if formula.Stable != nil {
+		resolve("url", &formula.Stable.URL)
+	}
+	for _, a := range archives {
+		resolve("url", &a.URL)
+	}
+
+	resolve("homepage", &formula.Homepage)
+	resolve("mirror", &formula.Mirror)
+	if formula.Head != nil {
+		resolve("head", &formula.Head.URL)
+	}
 }
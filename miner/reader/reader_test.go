@@ -1,10 +1,18 @@
 package reader
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"main/config"
 	"main/miner/types"
 
 	"github.com/stretchr/testify/assert"
@@ -19,8 +27,10 @@ var extractFromFileTests = []struct {
 		expected: &types.SourceFormula{
 			Name:     "i686-elf-gcc",
 			Homepage: "https://gcc.gnu.org",
+			Version:  "13.2.0",
 			Stable: &types.Stable{
-				URL: "https://ftp.gnu.org/gnu/gcc/gcc-13.2.0/gcc-13.2.0.tar.xz",
+				URL:      "https://ftp.gnu.org/gnu/gcc/gcc-13.2.0/gcc-13.2.0.tar.xz",
+				Checksum: "e275e76442a6067341a27f04c5c6b83d8613144004c0413528863dc6b5c743da",
 			},
 			Mirror:  "https://ftpmirror.gnu.org/gcc/gcc-13.2.0/gcc-13.2.0.tar.xz",
 			License: `"GPL-3.0-or-later" => { with: "GCC-exception-3.1" }`,
@@ -40,8 +50,10 @@ var extractFromFileTests = []struct {
 		expected: &types.SourceFormula{
 			Name:     "pike",
 			Homepage: "https://pike.lysator.liu.se/",
+			Version:  "8.0.1738",
 			Stable: &types.Stable{
-				URL: "https://pike.lysator.liu.se/pub/pike/latest-stable/Pike-v8.0.1738.tar.gz",
+				URL:      "https://pike.lysator.liu.se/pub/pike/latest-stable/Pike-v8.0.1738.tar.gz",
+				Checksum: "1033bc90621896ef6145df448b48fdfa342dbdf01b48fd9ae8acf64f6a31b92a",
 			},
 			Mirror:  "http://deb.debian.org/debian/pool/main/p/pike8.0/pike8.0_8.0.1738.orig.tar.gz",
 			License: `any_of: ["GPL-2.0-only", "LGPL-2.1-only", "MPL-1.1"]`,
@@ -71,8 +83,10 @@ var extractFromFileTests = []struct {
 		expected: &types.SourceFormula{
 			Name:     "srecord",
 			Homepage: "https://srecord.sourceforge.net/",
+			Version:  "1.64",
 			Stable: &types.Stable{
-				URL: "https://downloads.sourceforge.net/project/srecord/srecord/1.64/srecord-1.64.tar.gz",
+				URL:      "https://downloads.sourceforge.net/project/srecord/srecord/1.64/srecord-1.64.tar.gz",
+				Checksum: "49a4418733c508c03ad79a29e95acec9a2fbc4c7306131d2a8f5ef32012e67e2",
 			},
 			Mirror:  "",
 			License: `all_of: ["GPL-3.0-or-later", "LGPL-3.0-or-later"]`,
@@ -93,16 +107,20 @@ var extractFromFileTests = []struct {
 		expected: &types.SourceFormula{
 			Name:     "geckodriver",
 			Homepage: "https://github.com/mozilla/geckodriver",
+			Version:  "0.34.0",
 			Stable: &types.Stable{
-				URL: "https://hg.mozilla.org/mozilla-central/archive/bc25087baba17c78246db06bcab71c299fd8f46f.zip/testing/geckodriver/",
+				URL:      "https://hg.mozilla.org/mozilla-central/archive/bc25087baba17c78246db06bcab71c299fd8f46f.zip/testing/geckodriver/",
+				Checksum: "2282fe6ab8cca3fadbf496b68bbc08632e3084469306ca45ddf757c60232822f",
 				Dependencies: &types.Dependencies{
-					Lst: []*types.Dependency{},
+					Lst:      []*types.Dependency{},
+					Archives: []*types.Archive{},
 				},
 			},
 			Mirror:  "",
 			License: `"MPL-2.0"`,
 			Head: &types.Head{
-				URL: "https://hg.mozilla.org/mozilla-central/",
+				URL:   "https://hg.mozilla.org/mozilla-central/",
+				Using: "hg",
 			},
 			Dependencies: &types.Dependencies{
 				Lst: []*types.Dependency{
@@ -113,24 +131,376 @@ var extractFromFileTests = []struct {
 			},
 		},
 	},
+	{
+		inputFilePath: "../../test-data/binary-tool.rb",
+		expected: &types.SourceFormula{
+			Name:     "binary-tool",
+			Homepage: "https://github.com/example/binary-tool",
+			Version:  "1.4.2",
+			Stable:   nil,
+			Mirror:   "",
+			License:  `"Apache-2.0"`,
+			Head:     nil,
+			Dependencies: &types.Dependencies{
+				Lst: []*types.Dependency{
+					{Name: "glibc", DepType: []string{}, Restriction: "linux"}, // on_linux
+				},
+				Archives: []*types.Archive{
+					{
+						URL:         "https://github.com/example/binary-tool/releases/download/v1.4.2/binary-tool-darwin-arm64.tar.gz",
+						Checksum:    "3f1b4d0c7e7c0a4b4a4f7f2b9c1a8d5e6f0e2d3c4b5a69788796a5b4c3d2e1f0",
+						Restriction: "macos and arm",
+					},
+					{
+						URL:         "https://github.com/example/binary-tool/releases/download/v1.4.2/binary-tool-darwin-amd64.tar.gz",
+						Checksum:    "0f1e2d3c4b5a69788796a5b4c3d2e1f03f1b4d0c7e7c0a4b4a4f7f2b9c1a8d5e",
+						Restriction: "macos and intel",
+					},
+					{
+						URL:         "https://github.com/example/binary-tool/releases/download/v1.4.2/binary-tool-linux-amd64.tar.gz",
+						Checksum:    "9c1a8d5e6f0e2d3c4b5a69788796a5b4c3d2e1f03f1b4d0c7e7c0a4b4a4f7f2b",
+						Restriction: "linux",
+					},
+				},
+			},
+		},
+	},
+	{
+		inputFilePath: "../../test-data/cpu-tool.rb",
+		expected: &types.SourceFormula{
+			Name:     "cpu-tool",
+			Homepage: "https://github.com/example/cpu-tool",
+			Version:  "2.0.1",
+			Stable:   nil,
+			Mirror:   "",
+			License:  `"MIT"`,
+			Head:     nil,
+			Dependencies: &types.Dependencies{
+				Lst: []*types.Dependency{
+					{Name: "glibc", DepType: []string{}, Restriction: "linux"}, // on_linux
+				},
+				Archives: []*types.Archive{
+					{
+						URL:         "https://github.com/example/cpu-tool/releases/download/v2.0.1/cpu-tool-darwin-arm64.tar.gz",
+						Checksum:    "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90",
+						Restriction: "macos and arm",
+					},
+					{
+						URL:         "https://github.com/example/cpu-tool/releases/download/v2.0.1/cpu-tool-darwin-amd64.tar.gz",
+						Checksum:    "0918273645a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b",
+						Restriction: "macos and intel",
+					},
+					{
+						URL:         "https://github.com/example/cpu-tool/releases/download/v2.0.1/cpu-tool-linux-amd64.tar.gz",
+						Checksum:    "f0e1d2c3b4a5968778695a4b3c2d1e0ff0e1d2c3b4a5968778695a4b3c2d1e0f",
+						Restriction: "linux and intel",
+					},
+				},
+			},
+		},
+	},
 }
 
 func TestExtractFromFile(t *testing.T) {
-	for _, test := range extractFromFileTests {
-		file, err := os.Open(test.inputFilePath)
+	// Both the syntax tree and the legacy line parser must extract the same formulae.
+	for _, legacyParser := range []bool{false, true} {
+		for _, test := range extractFromFileTests {
+			file, err := os.Open(test.inputFilePath)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer file.Close()
+
+			formula, err := extractFromFile(test.inputFilePath, file, legacyParser)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			// Sources are covered by TestSources.
+			clearSources(formula)
+
+			expected := *test.expected
+			assert.ElementsMatch(t, expected.Dependencies.Lst, formula.Dependencies.Lst, "legacy: %t, expected: %v, got: %v", legacyParser, expected.Dependencies.Lst, formula.Dependencies.Lst)
+			assert.Equal(t, expected.Dependencies.SystemRequirements, formula.Dependencies.SystemRequirements, "legacy: %t, expected: %v, got: %v", legacyParser, expected.Dependencies.SystemRequirements, formula.Dependencies.SystemRequirements)
+			assert.ElementsMatch(t, expected.Dependencies.Archives, formula.Dependencies.Archives, "legacy: %t, expected: %v, got: %v", legacyParser, expected.Dependencies.Archives, formula.Dependencies.Archives)
+			expected.Dependencies, formula.Dependencies = nil, nil
+			assert.Equal(t, &expected, formula, "legacy: %t, expected: %v, got: %v", legacyParser, &expected, formula)
+		}
+	}
+}
+
+// clearSources removes the sources from the given formula and its dependencies.
+func clearSources(formula *types.SourceFormula) {
+	formula.Sources = nil
+	deps := formula.Dependencies.Lst
+	if formula.Stable != nil && formula.Stable.Dependencies != nil {
+		deps = append(deps, formula.Stable.Dependencies.Lst...)
+	}
+	if formula.Head != nil {
+		deps = append(deps, formula.Head.Dependencies...)
+	}
+	for _, dep := range deps {
+		dep.Source = nil
+	}
+}
+
+func TestSources(t *testing.T) {
+	path := "../../test-data/geckodriver.rb"
+
+	// Both parsers must locate the fields and dependencies at the same lines.
+	for _, legacyParser := range []bool{false, true} {
+		file, err := os.Open(path)
 		if err != nil {
 			log.Fatal(err)
 		}
 		defer file.Close()
 
-		formula, err := extractFromFile(file)
+		formula, err := extractFromFile(path, file, legacyParser)
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		assert.ElementsMatch(t, test.expected.Dependencies.Lst, formula.Dependencies.Lst, "expected: %v, got: %v", test.expected.Dependencies.Lst, formula.Dependencies.Lst)
-		assert.Equal(t, test.expected.Dependencies.SystemRequirements, formula.Dependencies.SystemRequirements, "expected: %v, got: %v", test.expected.Dependencies.SystemRequirements, formula.Dependencies.SystemRequirements)
-		test.expected.Dependencies, formula.Dependencies = nil, nil
-		assert.Equal(t, test.expected, formula, "expected: %v, got: %v", test.expected, formula)
+		for field, line := range map[string]int{"homepage": 3, "license": 4, "head": 5, "url": 7} {
+			if assert.NotEmpty(t, formula.Sources[field], "legacy: %t, no source for field %s", legacyParser, field) {
+				source := formula.Sources[field][0]
+				assert.Equal(t, path, source.Path, "legacy: %t, field: %s", legacyParser, field)
+				assert.Equal(t, line, source.Line, "legacy: %t, field: %s", legacyParser, field)
+			}
+		}
+
+		expected := map[string]*types.Source{
+			"rust":   {Path: path, Line: 47, EndLine: 47, Raw: `depends_on "rust" => :build`},
+			"netcat": {Path: path, Line: 49, EndLine: 49, Raw: `uses_from_macos "netcat" => :test`},
+			"unzip":  {Path: path, Line: 50, EndLine: 50, Raw: `uses_from_macos "unzip"`},
+		}
+		for _, dep := range formula.Dependencies.Lst {
+			assert.Equal(t, expected[dep.Name], dep.Source, "legacy: %t, dependency: %s", legacyParser, dep.Name)
+		}
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	value, err := withTimeout(context.Background(), time.Second, func() (string, error) {
+		return "foo", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", value)
+
+	// A call blocking forever is abandoned.
+	block := make(chan struct{})
+	defer close(block)
+	_, err = withTimeout(context.Background(), 10*time.Millisecond, func() (string, error) {
+		<-block
+		return "", nil
+	})
+	assert.ErrorIs(t, err, errTimeout)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = withTimeout(ctx, 0, func() (string, error) {
+		<-block
+		return "", nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReadFormulaeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	read := 0
+
+	// Cancel reading after the first formula, the formulae read so far are returned.
+	source := NewFSSource(fstest.MapFS{
+		"Formula/a/a.rb": {Data: []byte("class A < Formula\nend")},
+		"Formula/b/b.rb": {Data: []byte("class B < Formula\nend")},
+		"Formula/c/c.rb": {Data: []byte("class C < Formula\nend")},
+	})
+	formulae, err := ReadFormulae(ctx, source, config.ReaderConfig{MaxWorkers: 1}, nil, func(*types.Formula) {
+		read++
+		cancel()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, formulae, read)
+	assert.Contains(t, formulae, "a")
+}
+
+var resolveTests = []struct {
+	input    string
+	expected string
+	err      bool
+}{
+	{
+		input:    "https://ftp.gnu.org/gnu/gcc/gcc-#{version}/gcc-#{version}.tar.xz",
+		expected: "https://ftp.gnu.org/gnu/gcc/gcc-13.2.0/gcc-13.2.0.tar.xz",
+	},
+	{
+		input:    "https://download.gnome.org/sources/glib/#{version.major_minor}/glib-#{version}.tar.xz",
+		expected: "https://download.gnome.org/sources/glib/13.2/glib-13.2.0.tar.xz",
+	},
+	{
+		input:    `https://example.com/#{name}/v#{version.major}/#{name}-#{version.tr(".", "_")}.zip`,
+		expected: "https://example.com/tool/v13/tool-13_2_0.zip",
+	},
+	{
+		input:    "https://hg.mozilla.org/mozilla-central/archive/#{hg_revision}.zip",
+		expected: "https://hg.mozilla.org/mozilla-central/archive/bc25087b.zip",
+	},
+	{
+		input:    "https://example.com/#{BASE_URL}/#{release}.tar.gz",
+		expected: "https://example.com/downloads/tool/13.2.0-1.tar.gz",
+	},
+	{
+		input:    "https://example.com/#{unknown}/#{version}.tar.gz",
+		expected: "https://example.com/#{unknown}/13.2.0.tar.gz",
+		err:      true,
+	},
+}
+
+func TestResolve(t *testing.T) {
+	r, err := newResolver("tool", strings.NewReader(`class Tool < Formula
+  BASE_URL = "downloads/#{name}".freeze
+  release = "#{version}-1"
+  hg_revision = "bc25087b"
+  version "13.2.0"
+end`))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, test := range resolveTests {
+		resolved, err := r.resolve(test.input)
+		assert.Equal(t, test.err, err != nil, "expected error: %t, got: %v", test.err, err)
+		assert.Equal(t, test.expected, resolved, "expected: %s, got: %s", test.expected, resolved)
+	}
+}
+
+var versionFromURLTests = []struct {
+	input    string
+	expected string
+}{
+	{
+		input:    "https://pike.lysator.liu.se/pub/pike/latest-stable/Pike-v8.0.1738.tar.gz",
+		expected: "8.0.1738",
+	},
+	{
+		input:    "http://deb.debian.org/debian/pool/main/p/pike8.0/pike8.0_8.0.1738.orig.tar.gz",
+		expected: "8.0.1738",
+	},
+	{
+		input:    "https://github.com/zyantific/zydis/tree/v4.1.0",
+		expected: "4.1.0",
+	},
+	{
+		input:    "https://hg.mozilla.org/mozilla-central/archive/bc25087baba17c78246db06bcab71c299fd8f46f.zip/testing/geckodriver/",
+		expected: "",
+	},
+}
+
+func TestVersionFromURL(t *testing.T) {
+	for _, test := range versionFromURLTests {
+		version := versionFromURL(test.input)
+		assert.Equal(t, test.expected, version, "expected: %s, got: %s", test.expected, version)
+	}
+}
+
+// benchmarkFiles are the formula files used by the benchmarks.
+var benchmarkFiles = []string{
+	"../../test-data/i686-elf-gcc.rb",
+	"../../test-data/pike.rb",
+	"../../test-data/srecord.rb",
+	"../../test-data/geckodriver.rb",
+	"../../test-data/binary-tool.rb",
+	"../../test-data/cpu-tool.rb",
+}
+
+// benchmarkCorpusCopies is the number of copies of each benchmark file in the synthetic corpus.
+const benchmarkCorpusCopies = 200
+
+// readBenchmarkFiles returns the content of the benchmark files and silences the logs of the reader
+// until the benchmark has finished.
+func readBenchmarkFiles(b *testing.B) [][]byte {
+	srcs := make([][]byte, len(benchmarkFiles))
+	for i, path := range benchmarkFiles {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		srcs[i] = src
+	}
+
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+	return srcs
+}
+
+// benchmarkCorpus returns a synthetic core repository holding copies of the benchmark files.
+func benchmarkCorpus(b *testing.B) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for i, src := range readBenchmarkFiles(b) {
+		name := formulaName(benchmarkFiles[i])
+		for j := 0; j < benchmarkCorpusCopies; j++ {
+			fsys[fmt.Sprintf("Formula/%c/%s-%d.rb", name[0], name, j)] = &fstest.MapFile{Data: src}
+		}
+	}
+	return fsys
+}
+
+// benchmarkReadFormula benchmarks reading all benchmark files using the given parser.
+func benchmarkReadFormula(b *testing.B, legacyParser bool) {
+	srcs := readBenchmarkFiles(b)
+	readerConfig := config.ReaderConfig{DeriveRepo: true, FallbackLicense: "pseudo", LegacyParser: legacyParser}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for j, path := range benchmarkFiles {
+			if _, err := readFormula(path, srcs[j], readerConfig); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkReadFormulaTree(b *testing.B) {
+	benchmarkReadFormula(b, false)
+}
+
+func BenchmarkReadFormulaLegacy(b *testing.B) {
+	benchmarkReadFormula(b, true)
+}
+
+func BenchmarkExtractFromFile(b *testing.B) {
+	srcs := readBenchmarkFiles(b)
+
+	for _, legacyParser := range []bool{false, true} {
+		for i, path := range benchmarkFiles {
+			name := fmt.Sprintf("%s/legacy=%t", formulaName(path), legacyParser)
+			b.Run(name, func(b *testing.B) {
+				b.ReportAllocs()
+				for n := 0; n < b.N; n++ {
+					if _, err := extractFromFile(path, bytes.NewReader(srcs[i]), legacyParser); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkReadFormulae(b *testing.B) {
+	source := NewFSSource(benchmarkCorpus(b))
+
+	for _, legacyParser := range []bool{false, true} {
+		b.Run(fmt.Sprintf("legacy=%t", legacyParser), func(b *testing.B) {
+			readerConfig := config.ReaderConfig{MaxWorkers: 10, DeriveRepo: true, FallbackLicense: "pseudo", LegacyParser: legacyParser}
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				formulae, err := ReadFormulae(context.Background(), source, readerConfig, nil, nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(formulae) != len(benchmarkFiles)*benchmarkCorpusCopies {
+					b.Fatalf("expected %d formulae, got %d", len(benchmarkFiles)*benchmarkCorpusCopies, len(formulae))
+				}
+			}
+		})
 	}
 }
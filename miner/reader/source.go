@@ -0,0 +1,62 @@
+package reader
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+// Source provides the formula files of a core repository.
+type Source interface {
+	// Formulae returns the paths of all formula files, including the alias formula files.
+	Formulae() ([]string, error)
+
+	// Open opens the formula file at the given path.
+	Open(path string) (io.ReadCloser, error)
+}
+
+// fsSource provides the formula files of a core repository from a file system.
+type fsSource struct {
+	fsys fs.FS
+}
+
+// NewFSSource returns a Source reading the formula files from the given file system,
+// whose root is the root of the core repository.
+func NewFSSource(fsys fs.FS) Source {
+	return &fsSource{fsys: fsys}
+}
+
+// NewDirSource returns a Source reading the formula files from the core repository at the given directory.
+func NewDirSource(dir string) Source {
+	return NewFSSource(os.DirFS(dir))
+}
+
+// Formulae returns the paths of the formula files within the "Formula" directory
+// followed by the alias formula files within the "Aliases" directory.
+func (s *fsSource) Formulae() ([]string, error) {
+	// Match the fomula files.
+	matches, err := fs.Glob(s.fsys, "Formula/**/*.rb")
+	if err != nil {
+		return nil, err
+	}
+
+	// Match alias formula files.
+	aliasMatches, err := fs.Glob(s.fsys, "Aliases/*")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(matches, aliasMatches...), nil
+}
+
+// Open opens the formula file at the given path.
+func (s *fsSource) Open(path string) (io.ReadCloser, error) {
+	return s.fsys.Open(path)
+}
+
+// formulaName returns the name of the formula defined by the file at the given path.
+func formulaName(p string) string {
+	return strings.TrimSuffix(path.Base(p), ".rb")
+}
@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"fmt"
+)
+
+var (
+	// ErrNotARepository is returned when a revision should be checked out in a directory which isn't a git repository.
+	ErrNotARepository = func(dir string) error {
+		return fmt.Errorf("%s is not a git repository", dir)
+	}
+
+	// ErrDirtyWorkTree is returned when the work tree of the core repository has uncommitted changes.
+	ErrDirtyWorkTree = func(dir string) error {
+		return fmt.Errorf("the work tree of %s has uncommitted changes", dir)
+	}
+
+	// ErrNotFastForward is returned when the local branch can't be fast-forwarded to the remote branch.
+	ErrNotFastForward = func(branch string) error {
+		return fmt.Errorf("the branch %s can't be fast-forwarded", branch)
+	}
+
+	// ErrUnknownRevision is returned when the pinned revision doesn't exist in the core repository.
+	ErrUnknownRevision = func(revision string) error {
+		return fmt.Errorf("unknown revision %s", revision)
+	}
+)
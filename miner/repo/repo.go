@@ -0,0 +1,300 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"main/config"
+
+	git "gopkg.in/src-d/go-git.v4"
+	gitconfig "gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+// remoteName is the name of the remote the core repository is fetched from.
+const remoteName = "origin"
+
+// Sync synchronizes the local core repository with the given config and returns the SHA of the checked out commit.
+//
+// If cloning is enabled, a missing repository is cloned, and the branch of an existing clone is fetched and
+// fast-forwarded. As go-git can't fetch into a shallow clone, a shallow clone is cloned again instead,
+// unless it has been cloned with the configured depth and the remote branch hasn't moved since.
+// An existing full clone is kept and fetched, even if a depth is configured.
+// If a revision is pinned, it is checked out afterwards. The work tree of an existing clone must
+// be clean before it is changed. Progress messages of the remote are written to progress, which may be nil.
+//
+// If cloning is disabled and no revision is pinned, the repository is left untouched. In this case, the directory
+// isn't required to be a git repository, and an empty SHA is returned if it isn't.
+func Sync(ctx context.Context, c config.CoreRepoConfig, progress io.Writer) (string, error) {
+	repo, err := git.PlainOpen(c.Dir)
+	switch {
+	case errors.Is(err, git.ErrRepositoryNotExists) && c.Clone:
+		repo, err = clone(ctx, c, progress)
+	case errors.Is(err, git.ErrRepositoryNotExists) && c.Revision == "":
+		return "", nil
+	case errors.Is(err, git.ErrRepositoryNotExists):
+		return "", ErrNotARepository(c.Dir)
+	case err == nil && (c.Clone || c.Revision != ""):
+		if err = verifyClean(repo, c.Dir); err != nil || !c.Clone {
+			break
+		}
+		if isShallow(repo) {
+			repo, err = syncShallow(ctx, repo, c, progress)
+		} else {
+			err = update(ctx, repo, c, progress)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if c.Revision != "" {
+		if err := checkout(repo, c.Revision); err != nil {
+			return "", err
+		}
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
+// clone clones the core repository into its directory.
+func clone(ctx context.Context, c config.CoreRepoConfig, progress io.Writer) (*git.Repository, error) {
+	return git.PlainCloneContext(ctx, c.Dir, false, &git.CloneOptions{
+		URL:           c.URL,
+		RemoteName:    remoteName,
+		ReferenceName: branchRef(c.Branch),
+		SingleBranch:  c.SingleBranch,
+		Depth:         c.Depth,
+		Tags:          tagMode(c),
+		Progress:      progress,
+	})
+}
+
+// reclone replaces the clone of the core repository with a new clone.
+// The new clone is created next to the existing one, such that the existing one is kept if cloning fails.
+func reclone(ctx context.Context, c config.CoreRepoConfig, progress io.Writer) (*git.Repository, error) {
+	tmp, err := os.MkdirTemp(filepath.Dir(filepath.Clean(c.Dir)), ".clone-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	tc := c
+	tc.Dir = tmp
+	if _, err := clone(ctx, tc, progress); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(c.Dir); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp, c.Dir); err != nil {
+		return nil, err
+	}
+	return git.PlainOpen(c.Dir)
+}
+
+// syncShallow brings the shallow clone of the core repository up to date. If it has been cloned with another depth
+// or the remote branch has moved, it is cloned again. Otherwise, the local branch is checked out.
+func syncShallow(ctx context.Context, repo *git.Repository, c config.CoreRepoConfig, progress io.Writer) (*git.Repository, error) {
+	current, err := isCurrent(repo, c)
+	if err != nil {
+		return nil, err
+	}
+	if !current {
+		return reclone(ctx, c, progress)
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	return repo, w.Checkout(&git.CheckoutOptions{Branch: branchRef(c.Branch), Force: true})
+}
+
+// isCurrent returns true if the given shallow clone has been cloned with the configured depth,
+// its local branch points to the same commit as the remote branch and the pinned revision, if any, is known.
+func isCurrent(repo *git.Repository, c config.CoreRepoConfig) (bool, error) {
+	depth, err := cloneDepth(repo)
+	if err != nil || depth != c.Depth {
+		return false, err
+	}
+
+	local, err := repo.Reference(branchRef(c.Branch), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	remote, err := repo.Remote(remoteName)
+	if err != nil {
+		return false, err
+	}
+	refs, err := remote.List(&git.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+	i := slices.IndexFunc(refs, func(ref *plumbing.Reference) bool {
+		return ref.Name() == branchRef(c.Branch)
+	})
+	if i < 0 || refs[i].Hash() != local.Hash() {
+		return false, nil
+	}
+
+	if c.Revision != "" {
+		if _, err := repo.ResolveRevision(plumbing.Revision(c.Revision)); err != nil {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// cloneDepth returns the number of commits of the given shallow clone,
+// counted along the first parents from the HEAD to the shallow boundary.
+func cloneDepth(repo *git.Repository) (int, error) {
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return 0, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return 0, err
+	}
+	hash := head.Hash()
+	for depth := 1; ; depth++ {
+		if slices.Contains(shallow, hash) {
+			return depth, nil
+		}
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return 0, err
+		}
+		if len(commit.ParentHashes) == 0 {
+			return depth, nil
+		}
+		hash = commit.ParentHashes[0]
+	}
+}
+
+// update fetches the branch of the core repository and fast-forwards the local branch to it.
+// The local branch is checked out, even if another branch or commit has been checked out before.
+func update(ctx context.Context, repo *git.Repository, c config.CoreRepoConfig, progress io.Writer) error {
+	remote := plumbing.NewRemoteReferenceName(remoteName, c.Branch)
+	refSpecs := []gitconfig.RefSpec{
+		gitconfig.RefSpec(fmt.Sprintf("+%s:%s", branchRef(c.Branch), remote)),
+	}
+	err := repo.FetchContext(ctx, &git.FetchOptions{
+		RemoteName: remoteName,
+		RefSpecs:   refSpecs,
+		Tags:       tagMode(c),
+		Progress:   progress,
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return err
+	}
+
+	remoteRef, err := repo.Reference(remote, true)
+	if err != nil {
+		return err
+	}
+
+	local, err := repo.Reference(branchRef(c.Branch), true)
+	switch {
+	case errors.Is(err, plumbing.ErrReferenceNotFound):
+	case err != nil:
+		return err
+	case local.Hash() != remoteRef.Hash():
+		if err := verifyFastForward(repo, local.Hash(), remoteRef.Hash(), c.Branch); err != nil {
+			return err
+		}
+	}
+
+	if err := repo.Storer.SetReference(plumbing.NewHashReference(branchRef(c.Branch), remoteRef.Hash())); err != nil {
+		return err
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return w.Checkout(&git.CheckoutOptions{Branch: branchRef(c.Branch), Force: true})
+}
+
+// verifyFastForward returns an error unless the commit from is an ancestor of the commit to.
+func verifyFastForward(repo *git.Repository, from, to plumbing.Hash, branch string) error {
+	fromCommit, err := repo.CommitObject(from)
+	if err != nil {
+		return err
+	}
+	toCommit, err := repo.CommitObject(to)
+	if err != nil {
+		return err
+	}
+	ok, err := fromCommit.IsAncestor(toCommit)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotFastForward(branch)
+	}
+	return nil
+}
+
+// checkout checks out the commit of the given commit SHA or tag, detaching the HEAD.
+func checkout(repo *git.Repository, revision string) error {
+	hash, err := repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrUnknownRevision(revision), err)
+	}
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	return w.Checkout(&git.CheckoutOptions{Hash: *hash, Force: true})
+}
+
+// verifyClean returns an error if the work tree of the given repository has uncommitted changes.
+func verifyClean(repo *git.Repository, dir string) error {
+	w, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	status, err := w.Status()
+	if err != nil {
+		return err
+	}
+	if !status.IsClean() {
+		return ErrDirtyWorkTree(dir)
+	}
+	return nil
+}
+
+// isShallow returns true if the given repository is a shallow clone.
+func isShallow(repo *git.Repository) bool {
+	shallow, err := repo.Storer.Shallow()
+	return err == nil && len(shallow) > 0
+}
+
+// branchRef returns the name of the reference of the given local branch.
+func branchRef(branch string) plumbing.ReferenceName {
+	return plumbing.ReferenceName("refs/heads/" + branch)
+}
+
+// tagMode returns the tags to fetch. All tags are fetched if a revision is pinned, as it may be a tag.
+func tagMode(c config.CoreRepoConfig) git.TagMode {
+	if c.Revision != "" {
+		return git.AllTags
+	}
+	return git.TagFollowing
+}
@@ -0,0 +1,262 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"main/config"
+
+	"github.com/stretchr/testify/assert"
+	git "gopkg.in/src-d/go-git.v4"
+	gitconfig "gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+// upstream is a work tree pushing to a bare repository, which is cloned by the tests.
+type upstream struct {
+	dir  string
+	repo *git.Repository
+	w    *git.Worktree
+
+	// The URL of the bare repository.
+	url string
+}
+
+// newUpstream creates a bare repository and a work tree with the bare repository as its remote.
+func newUpstream(t *testing.T) *upstream {
+	bare := t.TempDir()
+	if _, err := git.PlainInit(bare, true); err != nil {
+		log.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{"file://" + bare}}); err != nil {
+		log.Fatal(err)
+	}
+	w, err := repo.Worktree()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return &upstream{dir: dir, repo: repo, w: w, url: "file://" + bare}
+}
+
+// commit writes the given content to the file at the given path, commits it and pushes it to the bare repository.
+func (u *upstream) commit(path, content string) string {
+	if err := os.MkdirAll(filepath.Join(u.dir, filepath.Dir(path)), 0755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(u.dir, path), []byte(content), 0644); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := u.w.Add(path); err != nil {
+		log.Fatal(err)
+	}
+	hash, err := u.w.Commit("Update "+path, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	u.push()
+	return hash.String()
+}
+
+// tag tags the given commit and pushes the tag to the bare repository.
+func (u *upstream) tag(name, hash string) {
+	if err := os.WriteFile(filepath.Join(u.dir, ".git", "refs", "tags", name), []byte(hash+"\n"), 0644); err != nil {
+		log.Fatal(err)
+	}
+	u.push()
+}
+
+// push pushes the master branch and all tags to the bare repository.
+func (u *upstream) push() {
+	err := u.repo.Push(&git.PushOptions{
+		RefSpecs: []gitconfig.RefSpec{"refs/heads/master:refs/heads/master", "refs/tags/*:refs/tags/*"},
+	})
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		log.Fatal(err)
+	}
+}
+
+// coreRepoConfig returns the config of a clone of the given upstream.
+func coreRepoConfig(t *testing.T, u *upstream) config.CoreRepoConfig {
+	return config.CoreRepoConfig{
+		URL:    u.url,
+		Branch: "master",
+		Dir:    filepath.Join(t.TempDir(), "core"),
+		Clone:  true,
+	}
+}
+
+// countCommits returns the number of commits reachable from the HEAD of the repository at the given directory.
+// The history of a shallow clone ends at the first missing commit.
+func countCommits(dir string) int {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	iter, err := repo.Log(&git.LogOptions{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	n := 0
+	err = iter.ForEach(func(*object.Commit) error {
+		n++
+		return nil
+	})
+	if err != nil && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		log.Fatal(err)
+	}
+	return n
+}
+
+func TestSync(t *testing.T) {
+	u := newUpstream(t)
+	u.commit("Formula/f/foo.rb", "class Foo < Formula\nend\n")
+	c := coreRepoConfig(t, u)
+
+	// A missing repository is cloned.
+	second := u.commit("Formula/f/foo.rb", "class Foo < Formula\n  license \"MIT\"\nend\n")
+	commit, err := Sync(context.Background(), c, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, second, commit)
+	assert.Equal(t, 2, countCommits(c.Dir))
+
+	// An existing clone is fast-forwarded.
+	third := u.commit("Formula/b/bar.rb", "class Bar < Formula\nend\n")
+	commit, err = Sync(context.Background(), c, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, third, commit)
+	assert.FileExists(t, filepath.Join(c.Dir, "Formula/b/bar.rb"))
+
+	// An existing clone is left untouched if cloning is disabled.
+	u.commit("Formula/b/baz.rb", "class Baz < Formula\nend\n")
+	c.Clone = false
+	commit, err = Sync(context.Background(), c, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, third, commit)
+
+	// A directory which isn't a git repository is accepted unless a revision is pinned.
+	plain := config.CoreRepoConfig{Dir: t.TempDir()}
+	commit, err = Sync(context.Background(), plain, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, commit)
+	plain.Revision = second
+	_, err = Sync(context.Background(), plain, nil)
+	assert.EqualError(t, err, ErrNotARepository(plain.Dir).Error())
+}
+
+func TestSyncRevision(t *testing.T) {
+	u := newUpstream(t)
+	first := u.commit("Formula/f/foo.rb", "class Foo < Formula\nend\n")
+	u.tag("v1", first)
+	second := u.commit("Formula/b/bar.rb", "class Bar < Formula\nend\n")
+	u.commit("Formula/b/baz.rb", "class Baz < Formula\nend\n")
+
+	// A pinned tag is checked out.
+	c := coreRepoConfig(t, u)
+	c.Revision = "v1"
+	commit, err := Sync(context.Background(), c, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, first, commit)
+	assert.NoFileExists(t, filepath.Join(c.Dir, "Formula/b/bar.rb"))
+
+	// A pinned commit SHA is checked out after updating the clone.
+	c.Revision = second
+	commit, err = Sync(context.Background(), c, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, second, commit)
+	assert.FileExists(t, filepath.Join(c.Dir, "Formula/b/bar.rb"))
+	assert.NoFileExists(t, filepath.Join(c.Dir, "Formula/b/baz.rb"))
+
+	// Without a pinned revision, the branch is checked out again.
+	c.Revision = ""
+	if _, err := Sync(context.Background(), c, nil); err != nil {
+		log.Fatal(err)
+	}
+	assert.FileExists(t, filepath.Join(c.Dir, "Formula/b/baz.rb"))
+
+	c.Revision = "v2"
+	_, err = Sync(context.Background(), c, nil)
+	assert.ErrorContains(t, err, ErrUnknownRevision("v2").Error())
+}
+
+func TestSyncShallow(t *testing.T) {
+	u := newUpstream(t)
+	u.commit("Formula/f/foo.rb", "class Foo < Formula\nend\n")
+	u.commit("Formula/b/bar.rb", "class Bar < Formula\nend\n")
+
+	c := coreRepoConfig(t, u)
+	c.Depth = 1
+	c.SingleBranch = true
+	if _, err := Sync(context.Background(), c, nil); err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, 1, countCommits(c.Dir))
+
+	third := u.commit("Formula/b/baz.rb", "class Baz < Formula\nend\n")
+	commit, err := Sync(context.Background(), c, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, third, commit)
+	assert.Equal(t, 1, countCommits(c.Dir))
+
+	// A clone of the configured depth isn't cloned again as long as the remote branch hasn't moved.
+	marker := filepath.Join(c.Dir, ".git", "marker")
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		log.Fatal(err)
+	}
+	commit, err = Sync(context.Background(), c, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, third, commit)
+	assert.FileExists(t, marker)
+
+	// A clone of another depth is cloned again.
+	c.Depth = 2
+	commit, err = Sync(context.Background(), c, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, third, commit)
+	assert.Equal(t, 2, countCommits(c.Dir))
+	assert.NoFileExists(t, marker)
+}
+
+func TestSyncDirty(t *testing.T) {
+	u := newUpstream(t)
+	u.commit("Formula/f/foo.rb", "class Foo < Formula\nend\n")
+
+	c := coreRepoConfig(t, u)
+	if _, err := Sync(context.Background(), c, nil); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(c.Dir, "Formula/f/foo.rb"), []byte("class Foo\n"), 0644); err != nil {
+		log.Fatal(err)
+	}
+	_, err := Sync(context.Background(), c, nil)
+	assert.EqualError(t, err, ErrDirtyWorkTree(c.Dir).Error())
+}
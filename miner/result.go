@@ -0,0 +1,48 @@
+package miner
+
+import (
+	"sort"
+
+	"main/miner/coverage"
+	"main/miner/types"
+)
+
+// Result holds the formulae mined from a core repository.
+type Result struct {
+	// A map of formulae, where the key is the name of the formula.
+	formulae map[string]*types.Formula
+
+	// The coverage report of the formula files, if enabled.
+	coverage *coverage.Report
+}
+
+// Formulae returns a map of all mined formulae, where the key is the name of the formula.
+func (r *Result) Formulae() map[string]*types.Formula {
+	return r.formulae
+}
+
+// Formula returns the formula with the given name and whether it has been mined.
+func (r *Result) Formula(name string) (*types.Formula, bool) {
+	f, ok := r.formulae[name]
+	return f, ok
+}
+
+// Names returns the sorted names of all mined formulae.
+func (r *Result) Names() []string {
+	names := make([]string, 0, len(r.formulae))
+	for name := range r.formulae {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Len returns the number of mined formulae.
+func (r *Result) Len() int {
+	return len(r.formulae)
+}
+
+// Coverage returns the coverage report of the formula files, or nil if it has not been enabled.
+func (r *Result) Coverage() *coverage.Report {
+	return r.coverage
+}
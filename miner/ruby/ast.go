@@ -0,0 +1,271 @@
+package ruby
+
+// NodeKind represents the kind of a statement node.
+type NodeKind int
+
+const (
+	// Program is the root node of a source.
+	Program NodeKind = iota
+
+	// Class is a class definition, e.g. "class Foo < Formula".
+	Class
+
+	// Module is a module definition.
+	Module
+
+	// Def is a method definition. Its body is parsed, but not interpreted.
+	Def
+
+	// Call is a method call, e.g. `depends_on "foo" => :build`, optionally with a block.
+	Call
+
+	// Assign is an assignment to a variable or constant, e.g. `BASE_URL = "..."`.
+	Assign
+
+	// Control is a control structure, e.g. an "if ... end" or "case ... end" block.
+	Control
+
+	// Expr is any other expression statement.
+	Expr
+)
+
+var nodeKindNames = map[NodeKind]string{
+	Program: "Program",
+	Class:   "Class",
+	Module:  "Module",
+	Def:     "Def",
+	Call:    "Call",
+	Assign:  "Assign",
+	Control: "Control",
+	Expr:    "Expr",
+}
+
+func (k NodeKind) String() string {
+	return nodeKindNames[k]
+}
+
+// Node represents a statement of a Ruby source.
+type Node struct {
+	// Kind of the node.
+	Kind NodeKind
+
+	// Name of the node, i.e. the name of the class, module, method, called method or assigned variable.
+	// For control structures, it's the opening keyword, e.g. "if".
+	Name string
+
+	// Receiver of a method call, e.g. "bin" for `bin.install "foo"`, or the superclass of a class.
+	Receiver string
+
+	// Arguments of a method call.
+	Args []*Value
+
+	// Value of an assignment, or the condition of a control structure.
+	Value *Value
+
+	// Modifier of the statement, e.g. `if OS.mac?` for `depends_on "foo" if OS.mac?`.
+	Modifier *Modifier
+
+	// Statements of the block attached to a call, or the body of a definition or control structure.
+	Body []*Node
+
+	// Statements of the "else", "elsif", "when", "rescue" and "ensure" clauses of a control structure.
+	Else []*Node
+
+	// Raw source of the statement.
+	Raw string
+
+	// Line and EndLine are the first and the last line of the statement.
+	Line, EndLine int
+
+	// Byte offsets of the statement in the source.
+	start, end int
+}
+
+// Modifier represents a trailing "if", "unless", "while", "until" or "rescue" modifier of a statement.
+type Modifier struct {
+	// Keyword of the modifier.
+	Keyword string
+
+	// Condition of the modifier.
+	Cond *Value
+}
+
+// ValueKind represents the kind of a value.
+type ValueKind int
+
+const (
+	// StringValue is a string literal. Adjacent literals are concatenated.
+	StringValue ValueKind = iota
+
+	// SymbolValue is a symbol literal.
+	SymbolValue
+
+	// NumberValue is a numeric literal.
+	NumberValue
+
+	// RegexpValue is a regular expression literal.
+	RegexpValue
+
+	// ArrayValue is an array literal, including "%w[]" literals.
+	ArrayValue
+
+	// HashValue is a hash literal, including the implicit hash of trailing call arguments.
+	HashValue
+
+	// ExprValue is any other expression, e.g. a method call or a binary operation.
+	ExprValue
+)
+
+// Value represents an argument or an operand of a statement.
+type Value struct {
+	// Kind of the value.
+	Kind ValueKind
+
+	// Text of the value, i.e. the raw content of a string, the name of a symbol
+	// or the raw source of any other value.
+	Text string
+
+	// Elements of an array.
+	Elems []*Value
+
+	// Pairs of a hash.
+	Pairs []*Pair
+
+	// Raw source of the value.
+	Raw string
+
+	// Line and EndLine are the first and the last line of the value.
+	Line, EndLine int
+
+	// Byte offsets of the value in the source.
+	start, end int
+}
+
+// Pair represents a key-value pair of a hash.
+type Pair struct {
+	Key   *Value
+	Value *Value
+}
+
+// IsCall returns true if the node is a call without a receiver of one of the given methods.
+func (n *Node) IsCall(names ...string) bool {
+	if n.Kind != Call || n.Receiver != "" {
+		return false
+	}
+	for _, name := range names {
+		if n.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Arg returns the argument at the given index, or nil if there is none.
+func (n *Node) Arg(i int) *Value {
+	if i < 0 || i >= len(n.Args) {
+		return nil
+	}
+	return n.Args[i]
+}
+
+// StringArg returns the content of the string argument at the given index.
+// The boolean is false if the argument is missing or not a string.
+func (n *Node) StringArg(i int) (string, bool) {
+	arg := n.Arg(i)
+	if arg == nil || arg.Kind != StringValue {
+		return "", false
+	}
+	return arg.Text, true
+}
+
+// Options returns the trailing hash argument of a call, or nil if there is none.
+func (n *Node) Options() *Value {
+	if len(n.Args) == 0 {
+		return nil
+	}
+	if last := n.Args[len(n.Args)-1]; last.Kind == HashValue {
+		return last
+	}
+	return nil
+}
+
+// ArgsRaw returns the raw source of all arguments of a call.
+func (n *Node) ArgsRaw() string {
+	if len(n.Args) == 0 {
+		return ""
+	}
+	start := n.Args[0].start - n.start
+	end := n.Args[len(n.Args)-1].end - n.start
+	return n.Raw[start:end]
+}
+
+// Walk calls the given function for the node and all its nested statements in source order.
+// If the function returns false, the nested statements of the node are skipped.
+func (n *Node) Walk(fn func(*Node) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, child := range n.Body {
+		child.Walk(fn)
+	}
+	for _, child := range n.Else {
+		child.Walk(fn)
+	}
+}
+
+// FormulaClass returns the first class definition inheriting from "Formula",
+// or the first class definition if there is none. It returns nil if the source contains no class.
+func (n *Node) FormulaClass() *Node {
+	var first, formula *Node
+	n.Walk(func(node *Node) bool {
+		if node.Kind != Class || formula != nil {
+			return formula == nil
+		}
+		if first == nil {
+			first = node
+		}
+		if node.Receiver == "Formula" {
+			formula = node
+		}
+		return true
+	})
+	if formula != nil {
+		return formula
+	}
+	return first
+}
+
+// Get returns the value of the given key of a hash, or nil if the key is missing or the value is no hash.
+// Keys are compared by the text of symbols, labels and strings.
+func (v *Value) Get(key string) *Value {
+	if v == nil || v.Kind != HashValue {
+		return nil
+	}
+	for _, pair := range v.Pairs {
+		if (pair.Key.Kind == SymbolValue || pair.Key.Kind == StringValue) && pair.Key.Text == key {
+			return pair.Value
+		}
+	}
+	return nil
+}
+
+// Strings returns the texts of all string and symbol elements of an array,
+// or the text of the value itself if it's a string or a symbol.
+func (v *Value) Strings() []string {
+	if v == nil {
+		return nil
+	}
+	switch v.Kind {
+	case StringValue, SymbolValue:
+		return []string{v.Text}
+	case ArrayValue:
+		strs := make([]string, 0, len(v.Elems))
+		for _, elem := range v.Elems {
+			if elem.Kind == StringValue || elem.Kind == SymbolValue {
+				strs = append(strs, elem.Text)
+			}
+		}
+		return strs
+	}
+	return nil
+}
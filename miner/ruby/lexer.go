@@ -0,0 +1,552 @@
+package ruby
+
+import (
+	"fmt"
+	"strings"
+)
+
+// heredoc represents a heredoc whose body is yet to be read.
+type heredoc struct {
+	// Index of the heredoc's token.
+	index int
+
+	// Identifier terminating the heredoc.
+	id string
+
+	// Indicates if the terminator may be indented, i.e. "<<~" or "<<-".
+	indented bool
+}
+
+// lexer splits a Ruby source into tokens.
+type lexer struct {
+	src    string
+	pos    int
+	line   int
+	tokens []Token
+
+	// Heredocs started on the current line, whose bodies start on the next line.
+	pending []heredoc
+}
+
+// Lex splits the given Ruby source into tokens.
+// Comments, line continuations and everything after "__END__" are dropped.
+// The returned slice always ends with an EOF token.
+func Lex(src string) ([]Token, error) {
+	l := &lexer{src: src, line: 1}
+	if err := l.run(); err != nil {
+		return nil, err
+	}
+	return l.tokens, nil
+}
+
+func (l *lexer) run() error {
+	space := false
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+
+		// Block comments and the data section are only recognized at the start of a line.
+		if l.atLineStart() {
+			if l.hasPrefix("=begin") {
+				l.skipBlockComment()
+				continue
+			}
+			if l.hasPrefix("__END__") && l.lineRest(len("__END__")) == "" {
+				l.pos = len(l.src)
+				break
+			}
+		}
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos++
+			space = true
+			continue
+		case c == '\\' && l.peekByte(1) == '\n':
+			// Line continuation.
+			l.pos += 2
+			l.line++
+			space = true
+			continue
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		case c == '\n':
+			l.emit(Newline, "\n", l.pos, l.pos+1, space)
+			l.tokens[len(l.tokens)-1].Line = l.line
+			l.pos++
+			l.line++
+			if err := l.readHeredocBodies(); err != nil {
+				return err
+			}
+		case c == ';':
+			l.emit(Newline, ";", l.pos, l.pos+1, space)
+			l.pos++
+		case c == '"' || c == '`' || c == '\'':
+			if err := l.lexString(c, space); err != nil {
+				return err
+			}
+		case c == ':' && l.peekByte(1) == '"':
+			start := l.pos
+			l.pos++
+			text, err := l.readDelimited('"', '"', true)
+			if err != nil {
+				return err
+			}
+			l.emit(Symbol, text, start, l.pos, space)
+		case c == ':' && isIdentStart(l.peekByte(1)):
+			start := l.pos
+			l.pos++
+			name := l.readIdent()
+			l.emit(Symbol, name, start, l.pos, space)
+		case c == '%' && l.isPercentLiteral(space):
+			if err := l.lexPercentLiteral(space); err != nil {
+				return err
+			}
+		case c == '/' && l.isRegexpStart(space):
+			start := l.pos
+			l.pos++
+			text, err := l.readDelimited('/', '/', true)
+			if err != nil {
+				return err
+			}
+			l.skipRegexpFlags()
+			l.emit(Regexp, text, start, l.pos, space)
+		case c == '<' && l.isHeredocStart(space):
+			l.lexHeredoc(space)
+		case isDigit(c):
+			l.lexNumber(space)
+		case isIdentStart(c) || c == '@' || c == '$':
+			l.lexIdent(space)
+		default:
+			if err := l.lexOperator(space); err != nil {
+				return err
+			}
+		}
+		space = false
+	}
+
+	if len(l.pending) > 0 {
+		return fmt.Errorf("line %d: unterminated heredoc %s", l.line, l.pending[0].id)
+	}
+	l.emit(EOF, "", len(l.src), len(l.src), space)
+	return nil
+}
+
+// emit appends a token of the given kind to the list of tokens.
+// The current line must be the last line of the token.
+func (l *lexer) emit(kind TokenKind, text string, start, end int, space bool) {
+	line := l.line - strings.Count(l.src[start:end], "\n")
+	l.tokens = append(l.tokens, Token{Kind: kind, Text: text, Start: start, End: end, Line: line, SpaceBefore: space, endLine: l.line})
+}
+
+// last returns the last emitted token, or an EOF token if none was emitted yet.
+func (l *lexer) last() Token {
+	if len(l.tokens) == 0 {
+		return Token{Kind: EOF}
+	}
+	return l.tokens[len(l.tokens)-1]
+}
+
+func (l *lexer) peekByte(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(l.src[l.pos:], prefix)
+}
+
+func (l *lexer) atLineStart() bool {
+	return l.pos == 0 || l.src[l.pos-1] == '\n'
+}
+
+// lineRest returns the trimmed rest of the current line after the given offset.
+func (l *lexer) lineRest(offset int) string {
+	rest := l.src[l.pos+offset:]
+	if i := strings.IndexByte(rest, '\n'); i != -1 {
+		rest = rest[:i]
+	}
+	return strings.TrimSpace(rest)
+}
+
+// skipBlockComment skips a "=begin ... =end" comment.
+func (l *lexer) skipBlockComment() {
+	for l.pos < len(l.src) {
+		isEnd := l.atLineStart() && l.hasPrefix("=end")
+		for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+			l.pos++
+		}
+		if l.pos < len(l.src) {
+			l.pos++
+			l.line++
+		}
+		if isEnd {
+			return
+		}
+	}
+}
+
+// valuePosition returns true if the next token starts a new value,
+// i.e. the last token can't be the end of an expression.
+// It is used to tell apart ambiguous tokens like "/" (division or regexp).
+func (l *lexer) valuePosition() bool {
+	last := l.last()
+	switch last.Kind {
+	case EOF, Newline, Label:
+		return true
+	case Op:
+		return !last.is(Op, ")", "]", "}")
+	case Keyword:
+		return !last.is(Keyword, "end", "self", "true", "false", "nil")
+	}
+	return false
+}
+
+// isCommandArg returns true if the last token is an identifier followed by a space,
+// and the current character is not followed by a space, e.g. "regex /foo/".
+func (l *lexer) isCommandArg(space bool) bool {
+	next := l.peekByte(1)
+	return l.last().Kind == Ident && space && next != ' ' && next != '=' && next != '\n'
+}
+
+func (l *lexer) isRegexpStart(space bool) bool {
+	return l.valuePosition() || l.isCommandArg(space)
+}
+
+func (l *lexer) isPercentLiteral(space bool) bool {
+	if !l.valuePosition() && !l.isCommandArg(space) {
+		return false
+	}
+	next := l.peekByte(1)
+	if strings.IndexByte("wWiIqQrsx", next) != -1 {
+		return isDelimiter(l.peekByte(2))
+	}
+	return strings.IndexByte("([{<|!/", next) != -1
+}
+
+func (l *lexer) isHeredocStart(space bool) bool {
+	if !l.hasPrefix("<<") {
+		return false
+	}
+	i := 2
+	if c := l.peekByte(i); c == '~' || c == '-' {
+		i++
+	}
+	c := l.peekByte(i)
+	if c == '"' || c == '\'' {
+		c = l.peekByte(i + 1)
+	}
+	if !isIdentStart(c) {
+		return false
+	}
+	// "a <<b" is ambiguous, so a heredoc requires a value position
+	// or an uppercase identifier, e.g. "write <<~EOS".
+	return l.valuePosition() || (space && (i == 3 || ('A' <= c && c <= 'Z')))
+}
+
+// lexString lexes a string literal delimited by the given quote.
+func (l *lexer) lexString(quote byte, space bool) error {
+	start := l.pos
+	l.pos++
+	text, err := l.readDelimited(quote, quote, quote != '\'')
+	if err != nil {
+		return err
+	}
+	l.emitStringOrLabel(text, start, space)
+	return nil
+}
+
+// emitStringOrLabel emits a string token, or a label token if the string is directly followed by a colon,
+// e.g. "\"foo\": 1".
+func (l *lexer) emitStringOrLabel(text string, start int, space bool) {
+	if l.peekByte(0) == ':' && isLabelEnd(l.peekByte(1)) {
+		l.pos++
+		l.emit(Label, text, start, l.pos, space)
+		return
+	}
+	l.emit(String, text, start, l.pos, space)
+}
+
+// readDelimited reads until the given closing delimiter and returns the raw content.
+// The opening delimiter must already be consumed. Nested pairs of delimiters are balanced.
+// If interpolate is true, "#{...}" interpolations may contain nested literals and delimiters.
+func (l *lexer) readDelimited(open, close byte, interpolate bool) (string, error) {
+	start, line := l.pos, l.line
+	depth := 0
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\':
+			if l.peekByte(1) == '\n' {
+				l.line++
+			}
+			l.pos += 2
+			continue
+		case c == '\n':
+			l.line++
+		case interpolate && c == '#' && l.peekByte(1) == '{':
+			l.pos += 2
+			if err := l.skipInterpolation(); err != nil {
+				return "", err
+			}
+			continue
+		case c == close && depth == 0:
+			text := l.src[start:l.pos]
+			l.pos++
+			return text, nil
+		case c == close:
+			depth--
+		case c == open:
+			depth++
+		}
+		l.pos++
+	}
+	return "", fmt.Errorf("line %d: unterminated literal", line)
+}
+
+// skipInterpolation skips the content of an interpolation up to and including the closing brace.
+// The opening "#{" must already be consumed.
+func (l *lexer) skipInterpolation() error {
+	line := l.line
+	depth := 0
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		switch c {
+		case '\n':
+			l.line++
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		case '"', '`':
+			if _, err := l.readDelimited(c, c, true); err != nil {
+				return err
+			}
+		case '\'':
+			if _, err := l.readDelimited(c, c, false); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("line %d: unterminated interpolation", line)
+}
+
+// lexPercentLiteral lexes a percent literal, e.g. "%w[a b]" or "%r{^v(\d+)$}".
+func (l *lexer) lexPercentLiteral(space bool) error {
+	start := l.pos
+	l.pos++
+
+	kind := byte('Q')
+	if !isDelimiter(l.peekByte(0)) {
+		kind = l.src[l.pos]
+		l.pos++
+	}
+
+	open := l.src[l.pos]
+	close := closingDelimiter(open)
+	l.pos++
+
+	text, err := l.readDelimited(open, close, kind != 'q' && kind != 'w' && kind != 'i')
+	if err != nil {
+		return err
+	}
+
+	switch kind {
+	case 'w', 'W', 'i', 'I':
+		l.emit(Words, text, start, l.pos, space)
+	case 'r':
+		l.skipRegexpFlags()
+		l.emit(Regexp, text, start, l.pos, space)
+	case 's':
+		l.emit(Symbol, text, start, l.pos, space)
+	default:
+		l.emit(String, text, start, l.pos, space)
+	}
+	return nil
+}
+
+func (l *lexer) skipRegexpFlags() {
+	for l.pos < len(l.src) && strings.IndexByte("imxounse", l.src[l.pos]) != -1 {
+		l.pos++
+	}
+}
+
+// lexHeredoc lexes the start of a heredoc, e.g. "<<~EOS".
+// The body is read once the end of the current line is reached.
+func (l *lexer) lexHeredoc(space bool) {
+	start := l.pos
+	l.pos += 2
+
+	indented := false
+	if c := l.peekByte(0); c == '~' || c == '-' {
+		indented = true
+		l.pos++
+	}
+
+	var id string
+	if c := l.peekByte(0); c == '"' || c == '\'' {
+		l.pos++
+		end := strings.IndexByte(l.src[l.pos:], c)
+		if end == -1 {
+			end = 0
+		}
+		id = l.src[l.pos : l.pos+end]
+		l.pos += end + 1
+	} else {
+		id = l.readIdent()
+	}
+
+	l.emit(String, "", start, l.pos, space)
+	l.pending = append(l.pending, heredoc{index: len(l.tokens) - 1, id: id, indented: indented})
+}
+
+// readHeredocBodies reads the bodies of all heredocs started on the previous line.
+func (l *lexer) readHeredocBodies() error {
+	for _, h := range l.pending {
+		start := l.pos
+		for {
+			if l.pos >= len(l.src) {
+				return fmt.Errorf("line %d: unterminated heredoc %s", l.line, h.id)
+			}
+			lineEnd := strings.IndexByte(l.src[l.pos:], '\n')
+			if lineEnd == -1 {
+				lineEnd = len(l.src) - l.pos
+			}
+			text := l.src[l.pos : l.pos+lineEnd]
+			if h.indented {
+				text = strings.TrimSpace(text)
+			}
+
+			if text == h.id {
+				l.tokens[h.index].Text = l.src[start:l.pos]
+				l.tokens[h.index].endLine = l.line
+				l.pos = min(l.pos+lineEnd+1, len(l.src))
+				l.line++
+				break
+			}
+			l.pos = min(l.pos+lineEnd+1, len(l.src))
+			l.line++
+		}
+	}
+	l.pending = nil
+	return nil
+}
+
+func (l *lexer) lexNumber(space bool) {
+	start := l.pos
+	for l.pos < len(l.src) && (isAlphaNumeric(l.src[l.pos]) || l.src[l.pos] == '_') {
+		l.pos++
+	}
+	// Include the fractional part of a float, but not a method call, e.g. "1.to_s".
+	if l.peekByte(0) == '.' && isDigit(l.peekByte(1)) {
+		l.pos++
+		for l.pos < len(l.src) && (isAlphaNumeric(l.src[l.pos]) || l.src[l.pos] == '_') {
+			l.pos++
+		}
+	}
+	l.emit(Number, l.src[start:l.pos], start, l.pos, space)
+}
+
+// readIdent reads an identifier, including a trailing "?" or "!" of a method name.
+func (l *lexer) readIdent() string {
+	start := l.pos
+	for l.pos < len(l.src) && (isAlphaNumeric(l.src[l.pos]) || l.src[l.pos] == '_') {
+		l.pos++
+	}
+	if c := l.peekByte(0); (c == '?' || c == '!') && l.peekByte(1) != '=' {
+		l.pos++
+	} else if c == '!' && l.peekByte(1) == '=' && l.peekByte(2) == '=' {
+		l.pos++
+	}
+	return l.src[start:l.pos]
+}
+
+func (l *lexer) lexIdent(space bool) {
+	start := l.pos
+	for l.pos < len(l.src) && (l.src[l.pos] == '@' || l.src[l.pos] == '$') {
+		l.pos++
+	}
+	// Special global variables, e.g. "$?" or "$0".
+	if l.pos > start && l.src[start] == '$' && !isIdentStart(l.peekByte(0)) {
+		l.pos++
+		l.emit(Ident, l.src[start:l.pos], start, l.pos, space)
+		return
+	}
+	l.readIdent()
+	text := l.src[start:l.pos]
+
+	// A label is an identifier directly followed by a single colon and a space, e.g. "tag: ".
+	if l.peekByte(0) == ':' && l.peekByte(1) != ':' && isLabelEnd(l.peekByte(1)) && !l.last().is(Op, "?") {
+		l.pos++
+		l.emit(Label, text, start, l.pos, space)
+		return
+	}
+
+	last := l.last()
+	afterDot := last.is(Op, ".", "&.") || (last.is(Op, "::") && !last.SpaceBefore)
+	switch {
+	case keywords[text] && !afterDot:
+		l.emit(Keyword, text, start, l.pos, space)
+	case text == "defined?":
+		l.emit(Keyword, text, start, l.pos, space)
+	case 'A' <= text[0] && text[0] <= 'Z':
+		l.emit(Constant, text, start, l.pos, space)
+	default:
+		l.emit(Ident, text, start, l.pos, space)
+	}
+}
+
+func (l *lexer) lexOperator(space bool) error {
+	for _, op := range operators {
+		if l.hasPrefix(op) {
+			l.emit(Op, op, l.pos, l.pos+len(op), space)
+			l.pos += len(op)
+			return nil
+		}
+	}
+	return fmt.Errorf("line %d: unexpected character %q", l.line, l.src[l.pos])
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func isAlphaNumeric(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || isDigit(c) || c >= 0x80
+}
+
+func isIdentStart(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || c == '_' || c >= 0x80
+}
+
+// isLabelEnd returns true if the given character may follow the colon of a label.
+func isLabelEnd(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == 0 || c == ',' || c == ')' || c == ']' || c == '}'
+}
+
+func isDelimiter(c byte) bool {
+	return c != 0 && !isAlphaNumeric(c) && c != ' ' && c != '\n' && c != '\t' && c != '_'
+}
+
+// closingDelimiter returns the closing delimiter for the given opening delimiter of a percent literal.
+func closingDelimiter(open byte) byte {
+	switch open {
+	case '(':
+		return ')'
+	case '[':
+		return ']'
+	case '{':
+		return '}'
+	case '<':
+		return '>'
+	}
+	return open
+}
@@ -0,0 +1,845 @@
+package ruby
+
+import (
+	"fmt"
+	"strings"
+)
+
+// exprMode controls at which tokens skipping an expression stops, in addition to the end of the statement.
+type exprMode int
+
+const (
+	// stopComma stops at a "," or "=>" outside of brackets, e.g. between call arguments.
+	stopComma exprMode = 1 << iota
+
+	// stopDo stops at a "do" outside of brackets, e.g. at the block of a command or a loop.
+	stopDo
+
+	// stopThen stops at a "then", e.g. at the end of a condition.
+	stopThen
+
+	// inGroup ignores line breaks and stops at the closing bracket of a group.
+	inGroup
+)
+
+// continuationOps are the operators which continue a statement on the next line.
+var continuationOps = map[string]bool{
+	",": true, "(": true, "[": true, "{": true, "=>": true, "=": true, "+=": true, "-=": true, "*=": true,
+	"/=": true, "||=": true, "&&=": true, "|=": true, "&=": true, "<<=": true, ">>=": true, "+": true, "-": true,
+	"*": true, "/": true, "%": true, "**": true, "||": true, "&&": true, "&": true, ".": true, "&.": true,
+	"::": true, "?": true, ":": true, "<": true, ">": true, "<=": true, ">=": true, "==": true, "!=": true,
+	"===": true, "=~": true, "!~": true, "<<": true, ">>": true, "<=>": true, "!": true, "->": true,
+}
+
+// assignmentOps are the operators of an assignment.
+var assignmentOps = []string{"=", "+=", "-=", "*=", "/=", "||=", "&&=", "|=", "&=", "<<=", ">>=", "**="}
+
+// binaryOps are the operators combining two operands of a value.
+var binaryOps = []string{
+	"+", "-", "*", "/", "%", "**", "==", "!=", "<", ">", "<=", ">=", "<=>", "===", "=~", "!~",
+	"&&", "||", "&", "|", "^", "<<", ">>", "..", "...", "?", ":",
+}
+
+// parseError is used to unwind the parser on the first syntax error.
+type parseError struct {
+	err error
+}
+
+// parser builds the statement tree of a Ruby source from its tokens.
+// It understands the block structure of the whole source,
+// but only the arguments of calls without a receiver are parsed into values.
+type parser struct {
+	src    string
+	tokens []Token
+	pos    int
+
+	// End offset and last line of the last consumed token.
+	lastEnd, lastLine int
+}
+
+// Parse parses the given Ruby source into a tree of statements.
+// The returned node is of kind Program and contains the top-level statements.
+func Parse(src string) (tree *Node, err error) {
+	tokens, err := Lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{src: src, tokens: tokens, lastLine: 1}
+	defer func() {
+		if r := recover(); r != nil {
+			pe, ok := r.(parseError)
+			if !ok {
+				panic(r)
+			}
+			tree, err = nil, pe.err
+		}
+	}()
+
+	root := &Node{Kind: Program, Raw: src, Line: 1, start: 0, end: len(src)}
+	root.Body = p.parseStatements()
+	if t := p.peek(); t.Kind != EOF {
+		p.fail("line %d: unexpected %q", t.Line, t.Text)
+	}
+	root.EndLine = p.lastLine
+	return root, nil
+}
+
+func (p *parser) fail(format string, args ...any) {
+	panic(parseError{fmt.Errorf(format, args...)})
+}
+
+func (p *parser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(offset int) Token {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+// next consumes the next token.
+func (p *parser) next() Token {
+	t := p.tokens[p.pos]
+	if t.Kind != EOF {
+		p.pos++
+		p.lastEnd, p.lastLine = t.End, t.endLine
+	}
+	return t
+}
+
+// prev returns the last consumed token, ignoring line breaks.
+// If no token was consumed yet, an EOF token is returned.
+func (p *parser) prev() Token {
+	for i := p.pos - 1; i >= 0; i-- {
+		if p.tokens[i].Kind != Newline || p.tokens[i].Text == ";" {
+			return p.tokens[i]
+		}
+	}
+	return Token{Kind: EOF}
+}
+
+func (p *parser) expect(kind TokenKind, text string, opener Token) {
+	if t := p.peek(); !t.is(kind, text) {
+		p.fail("line %d: expected %q to close %q of line %d, got %q", t.Line, text, opener.Text, opener.Line, t.Text)
+	}
+	p.next()
+}
+
+func (p *parser) skipNewlines() {
+	for p.peek().Kind == Newline {
+		p.next()
+	}
+}
+
+// continues returns true if the statement continues after the current line break,
+// i.e. the line ends with an operator or the next line starts with a method call.
+func (p *parser) continues() bool {
+	prev := p.prev()
+	if prev.is(Op) && continuationOps[prev.Text] || prev.is(Keyword, "and", "or", "not") {
+		return true
+	}
+	i := p.pos
+	for i < len(p.tokens) && p.tokens[i].is(Newline, "\n") {
+		i++
+	}
+	return i < len(p.tokens) && p.tokens[i].is(Op, ".", "&.")
+}
+
+// valuePosition returns true if the next token starts a new value,
+// i.e. the last consumed token can't be the end of an expression.
+// It is used to tell apart a control structure from a modifier, e.g. `x = if y` and `x if y`.
+func (p *parser) valuePosition() bool {
+	prev := p.prev()
+	switch prev.Kind {
+	case EOF, Newline, Label:
+		return true
+	case Op:
+		return !prev.is(Op, ")", "]", "}")
+	case Keyword:
+		return !prev.is(Keyword, "end", "self", "true", "false", "nil", "return", "next", "break", "redo", "retry", "super", "yield")
+	}
+	return false
+}
+
+// atStatementEnd returns true if the current statement ends at the next token.
+// Line breaks which continue the statement are consumed.
+func (p *parser) atStatementEnd() bool {
+	t := p.peek()
+	switch {
+	case t.Kind == EOF:
+		return true
+	case t.Kind == Newline:
+		if t.Text == "\n" && p.continues() {
+			p.skipNewlines()
+			return p.atStatementEnd()
+		}
+		return true
+	case t.is(Keyword, "end", "else", "elsif", "when", "ensure", "then", "do"):
+		return true
+	case t.is(Op, ")", "]", "}"):
+		return true
+	}
+	return false
+}
+
+// atClauseEnd returns true if the next token ends a body of statements.
+func (p *parser) atClauseEnd() bool {
+	t := p.peek()
+	return t.is(Keyword, "end", "else", "elsif", "when", "in", "rescue", "ensure", "then") || t.is(Op, ")", "]", "}")
+}
+
+// parseStatements parses statements up to the end of the enclosing body.
+func (p *parser) parseStatements() []*Node {
+	nodes := make([]*Node, 0)
+	for {
+		p.skipNewlines()
+		if p.peek().Kind == EOF || p.atClauseEnd() {
+			return nodes
+		}
+		nodes = append(nodes, p.parseStatement())
+	}
+}
+
+func (p *parser) parseStatement() *Node {
+	start := p.peek()
+
+	var node *Node
+	switch {
+	case start.is(Keyword, "class", "module"):
+		node = p.parseClass()
+	case start.is(Keyword, "def"):
+		node = p.parseDef()
+	case start.is(Keyword, "if", "unless", "while", "until", "case", "begin", "for"):
+		node = p.parseControl()
+	case start.Kind == Ident || start.Kind == Constant:
+		node = p.parseCommand()
+	default:
+		node = &Node{Kind: Expr}
+		p.skipExpr(node, 0)
+	}
+
+	p.finishStatement(node, start)
+	return node
+}
+
+// finishStatement parses the modifiers and the remainder of the statement and sets its source position.
+func (p *parser) finishStatement(node *Node, start Token) {
+	for !p.atStatementEnd() {
+		pos := p.pos
+		if p.peek().is(Keyword, "if", "unless", "while", "until", "rescue") {
+			keyword := p.next()
+			node.Modifier = &Modifier{Keyword: keyword.Text, Cond: p.parseValue()}
+			continue
+		}
+		p.skipExpr(node, 0)
+		if p.pos == pos {
+			t := p.peek()
+			p.fail("line %d: unexpected %q", t.Line, t.Text)
+		}
+	}
+
+	node.Raw = p.src[start.Start:p.lastEnd]
+	node.start, node.end = start.Start, p.lastEnd
+	node.Line, node.EndLine = start.Line, p.lastLine
+}
+
+// parseClass parses a class or a module definition.
+func (p *parser) parseClass() *Node {
+	keyword := p.next()
+	node := &Node{Kind: Class, Name: keyword.Text}
+	if keyword.Text == "module" {
+		node.Kind = Module
+	}
+
+	// Singleton classes, e.g. "class << self", have no name.
+	if !p.peek().is(Op, "<<") {
+		var name strings.Builder
+		for p.peek().Kind == Constant || p.peek().is(Op, "::") {
+			name.WriteString(p.next().Text)
+		}
+		node.Name = name.String()
+	}
+
+	if p.peek().is(Op, "<") {
+		p.next()
+		start := p.peek()
+		p.skipExpr(nil, 0)
+		node.Receiver = p.src[start.Start:p.lastEnd]
+	} else {
+		p.skipExpr(nil, 0)
+	}
+
+	node.Body = p.parseStatements()
+	p.parseClauses(node, keyword)
+	return node
+}
+
+// parseDef parses a method definition.
+func (p *parser) parseDef() *Node {
+	keyword := p.next()
+	node := &Node{Kind: Def}
+
+	// The name may be prefixed with a receiver, e.g. "def self.foo".
+	node.Name = p.next().Text
+	if p.peek().is(Op, ".") {
+		p.next()
+		node.Name = p.next().Text
+	}
+
+	if t := p.peek(); t.is(Op, "(") && !t.SpaceBefore {
+		p.skipGroup()
+	} else if !p.atStatementEnd() && !t.is(Op, "=") {
+		p.skipExpr(nil, 0)
+	}
+
+	// An endless method definition has no body, e.g. "def foo = 1".
+	if p.peek().is(Op, "=") {
+		p.next()
+		p.skipExpr(node, 0)
+		return node
+	}
+
+	node.Body = p.parseStatements()
+	p.parseClauses(node, keyword)
+	return node
+}
+
+// parseControl parses a control structure, e.g. "if ... end", "case ... end" or "begin ... end".
+func (p *parser) parseControl() *Node {
+	keyword := p.next()
+	node := &Node{Kind: Control, Name: keyword.Text}
+
+	if keyword.Text != "begin" {
+		mode := stopThen
+		if keyword.is(Keyword, "while", "until", "for") {
+			mode |= stopDo
+		}
+		if start := p.peek(); start.Kind != Newline {
+			p.skipExpr(nil, mode)
+			node.Value = p.valueFrom(start, ExprValue)
+		}
+		if p.peek().is(Keyword, "then", "do") {
+			p.next()
+		}
+	}
+
+	node.Body = p.parseStatements()
+	p.parseClauses(node, keyword)
+	return node
+}
+
+// parseClauses parses the remaining clauses of a block up to and including its "end".
+func (p *parser) parseClauses(node *Node, opener Token) {
+	for {
+		t := p.peek()
+		switch {
+		case t.is(Keyword, "end"):
+			p.next()
+			return
+		case t.is(Keyword, "else", "ensure"):
+			p.next()
+		case t.is(Keyword, "elsif", "when", "in", "rescue"):
+			p.next()
+			if !p.atStatementEnd() {
+				p.skipExpr(nil, stopThen)
+			}
+			if p.peek().is(Keyword, "then") {
+				p.next()
+			}
+		default:
+			p.fail("line %d: expected \"end\" to close %q of line %d, got %q", t.Line, opener.Text, opener.Line, t.Text)
+		}
+		node.Else = append(node.Else, p.parseStatements()...)
+	}
+}
+
+// parseBlock parses a "do ... end" or "{ ... }" block and appends its statements to the given node.
+func (p *parser) parseBlock(node *Node) {
+	opener := p.next()
+	p.skipBlockParams()
+
+	stmts := p.parseStatements()
+	if node != nil {
+		node.Body = append(node.Body, stmts...)
+	}
+
+	if opener.is(Op, "{") {
+		p.expect(Op, "}", opener)
+		return
+	}
+
+	clauses := &Node{}
+	p.parseClauses(clauses, opener)
+	if node != nil {
+		node.Else = append(node.Else, clauses.Else...)
+	}
+}
+
+// skipBlockParams skips the parameters of a block, e.g. "|a, b|".
+func (p *parser) skipBlockParams() {
+	switch {
+	case p.peek().is(Op, "||"):
+		p.next()
+	case p.peek().is(Op, "|"):
+		opener := p.next()
+		for !p.peek().is(Op, "|") {
+			if p.peek().Kind == EOF {
+				p.fail("line %d: unterminated block parameters", opener.Line)
+			}
+			p.next()
+		}
+		p.next()
+	}
+}
+
+// skipGroup skips a bracketed group, e.g. the arguments of a call, including the brackets.
+func (p *parser) skipGroup() {
+	opener := p.next()
+	if opener.is(Op, "{") {
+		p.skipBlockParams()
+	}
+	p.skipExpr(nil, inGroup)
+	p.expect(Op, closingBracket(opener.Text), opener)
+}
+
+// skipExpr skips an expression up to the end of the statement or a token given by the mode.
+// Nested blocks and control structures are parsed to find their end.
+// The statements of "do ... end" blocks are appended to the given node, if any.
+func (p *parser) skipExpr(node *Node, mode exprMode) {
+	for {
+		t := p.peek()
+		switch {
+		case t.Kind == EOF:
+			return
+		case t.Kind == Newline:
+			if mode&inGroup == 0 && (t.Text == ";" || !p.continues()) {
+				return
+			}
+			p.next()
+		case t.is(Op, "(", "[", "{"):
+			if t.Text == "{" && mode&inGroup == 0 && !p.valuePosition() {
+				p.parseBlock(node)
+				continue
+			}
+			p.skipGroup()
+		case t.is(Op, ")", "]", "}"):
+			return
+		case t.is(Op, ",", "=>") && mode&stopComma != 0:
+			return
+		case t.is(Keyword, "do"):
+			if mode&(stopDo|stopComma) != 0 {
+				return
+			}
+			p.parseBlock(node)
+		case t.is(Keyword, "if", "unless", "while", "until"):
+			if p.valuePosition() {
+				p.parseControl()
+				continue
+			}
+			if mode&inGroup == 0 {
+				return
+			}
+			p.next()
+		case t.is(Keyword, "rescue"):
+			if mode&inGroup == 0 {
+				return
+			}
+			p.next()
+		case t.is(Keyword, "case", "begin", "for"):
+			p.parseControl()
+		case t.is(Keyword, "def"):
+			p.parseDef()
+		case t.is(Keyword, "class", "module"):
+			p.parseClass()
+		case t.is(Keyword, "end", "else", "elsif", "when", "ensure"):
+			return
+		case t.is(Keyword, "then"):
+			if mode&stopThen != 0 || mode&inGroup == 0 {
+				return
+			}
+			p.next()
+		default:
+			p.next()
+		}
+	}
+}
+
+// parseCommand parses a statement starting with an identifier,
+// i.e. an assignment, a method call or any other expression.
+func (p *parser) parseCommand() *Node {
+	name, next := p.peek(), p.peekAt(1)
+
+	switch {
+	case next.is(Op, assignmentOps...):
+		p.next()
+		p.next()
+		return &Node{Kind: Assign, Name: name.Text, Value: p.parseValue()}
+	case name.Kind == Ident && p.isCommandArg(next):
+		p.next()
+		node := &Node{Kind: Call, Name: name.Text, Args: p.parseArgs(false)}
+		p.parseCallBlock(node)
+		return node
+	case name.Kind == Ident && next.is(Op, "(") && !next.SpaceBefore:
+		p.next()
+		opener := p.next()
+		node := &Node{Kind: Call, Name: name.Text, Args: p.parseArgs(true)}
+		p.expect(Op, ")", opener)
+		if p.peek().is(Op, ".", "&.", "[") {
+			// The result of the call is the receiver of another call.
+			node.Kind = Expr
+			p.skipExpr(node, 0)
+			return node
+		}
+		p.parseCallBlock(node)
+		return node
+	case name.Kind == Ident && (next.Kind == Newline || next.Kind == EOF || next.is(Keyword, "do", "if", "unless", "end") || next.is(Op, "{", "}")):
+		p.next()
+		node := &Node{Kind: Call, Name: name.Text, Args: make([]*Value, 0)}
+		p.parseCallBlock(node)
+		return node
+	}
+
+	node := &Node{Kind: Expr}
+	p.skipExpr(node, 0)
+	return node
+}
+
+// parseCallBlock parses the block attached to a call, if any.
+func (p *parser) parseCallBlock(node *Node) {
+	if t := p.peek(); t.is(Keyword, "do") || t.is(Op, "{") {
+		p.parseBlock(node)
+	}
+}
+
+// isCommandArg returns true if the given token, following an identifier,
+// starts the first argument of a call without parentheses, e.g. `depends_on "foo"`.
+func (p *parser) isCommandArg(t Token) bool {
+	switch t.Kind {
+	case String, Symbol, Number, Label, Regexp, Words, Constant:
+		return true
+	case Ident:
+		return t.SpaceBefore
+	case Keyword:
+		return t.SpaceBefore && t.is(Keyword, "true", "false", "nil", "self", "not", "defined?")
+	case Op:
+		if t.is(Op, "[", "(") {
+			return t.SpaceBefore
+		}
+		after := p.peekAt(2)
+		return t.SpaceBefore && !after.SpaceBefore && t.is(Op, "-", "*", "**", "&", "::", "!", "->")
+	}
+	return false
+}
+
+// parseArgs parses the arguments of a call.
+// Trailing key-value pairs are collected into a single hash argument.
+func (p *parser) parseArgs(parens bool) []*Value {
+	args := make([]*Value, 0)
+	var hash *Value
+
+	for {
+		if parens {
+			p.skipNewlines()
+			if p.peek().is(Op, ")") {
+				break
+			}
+		} else if p.atStatementEnd() || p.peek().is(Keyword, "if", "unless", "while", "until", "rescue") {
+			break
+		}
+
+		if t := p.peek(); t.Kind == Label {
+			hash = p.appendPair(hash, p.parseLabel())
+		} else if v := p.parseValue(); p.peek().is(Op, "=>") {
+			p.next()
+			p.skipNewlines()
+			hash = p.appendPair(hash, &Pair{Key: v, Value: p.parseValue()})
+		} else {
+			args = append(args, v)
+		}
+
+		if !p.peek().is(Op, ",") {
+			break
+		}
+		p.next()
+		p.skipNewlines()
+	}
+
+	if parens {
+		p.skipNewlines()
+	}
+	if hash != nil {
+		args = append(args, hash)
+	}
+	return args
+}
+
+// parseLabel parses a key-value pair with a label as key, e.g. "tag: \"v1\"".
+func (p *parser) parseLabel() *Pair {
+	label := p.next()
+	key := &Value{Kind: SymbolValue, Text: label.Text, Raw: p.src[label.Start:label.End], Line: label.Line, EndLine: label.endLine, start: label.Start, end: label.End}
+	p.skipNewlines()
+	return &Pair{Key: key, Value: p.parseValue()}
+}
+
+// appendPair appends the given pair to the given hash, creating the hash if it's nil.
+func (p *parser) appendPair(hash *Value, pair *Pair) *Value {
+	if hash == nil {
+		hash = &Value{Kind: HashValue, Line: pair.Key.Line, start: pair.Key.start}
+	}
+	hash.Pairs = append(hash.Pairs, pair)
+	hash.end, hash.EndLine = pair.Value.end, pair.Value.EndLine
+	hash.Raw = p.src[hash.start:hash.end]
+	hash.Text = hash.Raw
+	return hash
+}
+
+// parseValue parses a value, i.e. an operand followed by any binary operations.
+// Expressions which can't be parsed are skipped up to the next argument.
+func (p *parser) parseValue() *Value {
+	start := p.peek()
+	v := p.parseOperand()
+
+	combined := false
+	for t := p.peek(); t.is(Op, binaryOps...); t = p.peek() {
+		p.next()
+		p.skipNewlines()
+		p.parseOperand()
+		combined = true
+	}
+
+	if !p.atValueEnd() {
+		p.skipExpr(nil, stopComma|stopDo)
+		combined = true
+	}
+
+	if combined {
+		return p.valueFrom(start, ExprValue)
+	}
+	return v
+}
+
+// atValueEnd returns true if the next token ends a value.
+func (p *parser) atValueEnd() bool {
+	t := p.peek()
+	return t.Kind == EOF || t.Kind == Newline || t.Kind == Label ||
+		t.is(Op, ",", "=>", ")", "]", "}") ||
+		t.is(Keyword, "do", "if", "unless", "while", "until", "rescue", "end", "then", "and", "or")
+}
+
+// parseOperand parses a single operand, e.g. a literal or a chain of method calls.
+func (p *parser) parseOperand() *Value {
+	start := p.peek()
+
+	var v *Value
+	switch {
+	case start.Kind == String:
+		var text strings.Builder
+		for p.peek().Kind == String {
+			text.WriteString(p.next().Text)
+		}
+		v = p.valueFrom(start, StringValue)
+		v.Text = text.String()
+	case start.Kind == Symbol:
+		p.next()
+		v = p.valueFrom(start, SymbolValue)
+		v.Text = start.Text
+	case start.Kind == Number:
+		p.next()
+		v = p.valueFrom(start, NumberValue)
+	case start.Kind == Regexp:
+		p.next()
+		v = p.valueFrom(start, RegexpValue)
+		v.Text = start.Text
+	case start.Kind == Words:
+		p.next()
+		v = p.valueFrom(start, ArrayValue)
+		for _, word := range strings.Fields(start.Text) {
+			v.Elems = append(v.Elems, &Value{Kind: StringValue, Text: word, Raw: word, Line: start.Line, EndLine: start.endLine, start: start.Start, end: start.End})
+		}
+	case start.is(Op, "["):
+		v = p.parseArray()
+	case start.is(Op, "{"):
+		v = p.parseHash()
+	case start.is(Op, "("):
+		p.skipGroup()
+		v = p.valueFrom(start, ExprValue)
+	case start.is(Op, "-", "+", "!", "~", "*", "**", "&", "::", "..", "..."):
+		p.next()
+		p.parseOperand()
+		v = p.valueFrom(start, ExprValue)
+	case start.is(Op, "->"):
+		p.next()
+		if p.peek().is(Op, "(") {
+			p.skipGroup()
+		}
+		if t := p.peek(); t.is(Op, "{") || t.is(Keyword, "do") {
+			p.parseBlock(nil)
+		}
+		v = p.valueFrom(start, ExprValue)
+	case start.is(Keyword, "if", "unless", "while", "until", "case", "begin", "for"):
+		p.parseControl()
+		v = p.valueFrom(start, ExprValue)
+	case start.is(Keyword, "def"):
+		p.parseDef()
+		v = p.valueFrom(start, ExprValue)
+	case start.is(Keyword, "not", "defined?"):
+		p.next()
+		p.parseOperand()
+		v = p.valueFrom(start, ExprValue)
+	case start.Kind == Ident || start.Kind == Constant || start.Kind == Keyword:
+		p.next()
+		v = p.valueFrom(start, ExprValue)
+	case p.atValueEnd():
+		p.fail("line %d: expected a value, got %q", start.Line, start.Text)
+	default:
+		p.next()
+		v = p.valueFrom(start, ExprValue)
+	}
+
+	if p.parsePostfix(v) {
+		v = p.valueFrom(start, ExprValue)
+	}
+	return v
+}
+
+// parsePostfix parses method calls, indexing and blocks following an operand.
+// It returns true if anything was parsed.
+func (p *parser) parsePostfix(v *Value) bool {
+	callable := v.Kind == ExprValue
+	parsed := false
+	for {
+		t := p.peek()
+		switch {
+		case t.is(Op, ".", "&.") || (t.is(Op, "::") && !t.SpaceBefore):
+			p.next()
+			p.skipNewlines()
+			p.next()
+			callable = true
+		case t.is(Op, "[") && !t.SpaceBefore:
+			p.skipGroup()
+		case t.is(Op, "(") && !t.SpaceBefore && callable:
+			p.skipGroup()
+		case t.is(Op, "{") && callable:
+			p.skipGroup()
+		case t.is(Newline, "\n") && p.continues() && !continuationOps[p.prev().Text]:
+			// A method call on the next line, e.g. ".freeze".
+			p.skipNewlines()
+		default:
+			return parsed
+		}
+		parsed = true
+	}
+}
+
+// parseArray parses an array literal. Key-value pairs are collected into a hash element.
+func (p *parser) parseArray() *Value {
+	opener := p.next()
+	v := &Value{Kind: ArrayValue, Elems: make([]*Value, 0)}
+	var hash *Value
+
+	for {
+		p.skipNewlines()
+		if p.peek().is(Op, "]") {
+			break
+		}
+
+		if t := p.peek(); t.Kind == Label {
+			hash = p.appendPair(hash, p.parseLabel())
+		} else if elem := p.parseValue(); p.peek().is(Op, "=>") {
+			p.next()
+			p.skipNewlines()
+			hash = p.appendPair(hash, &Pair{Key: elem, Value: p.parseValue()})
+		} else {
+			if hash != nil {
+				v.Elems = append(v.Elems, hash)
+				hash = nil
+			}
+			v.Elems = append(v.Elems, elem)
+		}
+
+		p.skipNewlines()
+		if !p.peek().is(Op, ",") {
+			break
+		}
+		p.next()
+	}
+	if hash != nil {
+		v.Elems = append(v.Elems, hash)
+	}
+
+	p.expect(Op, "]", opener)
+	p.setSource(v, opener)
+	return v
+}
+
+// parseHash parses a hash literal. If the braces turn out to be a block, they are skipped.
+func (p *parser) parseHash() *Value {
+	opener := p.peek()
+	if t := p.peekAt(1); t.is(Op, "|", "||") {
+		p.skipGroup()
+		return p.valueFrom(opener, ExprValue)
+	}
+	p.next()
+
+	v := &Value{Kind: HashValue, Pairs: make([]*Pair, 0)}
+	for {
+		p.skipNewlines()
+		if p.peek().is(Op, "}") {
+			break
+		}
+
+		if p.peek().Kind == Label {
+			v.Pairs = append(v.Pairs, p.parseLabel())
+		} else {
+			key := p.parseValue()
+			if !p.peek().is(Op, "=>") {
+				// Not a hash, e.g. a block in a value position.
+				p.skipExpr(nil, inGroup)
+				p.expect(Op, "}", opener)
+				return p.valueFrom(opener, ExprValue)
+			}
+			p.next()
+			p.skipNewlines()
+			v.Pairs = append(v.Pairs, &Pair{Key: key, Value: p.parseValue()})
+		}
+
+		p.skipNewlines()
+		if !p.peek().is(Op, ",") {
+			break
+		}
+		p.next()
+	}
+
+	p.expect(Op, "}", opener)
+	p.setSource(v, opener)
+	return v
+}
+
+// valueFrom creates a value of the given kind spanning from the given token to the last consumed token.
+func (p *parser) valueFrom(start Token, kind ValueKind) *Value {
+	v := &Value{Kind: kind}
+	p.setSource(v, start)
+	v.Text = v.Raw
+	return v
+}
+
+// setSource sets the source position of the given value, spanning from the given token to the last consumed token.
+func (p *parser) setSource(v *Value, start Token) {
+	end := max(p.lastEnd, start.Start)
+	v.Raw = p.src[start.Start:end]
+	v.start, v.end = start.Start, end
+	v.Line, v.EndLine = start.Line, p.lastLine
+}
+
+func closingBracket(open string) string {
+	switch open {
+	case "(":
+		return ")"
+	case "[":
+		return "]"
+	}
+	return "}"
+}
@@ -0,0 +1,228 @@
+package ruby
+
+import (
+	"log"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var lexTests = []struct {
+	input    string
+	expected []Token
+}{
+	{
+		input: `depends_on "gcc" => [:build, :test]`,
+		expected: []Token{
+			{Kind: Ident, Text: "depends_on"},
+			{Kind: String, Text: "gcc"},
+			{Kind: Op, Text: "=>"},
+			{Kind: Op, Text: "["},
+			{Kind: Symbol, Text: "build"},
+			{Kind: Op, Text: ","},
+			{Kind: Symbol, Text: "test"},
+			{Kind: Op, Text: "]"},
+			{Kind: EOF},
+		},
+	},
+	{
+		input: `url "https://example.com/#{name}-#{version.tr(".", "_")}.zip", tag: "v1" # comment`,
+		expected: []Token{
+			{Kind: Ident, Text: "url"},
+			{Kind: String, Text: `https://example.com/#{name}-#{version.tr(".", "_")}.zip`},
+			{Kind: Op, Text: ","},
+			{Kind: Label, Text: "tag"},
+			{Kind: String, Text: "v1"},
+			{Kind: EOF},
+		},
+	},
+	{
+		input: "(buildpath/\"test\").write <<~EOS\n  end\n  EOS\nregex(/v?(\\d+)/i)",
+		expected: []Token{
+			{Kind: Op, Text: "("},
+			{Kind: Ident, Text: "buildpath"},
+			{Kind: Op, Text: "/"},
+			{Kind: String, Text: "test"},
+			{Kind: Op, Text: ")"},
+			{Kind: Op, Text: "."},
+			{Kind: Ident, Text: "write"},
+			{Kind: String, Text: "  end\n"},
+			{Kind: Newline, Text: "\n"},
+			{Kind: Ident, Text: "regex"},
+			{Kind: Op, Text: "("},
+			{Kind: Regexp, Text: `v?(\d+)`},
+			{Kind: Op, Text: ")"},
+			{Kind: EOF},
+		},
+	},
+	{
+		input: "args = %w[--foo --bar]\n__END__\ndiff --git a/end b/end",
+		expected: []Token{
+			{Kind: Ident, Text: "args"},
+			{Kind: Op, Text: "="},
+			{Kind: Words, Text: "--foo --bar"},
+			{Kind: Newline, Text: "\n"},
+			{Kind: EOF},
+		},
+	},
+}
+
+func TestLex(t *testing.T) {
+	for _, test := range lexTests {
+		tokens, err := Lex(test.input)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		// Only compare the kinds and texts.
+		actual := make([]Token, len(tokens))
+		for i, token := range tokens {
+			actual[i] = Token{Kind: token.Kind, Text: token.Text}
+		}
+		assert.Equal(t, test.expected, actual, "expected: %v, got: %v", test.expected, actual)
+	}
+}
+
+func TestParseBlocks(t *testing.T) {
+	tree, err := Parse(`class Foo < Formula
+  url "https://example.com/foo-1.0.tar.gz",
+      tag:      "v1.0",
+      revision: "abc"
+
+  on_linux { depends_on "bar" }
+  on_system :linux, macos: :mojave_or_older do
+    depends_on "baz" => [:build, :test] if DevelopmentTools.clang_build_version <= 1403
+  end
+
+  def install
+    args = if build.head?
+      %w[--head]
+    else
+      []
+    end
+    while args.empty? do args << "x" end
+    %w[a b].each { |x| system "echo", x }
+    (prefix/"foo").write <<~EOS
+      end
+    EOS
+  end
+
+  test do
+    begin
+      system bin/"foo"
+    rescue
+      nil
+    end
+  end
+end`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	class := tree.FormulaClass()
+	if !assert.NotNil(t, class) {
+		return
+	}
+	assert.Equal(t, "Foo", class.Name)
+	assert.Equal(t, "Formula", class.Receiver)
+	if !assert.Len(t, class.Body, 5) {
+		return
+	}
+
+	url := class.Body[0]
+	assert.True(t, url.IsCall("url"))
+	assert.Equal(t, 2, url.Line)
+	assert.Equal(t, 4, url.EndLine)
+	u, ok := url.StringArg(0)
+	assert.True(t, ok)
+	assert.Equal(t, "https://example.com/foo-1.0.tar.gz", u)
+	assert.Equal(t, "v1.0", url.Options().Get("tag").Text)
+	assert.Equal(t, "abc", url.Options().Get("revision").Text)
+
+	onLinux := class.Body[1]
+	assert.True(t, onLinux.IsCall("on_linux"))
+	if assert.Len(t, onLinux.Body, 1) {
+		assert.True(t, onLinux.Body[0].IsCall("depends_on"))
+	}
+
+	onSystem := class.Body[2]
+	assert.True(t, onSystem.IsCall("on_system"))
+	assert.Equal(t, SymbolValue, onSystem.Arg(0).Kind)
+	assert.Equal(t, "mojave_or_older", onSystem.Options().Get("macos").Text)
+	if assert.Len(t, onSystem.Body, 1) {
+		dep := onSystem.Body[0]
+		assert.True(t, dep.IsCall("depends_on"))
+		assert.Equal(t, `"baz" => [:build, :test]`, dep.ArgsRaw())
+		pair := dep.Options().Pairs[0]
+		assert.Equal(t, "baz", pair.Key.Text)
+		assert.Equal(t, []string{"build", "test"}, pair.Value.Strings())
+		if assert.NotNil(t, dep.Modifier) {
+			assert.Equal(t, "if", dep.Modifier.Keyword)
+			assert.Equal(t, "DevelopmentTools.clang_build_version <= 1403", dep.Modifier.Cond.Raw)
+		}
+	}
+
+	install := class.Body[3]
+	assert.Equal(t, Def, install.Kind)
+	assert.Equal(t, "install", install.Name)
+	assert.Len(t, install.Body, 4)
+	assert.Equal(t, 22, install.EndLine)
+
+	test := class.Body[4]
+	assert.True(t, test.IsCall("test"))
+	if assert.Len(t, test.Body, 1) {
+		assert.Equal(t, Control, test.Body[0].Kind)
+		assert.Equal(t, "begin", test.Body[0].Name)
+	}
+}
+
+func TestParseLicense(t *testing.T) {
+	tree, err := Parse(`license any_of: [
+  "MIT",
+  "Apache-2.0" => { with: "LLVM-exception" },
+]`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	license := tree.Body[0]
+	anyOf := license.Options().Get("any_of")
+	if assert.NotNil(t, anyOf) && assert.Len(t, anyOf.Elems, 2) {
+		assert.Equal(t, "MIT", anyOf.Elems[0].Text)
+		assert.Equal(t, "LLVM-exception", anyOf.Elems[1].Get("Apache-2.0").Get("with").Text)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"class Foo < Formula\n  on_linux do\n    depends_on \"bar\"\nend",
+		"class Foo < Formula\n  license \"MIT\"\n  end\nend",
+		"class Foo < Formula\n  url \"https://example.com\n",
+	} {
+		_, err := Parse(input)
+		assert.Error(t, err, "expected error for: %s", input)
+	}
+}
+
+func TestParseTestData(t *testing.T) {
+	for _, path := range []string{
+		"../../test-data/i686-elf-gcc.rb",
+		"../../test-data/pike.rb",
+		"../../test-data/srecord.rb",
+		"../../test-data/geckodriver.rb",
+		"../../test-data/binary-tool.rb",
+		"../../test-data/cpu-tool.rb",
+	} {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		tree, err := Parse(string(src))
+		assert.NoError(t, err, "expected no error for: %s", path)
+		if assert.NotNil(t, tree) {
+			assert.NotNil(t, tree.FormulaClass(), "expected a formula class in: %s", path)
+		}
+	}
+}
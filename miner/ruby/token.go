@@ -0,0 +1,131 @@
+package ruby
+
+import "fmt"
+
+// TokenKind represents the kind of a token.
+type TokenKind int
+
+const (
+	// EOF marks the end of the source.
+	EOF TokenKind = iota
+
+	// Newline marks the end of a line or a semicolon.
+	Newline
+
+	// Ident is an identifier starting with a lowercase letter or an underscore,
+	// e.g. a local variable or a method name. It includes instance and global variables.
+	Ident
+
+	// Constant is an identifier starting with an uppercase letter.
+	Constant
+
+	// Keyword is a reserved word, e.g. "do" or "end".
+	Keyword
+
+	// Label is a hash key followed by a colon, e.g. "tag:".
+	// The token's text excludes the colon.
+	Label
+
+	// Symbol is a symbol literal, e.g. ":build".
+	// The token's text excludes the leading colon.
+	Symbol
+
+	// String is a string literal or a heredoc.
+	// The token's text is the raw content between the delimiters.
+	String
+
+	// Words is a percent literal array of words, e.g. "%w[a b]".
+	// The token's text is the raw content between the delimiters.
+	Words
+
+	// Number is an integer or a float literal.
+	Number
+
+	// Regexp is a regular expression literal.
+	Regexp
+
+	// Op is an operator or a punctuation character.
+	Op
+)
+
+var tokenKindNames = map[TokenKind]string{
+	EOF:      "EOF",
+	Newline:  "Newline",
+	Ident:    "Ident",
+	Constant: "Constant",
+	Keyword:  "Keyword",
+	Label:    "Label",
+	Symbol:   "Symbol",
+	String:   "String",
+	Words:    "Words",
+	Number:   "Number",
+	Regexp:   "Regexp",
+	Op:       "Op",
+}
+
+func (k TokenKind) String() string {
+	return tokenKindNames[k]
+}
+
+// Token represents a lexical token of a Ruby source.
+type Token struct {
+	// Kind of the token.
+	Kind TokenKind
+
+	// Text of the token.
+	Text string
+
+	// Start and End are the byte offsets of the token in the source.
+	Start, End int
+
+	// Line is the line number of the token, starting at 1.
+	Line int
+
+	// SpaceBefore indicates if the token is preceded by whitespace.
+	SpaceBefore bool
+
+	// Last line of the token, which differs from Line for multi-line literals and heredocs.
+	endLine int
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("{%s %q %d}", t.Kind, t.Text, t.Line)
+}
+
+// is returns true if the token is of the given kind and has one of the given texts.
+// If no texts are given, only the kind is compared.
+func (t Token) is(kind TokenKind, texts ...string) bool {
+	if t.Kind != kind {
+		return false
+	}
+	if len(texts) == 0 {
+		return true
+	}
+	for _, text := range texts {
+		if t.Text == text {
+			return true
+		}
+	}
+	return false
+}
+
+// keywords is the set of Ruby's reserved words.
+var keywords = map[string]bool{
+	"alias": true, "and": true, "begin": true, "BEGIN": true, "break": true,
+	"case": true, "class": true, "def": true, "do": true, "else": true,
+	"elsif": true, "END": true, "end": true, "ensure": true, "false": true,
+	"for": true, "if": true, "in": true, "module": true, "next": true,
+	"nil": true, "not": true, "or": true, "redo": true, "rescue": true,
+	"retry": true, "return": true, "self": true, "super": true, "then": true,
+	"true": true, "undef": true, "unless": true, "until": true, "when": true,
+	"while": true, "yield": true,
+}
+
+// operators is the list of operators, ordered such that longer operators are matched first.
+var operators = []string{
+	"**=", "<=>", "===", "...", "<<=", ">>=", "&&=", "||=",
+	"**", "==", "!=", ">=", "<=", "&&", "||", "<<", ">>", "=~", "!~",
+	"..", "::", "=>", "->", "+=", "-=", "*=", "/=", "%=", "|=", "&=", "^=", "&.",
+	"+", "-", "*", "/", "%", "=", "<", ">", "!", "&", "|", "^", "~",
+	"?", ":", ",", ".", "(", ")", "[", "]", "{", "}",
+}
@@ -0,0 +1,42 @@
+package setup
+
+import (
+	"fmt"
+	"strings"
+
+	"main/miner/pattern"
+	"main/miner/ruby"
+)
+
+// isDefaultChecksumPattern returns true if the given line
+// matches the checksum pattern. It also returns the matches.
+func isDefaultChecksumPattern(line string) (bool, []string) {
+	if !strings.Contains(line, "sha256") {
+		return false, nil
+	}
+	regex := pattern.Get(checksumPattern)
+	matches := regex.FindStringSubmatch(line)
+	return len(matches) >= 2, matches
+}
+
+// isChecksumNode returns true if the given statement is a sha256 call with a single checksum.
+// The checksums of bottles are passed as hash and thus don't match.
+func isChecksumNode(node *ruby.Node) bool {
+	_, ok := node.StringArg(0)
+	return node.IsCall("sha256") && ok
+}
+
+// extractChecksumNode returns the checksum of the first sha256 call.
+func extractChecksumNode(nodes []*ruby.Node) (string, error) {
+	return getStringArg(nodes[0])
+}
+
+// getStringArg returns the string of the first argument of the given call.
+// If the argument is not a string, an error is returned.
+func getStringArg(node *ruby.Node) (string, error) {
+	s, ok := node.StringArg(0)
+	if !ok {
+		return "", fmt.Errorf("line %d: expected a string argument for %s", node.Line, node.Name)
+	}
+	return s, nil
+}
@@ -0,0 +1,159 @@
+package setup
+
+import (
+	"strings"
+
+	"main/miner/pattern"
+	"main/miner/ruby"
+	"main/miner/types"
+)
+
+// cleanConflictSequence returns a cleaned slice of conflicts from a given sequence.
+// Each statement may list multiple conflicting formulae sharing the same reason.
+// Conflicts with casks, e.g. `conflicts_with cask: "foo"`, are skipped like in extractConflictNodes.
+func cleanConflictSequence(sequence []string) []*types.Conflict {
+	conflicts := make([]*types.Conflict, 0)
+	for _, statement := range joinStatements(sequence, conflictsWithPattern) {
+		var reason string
+		names := statement
+		if i := strings.Index(statement, "because:"); i != -1 {
+			names = statement[:i]
+
+			regex := pattern.Get(becausePattern)
+			if matches := regex.FindStringSubmatch(statement[i:]); len(matches) >= 2 {
+				reason = matches[1]
+			}
+		}
+
+		names = pattern.Get(conflictOptionPattern).ReplaceAllString(names, "")
+		for _, name := range extractQuotedStrings(names) {
+			conflicts = append(conflicts, &types.Conflict{Name: name, Reason: reason})
+		}
+	}
+	return conflicts
+}
+
+// isDefaultConflictPattern always returns false
+// since conflicts are collected from a sequence of lines.
+func isDefaultConflictPattern(line string) (bool, []string) {
+	return false, []string{}
+}
+
+// isBeginConflictSequence returns true if the given line
+// is the beginning of a conflict sequence.
+func isBeginConflictSequence(line string) bool {
+	regex := pattern.Get(conflictsWithPattern)
+	return regex.MatchString(line)
+}
+
+// isEndConflictSequence returns true if the given line
+// is the end of a conflict sequence.
+func isEndConflictSequence(line string) bool {
+	regex := pattern.Get(endConflictsPatternNegated)
+	return !regex.MatchString(line)
+}
+
+// cleanLinkOverwriteSequence returns a cleaned slice of paths from a given sequence.
+func cleanLinkOverwriteSequence(sequence []string) []string {
+	paths := make([]string, 0)
+	for _, statement := range joinStatements(sequence, linkOverwritePattern) {
+		paths = append(paths, extractQuotedStrings(statement)...)
+	}
+	return paths
+}
+
+// isDefaultLinkOverwritePattern always returns false
+// since link overwrites are collected from a sequence of lines.
+func isDefaultLinkOverwritePattern(line string) (bool, []string) {
+	return false, []string{}
+}
+
+// isBeginLinkOverwriteSequence returns true if the given line
+// is the beginning of a link overwrite sequence.
+func isBeginLinkOverwriteSequence(line string) bool {
+	regex := pattern.Get(linkOverwritePattern)
+	return regex.MatchString(line)
+}
+
+// isEndLinkOverwriteSequence returns true if the given line
+// is the end of a link overwrite sequence.
+func isEndLinkOverwriteSequence(line string) bool {
+	regex := pattern.Get(endLinkOverwritePatternNegated)
+	return !regex.MatchString(line)
+}
+
+// joinStatements joins the lines of the given sequence into statements.
+// A statement begins with a line matching the given keywordPattern and
+// spans all following continuation lines. Empty and comment lines are dropped.
+func joinStatements(sequence []string, keywordPattern string) []string {
+	keywordRe := pattern.Get(keywordPattern)
+	commentRe := pattern.Get(`^\s*#`)
+
+	statements := make([]string, 0)
+	for _, line := range sequence {
+		if strings.TrimSpace(line) == "" || commentRe.MatchString(line) {
+			continue
+		}
+
+		if keywordRe.MatchString(line) || len(statements) == 0 {
+			statements = append(statements, strings.TrimSpace(line))
+			continue
+		}
+
+		statements[len(statements)-1] += " " + strings.TrimSpace(line)
+	}
+	return statements
+}
+
+// extractQuotedStrings returns all strings enclosed in double quotes from the given string.
+func extractQuotedStrings(s string) []string {
+	regex := pattern.Get(quotedStringPattern)
+	res := make([]string, 0)
+	for _, matches := range regex.FindAllStringSubmatch(s, -1) {
+		res = append(res, matches[1])
+	}
+	return res
+}
+
+// isConflictNode returns true if the given statement is a conflicts_with call.
+func isConflictNode(node *ruby.Node) bool {
+	return node.IsCall("conflicts_with")
+}
+
+// extractConflictNodes returns the conflicts of all conflicts_with calls.
+// Each call may list multiple conflicting formulae sharing the same reason.
+func extractConflictNodes(nodes []*ruby.Node) ([]*types.Conflict, error) {
+	conflicts := make([]*types.Conflict, 0)
+	for _, node := range nodes {
+		var reason string
+		if because := node.Options().Get("because"); because != nil {
+			reason = because.Text
+		}
+
+		for _, arg := range node.Args {
+			if arg.Kind == ruby.HashValue {
+				continue
+			}
+			for _, name := range arg.Strings() {
+				conflicts = append(conflicts, &types.Conflict{Name: name, Reason: reason})
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// isLinkOverwriteNode returns true if the given statement is a link_overwrite call.
+func isLinkOverwriteNode(node *ruby.Node) bool {
+	return node.IsCall("link_overwrite")
+}
+
+// extractLinkOverwriteNodes returns the paths of all link_overwrite calls.
+func extractLinkOverwriteNodes(nodes []*ruby.Node) ([]string, error) {
+	paths := make([]string, 0)
+	for _, node := range nodes {
+		for _, arg := range node.Args {
+			paths = append(paths, arg.Strings()...)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,240 @@
+package setup
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"main/miner/ruby"
+	"main/miner/types"
+	"main/stack"
+)
+
+// macOSVersions are the macOS versions with an "on_<version>" block.
+var macOSVersions = []string{
+	"el_capitan", "sierra", "high_sierra", "mojave", "catalina", "big_sur", "monterey", "ventura", "sonoma", "sequoia",
+}
+
+// depCollector collects the dependencies, formula requirements and platform specific archives
+// from the statements of a formula's syntax tree.
+type depCollector struct {
+	set          dependecySet
+	requirements []string
+	archives     []*types.Archive
+
+	// Holds the restrictions of the enclosing blocks.
+	restrictions *stack.Stack[string]
+}
+
+// extractDepNodes returns the dependencies declared by the given statements.
+// Resource, patch and fails_with blocks are skipped.
+func extractDepNodes(nodes []*ruby.Node) *types.Dependencies {
+	c := &depCollector{
+		set:          make(dependecySet, 0),
+		requirements: make([]string, 0),
+		archives:     make([]*types.Archive, 0),
+		restrictions: stack.New[string](),
+	}
+	c.collect(nodes)
+
+	return &types.Dependencies{
+		Lst:                c.set.toSlice(),
+		SystemRequirements: strings.Join(c.requirements, ", "),
+		Archives:           c.archives,
+	}
+}
+
+func (c *depCollector) collect(nodes []*ruby.Node) {
+	for _, node := range nodes {
+		switch {
+		case node.IsCall("uses_from_macos"):
+			c.addMacOSDependency(node)
+		case node.IsCall("depends_on"):
+			c.addDependency(node)
+		case node.IsCall("url"):
+			c.addArchive(node)
+		case node.IsCall("sha256"):
+			if checksum, ok := node.StringArg(0); ok && len(c.restrictions.Values()) > 0 && len(c.archives) > 0 {
+				c.archives[len(c.archives)-1].Checksum = checksum
+			}
+		case node.IsCall("resource", "patch", "fails_with"):
+			continue
+		default:
+			res, ok := getNodeRestriction(node)
+			if !ok {
+				continue
+			}
+			c.restrictions.Push(res)
+			c.collect(node.Body)
+			c.restrictions.Pop()
+
+			// Only the else branch of a condition on the CPU architecture is considered.
+			if other := getOtherCPU(res); other != "" && node.Kind == ruby.Control {
+				c.restrictions.Push(other)
+				c.collect(node.Else)
+				c.restrictions.Pop()
+			}
+		}
+	}
+}
+
+// addMacOSDependency adds the dependency of a uses_from_macos call,
+// which is only required on linux or older macOS versions.
+func (c *depCollector) addMacOSDependency(node *ruby.Node) {
+	name, depType, ok := getNodeDependency(node)
+	if !ok {
+		return
+	}
+
+	res := "linux"
+	if since := node.Options().Get("since"); since != nil && since.Kind == ruby.SymbolValue {
+		res += " or macos: < " + since.Text
+	}
+	c.set.add(&types.Dependency{
+		Name:        name,
+		DepType:     depType,
+		Restriction: res,
+	})
+}
+
+// addDependency adds the dependency or the formula requirement of a depends_on call.
+func (c *depCollector) addDependency(node *ruby.Node) {
+	name, depType, ok := getNodeDependency(node)
+	if !ok {
+		c.addRequirement(node)
+		return
+	}
+
+	restrictions := c.restrictions.Values()
+	if node.Modifier != nil && node.Modifier.Keyword == "if" {
+		if clangRestriction := getClangRestriction("if " + node.Modifier.Cond.Raw); clangRestriction != "" {
+			restrictions = append(restrictions, "clang version "+clangRestriction)
+		}
+	}
+
+	c.set.add(&types.Dependency{
+		Name:        name,
+		DepType:     depType,
+		Restriction: strings.Join(restrictions, " and "),
+	})
+}
+
+// addRequirement adds the formula requirement of a depends_on call,
+// e.g. `depends_on :linux` or `depends_on macos: :ventura`.
+func (c *depCollector) addRequirement(node *ruby.Node) {
+	arg := node.Arg(0)
+	if arg == nil {
+		return
+	}
+
+	var req string
+	var ok bool
+	switch arg.Kind {
+	case ruby.SymbolValue:
+		req, ok = formatFormulaRequirement(":"+arg.Text, "")
+	case ruby.HashValue:
+		pair := arg.Pairs[0]
+		req, ok = formatFormulaRequirement(pair.Key.Text+":", pair.Value.Raw)
+	}
+	if ok {
+		c.requirements = append(c.requirements, req)
+	}
+}
+
+// addArchive adds a platform specific archive for a URL within a restriction block.
+func (c *depCollector) addArchive(node *ruby.Node) {
+	url, ok := node.StringArg(0)
+	if !ok || len(c.restrictions.Values()) == 0 {
+		return
+	}
+	c.archives = append(c.archives, &types.Archive{
+		URL:         url,
+		Restriction: strings.Join(c.restrictions.Values(), " and "),
+	})
+}
+
+// getNodeDependency returns the name and the types of the dependency declared by the given call.
+// Example:
+// `depends_on "foo"` => "foo", []
+// `depends_on "foo" => [:build, :test]` => "foo", ["build", "test"]
+// It returns false if the call declares no named dependency, e.g. `depends_on :linux`.
+func getNodeDependency(node *ruby.Node) (string, []string, bool) {
+	if name, ok := node.StringArg(0); ok {
+		return name, []string{}, true
+	}
+
+	opts := node.Options()
+	if opts == nil || len(node.Args) != 1 || opts.Pairs[0].Key.Kind != ruby.StringValue {
+		return "", nil, false
+	}
+
+	pair := opts.Pairs[0]
+	depType := slices.DeleteFunc(pair.Value.Strings(), func(s string) bool {
+		return s == ""
+	})
+	if depType == nil {
+		depType = []string{}
+	}
+	return pair.Key.Text, depType, true
+}
+
+// getNodeRestriction returns the dependency restriction of the given block, if any.
+// Dependecy restrictions include: on_system, on_linux, on_macos, on_arm, on_intel,
+// on_<macos version> and conditions on the CPU architecture or the clang version.
+func getNodeRestriction(node *ruby.Node) (string, bool) {
+	switch {
+	case node.IsCall("on_linux"):
+		return "linux", true
+	case node.IsCall("on_macos"):
+		return "macos", true
+	case node.IsCall("on_arm"):
+		return "arm", true
+	case node.IsCall("on_intel"):
+		return "intel", true
+	case node.IsCall("on_system"):
+		macos := node.Options().Get("macos")
+		if macos == nil {
+			log.Printf("Unsupported on_system block: %s\n", node.ArgsRaw())
+			return "", false
+		}
+		v, err := formatVersion(macos.Text)
+		if err != nil {
+			log.Printf("Unsupported on_system block: %s\n", node.ArgsRaw())
+			return "", false
+		}
+		return "linux or macos: " + v, true
+	case node.Kind == ruby.Call && strings.HasPrefix(node.Name, "on_"):
+		version := strings.TrimPrefix(node.Name, "on_")
+		if !slices.Contains(macOSVersions, version) {
+			return "", false
+		}
+		var qualifier string
+		if arg := node.Arg(0); arg != nil && arg.Kind == ruby.SymbolValue {
+			qualifier = arg.Text
+		}
+		res, err := formatMacOSRestriction(version, qualifier)
+		if err != nil {
+			log.Printf("Unsupported %s block: %s\n", node.Name, node.ArgsRaw())
+			return "", false
+		}
+		return res, true
+	case node.Kind == ruby.Control && (node.Name == "if" || node.Name == "unless") && node.Value != nil:
+		if cpuRestriction := getCPURestriction(node.Name + " " + node.Value.Raw); cpuRestriction != "" {
+			return cpuRestriction, true
+		}
+		if clangRestriction := getClangRestriction(node.Name + " " + node.Value.Raw); node.Name == "if" && clangRestriction != "" {
+			return "clang version " + clangRestriction, true
+		}
+	}
+	return "", false
+}
+
+// isDependencyNode returns true if the given statement declares dependencies,
+// i.e. a depends_on or uses_from_macos call or a restriction block.
+func isDependencyNode(node *ruby.Node) bool {
+	if node.IsCall("depends_on", "uses_from_macos") {
+		return true
+	}
+	_, ok := getNodeRestriction(node)
+	return ok
+}
@@ -3,10 +3,10 @@ package setup
 import (
 	"fmt"
 	"log"
-	"regexp"
 	"slices"
 	"strings"
 
+	"main/miner/pattern"
 	"main/miner/types"
 	"main/stack"
 )
@@ -40,12 +40,13 @@ func (s dependecySet) add(dep *types.Dependency) {
 	d.Restriction = strings.Join([]string{d.Restriction, dep.Restriction}, " or ")
 }
 
-// toSlice returns the set as a slice of dependencies.
+// toSlice returns the set as a slice of dependencies sorted by their name and type.
 func (s dependecySet) toSlice() []*types.Dependency {
 	res := make([]*types.Dependency, 0)
 	for _, v := range s {
 		res = append(res, v)
 	}
+	types.SortDependencies(res)
 	return res
 }
 
@@ -60,7 +61,7 @@ type skips []skipSequence
 
 func (s skips) shouldSkip(line string) (bool, *skipSequence) {
 	for _, skip := range s {
-		regex := regexp.MustCompile(skip.begin)
+		regex := pattern.Get(skip.begin)
 		if regex.MatchString(line) {
 			return true, &skip
 		}
@@ -75,12 +76,13 @@ func cleanDepSequence(sequence []string, skips skips, numIgnoreEmpty int) *types
 	depResStack := stack.New[string]()     // Holds the dependecy restirctions.
 	formulaReqStack := stack.New[string]() // Holds the formula requirements.
 	set := make(dependecySet, 0)
+	archives := make([]*types.Archive, 0)
 	var skip *skipSequence
 	for i := range sequence {
 		// Check whether to skip the current line.
 		if skip != nil {
 			// Check for end sequence.
-			regex := regexp.MustCompile(skip.end)
+			regex := pattern.Get(skip.end)
 			if regex.MatchString(sequence[i]) {
 				skip = nil
 			}
@@ -94,7 +96,7 @@ func cleanDepSequence(sequence []string, skips skips, numIgnoreEmpty int) *types
 		}
 
 		// Check for uses_from_macos.
-		regex := regexp.MustCompile(macOSSystemDependencyPattern)
+		regex := pattern.Get(macOSSystemDependencyPattern)
 		nameMatches := regex.FindStringSubmatch(sequence[i])
 		if len(nameMatches) >= 2 {
 			depType := getDepType(sequence[i])
@@ -113,7 +115,7 @@ func cleanDepSequence(sequence []string, skips skips, numIgnoreEmpty int) *types
 		}
 
 		// Check for end.
-		regex = regexp.MustCompile(endPatternGeneric)
+		regex = pattern.Get(endPatternGeneric)
 		if regex.MatchString(sequence[i]) {
 			_, err := depResStack.Pop()
 			if err != nil {
@@ -126,8 +128,22 @@ func cleanDepSequence(sequence []string, skips skips, numIgnoreEmpty int) *types
 			continue
 		}
 
+		// Check for the else branch of a condition.
+		regex = pattern.Get(elsePattern)
+		if regex.MatchString(sequence[i]) {
+			res, _ := depResStack.Pop()
+			if other := getOtherCPU(res); other != "" {
+				depResStack.Push(other)
+				continue
+			}
+			// Only the else branch of a condition on the CPU architecture is considered,
+			// the branch of any other condition is skipped including its end statement.
+			skip = &skipSequence{end: endPatternGeneric}
+			continue
+		}
+
 		// Check for the dependency name.
-		regex = regexp.MustCompile(dependencyKeywordPattern)
+		regex = pattern.Get(dependencyKeywordPattern)
 		nameMatches = regex.FindStringSubmatch(sequence[i])
 		if len(nameMatches) >= 2 {
 			depType := getDepType(sequence[i])
@@ -157,9 +173,14 @@ func cleanDepSequence(sequence []string, skips skips, numIgnoreEmpty int) *types
 			continue
 		}
 
+		// Check for platform specific archives.
+		if found := checkArchive(sequence[i], depResStack, &archives); found {
+			continue
+		}
+
 		// Check for fails_with & resource blocks.
-		failsExp := regexp.MustCompile(failsWithPattern)
-		resourceExp := regexp.MustCompile(resourcePattern)
+		failsExp := pattern.Get(failsWithPattern)
+		resourceExp := pattern.Get(resourcePattern)
 		if failsExp.MatchString(sequence[i]) || resourceExp.MatchString(sequence[i]) {
 			// Add a new empty restriction which will be poped as soon as
 			// the end statement of the respective block is reached.
@@ -169,13 +190,42 @@ func cleanDepSequence(sequence []string, skips skips, numIgnoreEmpty int) *types
 	return &types.Dependencies{
 		Lst:                set.toSlice(),
 		SystemRequirements: strings.Join(formulaReqStack.Values(), ", "),
+		Archives:           archives,
+	}
+}
+
+// checkArchive checks the given line for the URL or checksum of a platform specific archive.
+// Archives are only considered within restriction blocks, such as on_macos or on_arm.
+// If a URL is found, a new archive with the current restrictions is added to the archives and true is returned.
+// If a checksum is found, it is set on the last added archive and true is returned.
+func checkArchive(line string, resStack *stack.Stack[string], archives *[]*types.Archive) bool {
+	// The empty restriction is pushed for blocks like resources, which may contain URLs as well.
+	if res, err := resStack.Peek(); err != nil || res == "" {
+		return false
+	}
+
+	regex := pattern.Get(archiveURLPattern)
+	if matches := regex.FindStringSubmatch(line); len(matches) >= 2 {
+		*archives = append(*archives, &types.Archive{
+			URL:         matches[1],
+			Restriction: strings.Join(resStack.Values(), " and "),
+		})
+		return true
+	}
+
+	regex = pattern.Get(archiveChecksumPattern)
+	if matches := regex.FindStringSubmatch(line); len(matches) >= 2 && len(*archives) > 0 {
+		(*archives)[len(*archives)-1].Checksum = matches[1]
+		return true
 	}
+
+	return false
 }
 
 // getDepType returns the dependency type from the given line.
 // If no type is found, an empty slice is returned.
 func getDepType(line string) []string {
-	regex := regexp.MustCompile(dependencyTypePattern)
+	regex := pattern.Get(dependencyTypePattern)
 	typeMatches := regex.FindStringSubmatch(line)
 	if len(typeMatches) >= 2 {
 		return slices.DeleteFunc(typeMatches[1:], func(s string) bool {
@@ -188,7 +238,7 @@ func getDepType(line string) []string {
 // getClangRestriction returns the clang restriction for a dependecy from the given line.
 // If no restriction is found, an empty string is returned.
 func getClangRestriction(line string) string {
-	regex := regexp.MustCompile(clangVersionPattern)
+	regex := pattern.Get(clangVersionPattern)
 	matches := regex.FindStringSubmatch(line)
 	if len(matches) >= 2 {
 		return matches[1]
@@ -196,10 +246,40 @@ func getClangRestriction(line string) string {
 	return ""
 }
 
+// getCPURestriction returns the restriction of a condition on the CPU architecture from the given line.
+// If no condition is found, an empty string is returned.
+// Example:
+// "if Hardware::CPU.arm?" => "arm"
+// "unless Hardware::CPU.arm?" => "intel"
+func getCPURestriction(line string) string {
+	regex := pattern.Get(cpuConditionPattern)
+	matches := regex.FindStringSubmatch(line)
+	if len(matches) < 3 {
+		return ""
+	}
+	if matches[1] == "unless" {
+		return getOtherCPU(matches[2])
+	}
+	return matches[2]
+}
+
+// getOtherCPU returns the CPU architecture of the else branch of a condition on the given architecture.
+// Homebrew only distinguishes between arm and intel.
+// If the given restriction is no CPU architecture, an empty string is returned.
+func getOtherCPU(res string) string {
+	switch res {
+	case "arm":
+		return "intel"
+	case "intel":
+		return "arm"
+	}
+	return ""
+}
+
 // getOSRestriction returns the OS restriction from the given line.
 // If no restriction is found, an empty string is returned.
 func getOSRestriction(line string) string {
-	regex := regexp.MustCompile(osRestrictionPattern)
+	regex := pattern.Get(osRestrictionPattern)
 	matches := regex.FindStringSubmatch(line)
 	if len(matches) >= 2 {
 		return matches[1]
@@ -211,48 +291,54 @@ func getOSRestriction(line string) string {
 // If a requirement is found, it is added to the stack and true is returned.
 // Formula system requirements include: macos, maximum_macos, xcode, and arch.
 func checkFormulaRequirements(line string, reqStack *stack.Stack[string]) bool {
-	regex := regexp.MustCompile(formulaRequirementPattern)
+	regex := pattern.Get(formulaRequirementPattern)
 	matches := regex.FindStringSubmatch(line)
-	count := len(matches)
-	if count < 2 {
+	if len(matches) < 3 {
+		return false
+	}
+
+	req, ok := formatFormulaRequirement(matches[1], matches[2])
+	if !ok {
 		return false
 	}
 
-	req := matches[1]
+	reqStack.Push(req)
+	return true
+}
 
+// formatFormulaRequirement returns the formatted formula requirement from the given
+// requirement keyword and its value. It returns false if the requirement is unknown.
+// Example:
+// ":linux", "" => "linux"
+// "macos:", ":ventura" => "macos >= ventura (or linux)"
+func formatFormulaRequirement(req, value string) (string, bool) {
 	// Leading colon indicates an OS requirement without version e.g. ":linux" or ":macos".
 	if s, found := strings.CutPrefix(req, ":"); found {
-		reqStack.Push(s)
-		return true
+		return s, true
 	}
 
 	req = strings.TrimSuffix(req, ":")
 
-	if count == 3 {
-		switch req {
-		case "macos":
-			req += " >= " + strings.TrimPrefix(matches[2], ":") + " (or linux)"
-		case "maximum_macos":
-			req += " <= " + formatRequirements(matches[2]) + " (or linux)"
-		case "xcode":
-			if strings.Contains(matches[2], `"`) {
-				// Indicates a min version.
-				req += " >= " + formatRequirements(matches[2]) + " (on macos)"
-			} else {
-				req += " " + formatRequirements(matches[2]) + " (on macos)"
-			}
-		case "arch":
-			req = formatRequirements(strings.TrimSpace(matches[2]))
-		default:
-			log.Printf("Incomplete formula requirement: %s, %s\n", req, matches[2])
-			return false
+	switch req {
+	case "macos":
+		req += " >= " + strings.TrimPrefix(value, ":") + " (or linux)"
+	case "maximum_macos":
+		req += " <= " + formatRequirements(value) + " (or linux)"
+	case "xcode":
+		if strings.Contains(value, `"`) {
+			// Indicates a min version.
+			req += " >= " + formatRequirements(value) + " (on macos)"
+		} else {
+			req += " " + formatRequirements(value) + " (on macos)"
 		}
+	case "arch":
+		req = formatRequirements(strings.TrimSpace(value))
+	default:
+		log.Printf("Incomplete formula requirement: %s, %s\n", req, value)
+		return "", false
 	}
 
-	req = strings.ReplaceAll(req, "DevelopmentTools.clang_build_version", "clang version")
-
-	reqStack.Push(req)
-	return true
+	return strings.ReplaceAll(req, "DevelopmentTools.clang_build_version", "clang version"), true
 }
 
 // formatRequirements returns a formatted string from the given requirements.
@@ -271,12 +357,12 @@ func formatRequirements(req string) string {
 
 // checkDependencyRestrictions checks the given line for dependecy restrictions.
 // If a restriction is found, it is added to the stack and true is returned.
-// Dependecy restrictions include: on_system, on_linux, on_arm, and on_intel.
+// Dependecy restrictions include: on_system, on_linux, on_arm, on_intel and conditions on the CPU architecture.
 func checkDependencyRestrictions(line string, resStack *stack.Stack[string]) bool {
 	// Check for on_system.
-	regex := regexp.MustCompile(onSystemPattern)
+	regex := pattern.Get(onSystemPattern)
 	if regex.MatchString(line) {
-		regex = regexp.MustCompile(onSystemExtractPattern)
+		regex = pattern.Get(onSystemExtractPattern)
 		matches := regex.FindStringSubmatch(line)
 		if len(matches) != 2 {
 			panic("Invalid on_system pattern")
@@ -290,52 +376,51 @@ func checkDependencyRestrictions(line string, resStack *stack.Stack[string]) boo
 	}
 
 	// Check for on_linux.
-	regex = regexp.MustCompile(onLinuxPattern)
+	regex = pattern.Get(onLinuxPattern)
 	if regex.MatchString(line) {
 		resStack.Push("linux")
 		return true
 	}
 
 	// Check for on_macos.
-	regex = regexp.MustCompile(onMacosPattern)
+	regex = pattern.Get(onMacosPattern)
 	if regex.MatchString(line) {
 		resStack.Push("macos")
 		return true
 	}
 
 	// Check for on_arm.
-	regex = regexp.MustCompile(onArmPattern)
+	regex = pattern.Get(onArmPattern)
 	if regex.MatchString(line) {
 		resStack.Push("arm")
 		return true
 	}
 
 	// Check for on_intel.
-	regex = regexp.MustCompile(onIntelPattern)
+	regex = pattern.Get(onIntelPattern)
 	if regex.MatchString(line) {
 		resStack.Push("intel")
 		return true
 	}
 
 	// Check for on_macos versions e.g. "on_mojave :or_newer".
-	regex = regexp.MustCompile(onMacOSVersionPattern)
+	regex = pattern.Get(onMacOSVersionPattern)
 	matches := regex.FindStringSubmatch(line)
 	if len(matches) >= 3 {
-		req := "macos: "
-		v, res := matches[2], matches[3]
-		if res != "" {
-			fv, err := formatVersion(v + "_" + res)
-			if err != nil {
-				panic(err)
-			}
-			req += fv
-		} else {
-			req += v
+		req, err := formatMacOSRestriction(matches[2], matches[3])
+		if err != nil {
+			panic(err)
 		}
 		resStack.Push(req)
 		return true
 	}
 
+	// Check for conditions on the CPU architecture, e.g. "if Hardware::CPU.arm?".
+	if cpuRestriction := getCPURestriction(line); cpuRestriction != "" {
+		resStack.Push(cpuRestriction)
+		return true
+	}
+
 	// Check for DevelopmentTools.clang_build_version.
 	clangRestriction := getClangRestriction(line)
 	if clangRestriction != "" {
@@ -346,6 +431,23 @@ func checkDependencyRestrictions(line string, resStack *stack.Stack[string]) boo
 	return false
 }
 
+// formatMacOSRestriction returns the restriction of an "on_<version>" block
+// from the given macOS version and optional qualifier, e.g. ":or_newer".
+// If the qualifier is invalid, an error is returned.
+// Example:
+// "sonoma", ":or_newer" => "macos: >= sonoma"
+// "ventura", "" => "macos: ventura"
+func formatMacOSRestriction(version, qualifier string) (string, error) {
+	if qualifier == "" {
+		return "macos: " + version, nil
+	}
+	v, err := formatVersion(version + "_" + strings.TrimPrefix(qualifier, ":"))
+	if err != nil {
+		return "", err
+	}
+	return "macos: " + v, nil
+}
+
 // formatVersion returns a formatted string from the given version.
 // If the string format is invalid, an error is returned.
 // Example:
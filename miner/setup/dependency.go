@@ -1,8 +1,8 @@
 package setup
 
 import (
-	"regexp"
-
+	"main/miner/pattern"
+	"main/miner/ruby"
 	"main/miner/types"
 )
 
@@ -20,13 +20,18 @@ func isDefaultDependencyPattern(line string) (bool, []string) {
 // isBeginDependencySequence returns true if the given line
 // is the beginning of a dependency sequence.
 func isBeginDependencySequence(line string) bool {
-	regex := regexp.MustCompile(beginDependencyPattern)
+	regex := pattern.Get(beginDependencyPattern)
 	return regex.MatchString(line)
 }
 
 // isEndDependencySequence returns true if the given line
 // is the end of a dependency sequence.
 func isEndDependencySequence(line string) bool {
-	regex := regexp.MustCompile(endDependencyPatternNegated)
+	regex := pattern.Get(endDependencyPatternNegated)
 	return !regex.MatchString(line)
 }
+
+// extractDependencyNodes returns the dependencies declared by the given statements.
+func extractDependencyNodes(nodes []*ruby.Node) (*types.Dependencies, error) {
+	return extractDepNodes(nodes), nil
+}
@@ -1,25 +1,31 @@
 package setup
 
 import (
-	"regexp"
-
+	"main/miner/pattern"
+	"main/miner/ruby"
 	"main/miner/types"
 )
 
 // cleanHeadSequence returns a cleaned head from a sequence.
 func cleanHeadSequence(sequence []string) *types.Head {
 	if len(sequence) == 1 {
-		return &types.Head{URL: sequence[0]}
+		// The sequence holds the arguments of the head, e.g. `"https://hg.example.com/foo", using: :hg`.
+		head := &types.Head{Using: getUsing(sequence[0])}
+		if matches := pattern.Get(headArgsURLPattern).FindStringSubmatch(sequence[0]); len(matches) >= 2 {
+			head.URL = matches[1]
+		}
+		return head
 	}
 
 	head := &types.Head{}
 	var index int
 	for i := range sequence {
 		// Check for the URL.
-		regex := regexp.MustCompile(headBlockURLPattern)
+		regex := pattern.Get(headBlockURLPattern)
 		matches := regex.FindStringSubmatch(sequence[i])
 		if len(matches) >= 2 {
 			head.URL = matches[1]
+			head.Using = getUsing(sequence[i])
 			index = i
 			break
 		}
@@ -36,10 +42,20 @@ func cleanHeadSequence(sequence []string) *types.Head {
 	return head
 }
 
+// getUsing returns the download strategy of the using option in the given line, e.g. "hg".
+// If no using option is found, an empty string is returned.
+func getUsing(line string) string {
+	regex := pattern.Get(usingPattern)
+	if matches := regex.FindStringSubmatch(line); len(matches) >= 2 {
+		return matches[1]
+	}
+	return ""
+}
+
 // isDefaultHeadPattern returns true if the given line
 // matches the head pattern. It also returns the matches.
 func isDefaultHeadPattern(line string) (bool, []string) {
-	regex := regexp.MustCompile(headURLPattern)
+	regex := pattern.Get(headURLPattern)
 	matches := regex.FindStringSubmatch(line)
 	return len(matches) >= 2, matches
 }
@@ -47,13 +63,58 @@ func isDefaultHeadPattern(line string) (bool, []string) {
 // isBeginHeadSequence returns true if the given line
 // is the beginning of a head sequence.
 func isBeginHeadSequence(line string) bool {
-	regex := regexp.MustCompile(beginHeadPattern)
+	regex := pattern.Get(beginHeadPattern)
 	return regex.MatchString(line)
 }
 
 // isEndHeadSequence returns true if the given line
 // is the end of a head sequence.
 func isEndHeadSequence(line string) bool {
-	regex := regexp.MustCompile(endPattern(2))
+	regex := pattern.Get(endPattern(2))
 	return regex.MatchString(line)
 }
+
+// isHeadNode returns true if the given statement is a head call or block.
+func isHeadNode(node *ruby.Node) bool {
+	return node.IsCall("head")
+}
+
+// extractHeadNode returns the head of the first head call or block.
+// The dependencies of a head block are extracted as well.
+func extractHeadNode(nodes []*ruby.Node) (*types.Head, error) {
+	node := nodes[0]
+	if len(node.Args) > 0 {
+		url, err := getStringArg(node)
+		if err != nil {
+			return nil, err
+		}
+		return &types.Head{URL: url, Using: getNodeUsing(node)}, nil
+	}
+
+	head := &types.Head{}
+	rest := make([]*ruby.Node, 0)
+	for _, stmt := range node.Body {
+		if stmt.IsCall("url") && head.URL == "" {
+			url, err := getStringArg(stmt)
+			if err != nil {
+				return nil, err
+			}
+			head.URL = url
+			head.Using = getNodeUsing(stmt)
+			continue
+		}
+		rest = append(rest, stmt)
+	}
+
+	head.Dependencies = extractDepNodes(rest).Lst
+	return head, nil
+}
+
+// getNodeUsing returns the download strategy of the using option of the given call, e.g. "hg".
+// If the call has no using option with a symbol, an empty string is returned.
+func getNodeUsing(node *ruby.Node) string {
+	if using := node.Options().Get("using"); using != nil && using.Kind == ruby.SymbolValue {
+		return using.Text
+	}
+	return ""
+}
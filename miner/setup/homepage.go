@@ -1,11 +1,29 @@
 package setup
 
-import "regexp"
+import (
+	"strings"
+
+	"main/miner/pattern"
+	"main/miner/ruby"
+)
 
 // isDefaultHomepagePattern returns true if the given line
 // matches the homepage pattern. It also returns the matches.
 func isDefaultHomepagePattern(line string) (bool, []string) {
-	regex := regexp.MustCompile(homepagePattern)
+	if !strings.Contains(line, "homepage") {
+		return false, nil
+	}
+	regex := pattern.Get(homepagePattern)
 	matches := regex.FindStringSubmatch(line)
 	return len(matches) >= 2, matches
 }
+
+// isHomepageNode returns true if the given statement is a homepage call.
+func isHomepageNode(node *ruby.Node) bool {
+	return node.IsCall("homepage")
+}
+
+// extractHomepageNode returns the homepage of the first homepage call.
+func extractHomepageNode(nodes []*ruby.Node) (string, error) {
+	return getStringArg(nodes[0])
+}
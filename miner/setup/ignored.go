@@ -0,0 +1,26 @@
+package setup
+
+import (
+	"main/miner/ruby"
+)
+
+// ignoredCalls are the formula DSL calls which are intentionally not mined,
+// since they hold no metadata relevant for the dependency graph.
+var ignoredCalls = []string{
+	"desc", "version", "revision", "version_scheme", "compatibility_version",
+	"livecheck", "bottle", "pour_bottle?", "keg_only", "skip_clean", "cxxstdlib_check",
+	"option", "deprecated_option", "deprecate!", "disable!", "no_autobump!",
+	"resource", "patch", "fails_with", "go_resource", "needs", "env", "preserve_rpath",
+	"allow_network_access!", "deny_network_access!",
+	"test", "service", "plist_options", "caveats", "include", "extend",
+}
+
+// IsIgnoredNode returns true if the given top-level statement of a formula is intentionally not mined.
+// This includes all method definitions, e.g. install, test and caveats, as well as assignments,
+// which are only evaluated to resolve string interpolations.
+func IsIgnoredNode(node *ruby.Node) bool {
+	if node.Kind == ruby.Def || node.Kind == ruby.Assign {
+		return true
+	}
+	return node.IsCall(ignoredCalls...)
+}
@@ -1,18 +1,20 @@
 package setup
 
 import (
-	"regexp"
 	"strings"
+
+	"main/miner/pattern"
+	"main/miner/ruby"
 )
 
 // cleanLicenseSequence returns a cleaned string from a sequence.
 func cleanLicenseSequence(sequence []string) string {
 	// Remove leading license keyword.
-	regex := regexp.MustCompile(licenseKeywordPattern)
+	regex := pattern.Get(licenseKeywordPattern)
 	sequence[0] = regex.ReplaceAllString(sequence[0], "")
 	for i := range sequence {
 		// Remove comments.
-		regex := regexp.MustCompile(commentPattern)
+		regex := pattern.Get(commentPattern)
 		sequence[i] = regex.ReplaceAllString(sequence[i], "")
 		// Remove whitespace, tabs, and newlines.
 		sequence[i] = strings.TrimSpace(sequence[i])
@@ -23,7 +25,10 @@ func cleanLicenseSequence(sequence []string) string {
 // isDefaultLicensePattern returns true if the given line
 // matches the license pattern. It also returns the matches.
 func isDefaultLicensePattern(line string) (bool, []string) {
-	regex := regexp.MustCompile(licensePattern)
+	if !strings.Contains(line, "license") {
+		return false, nil
+	}
+	regex := pattern.Get(licensePattern)
 	matches := regex.FindStringSubmatch(line)
 	return len(matches) >= 2, matches
 }
@@ -31,14 +36,17 @@ func isDefaultLicensePattern(line string) (bool, []string) {
 // isBeginLicenseSequence returns true if the given line
 // is the beginning of a license sequence.
 func isBeginLicenseSequence(line string) bool {
-	match, _ := regexp.MatchString(licenseKeywordPattern, line)
+	if !strings.Contains(line, "license") {
+		return false
+	}
+	match := pattern.Get(licenseKeywordPattern).MatchString(line)
 	return match && hasUnclosedBrackets(line)
 }
 
 // isEndLicenseSequence returns true if the given line
 // is the end of a license sequence.
 func isEndLicenseSequence(line string) bool {
-	match, _ := regexp.MatchString(trailingCommaPattern, line)
+	match := pattern.Get(trailingCommaPattern).MatchString(line)
 	return hasUnopenedBrackets(line) && !match
 }
 
@@ -70,3 +78,13 @@ func countBrackets(s string) (open int, close int) {
 	}
 	return openCount, closeCount
 }
+
+// isLicenseNode returns true if the given statement is a license call.
+func isLicenseNode(node *ruby.Node) bool {
+	return node.IsCall("license")
+}
+
+// extractLicenseNode returns the cleaned arguments of the first license call.
+func extractLicenseNode(nodes []*ruby.Node) (string, error) {
+	return cleanLicenseSequence(strings.Split(nodes[0].ArgsRaw(), "\n")), nil
+}
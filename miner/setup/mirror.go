@@ -1,11 +1,29 @@
 package setup
 
-import "regexp"
+import (
+	"strings"
+
+	"main/miner/pattern"
+	"main/miner/ruby"
+)
 
 // isDefaultMirrorPattern returns true if the given line
 // matches the mirror pattern. It also returns the matches.
 func isDefaultMirrorPattern(line string) (bool, []string) {
-	regex := regexp.MustCompile(mirrorPattern)
+	if !strings.Contains(line, "mirror") {
+		return false, nil
+	}
+	regex := pattern.Get(mirrorPattern)
 	matches := regex.FindStringSubmatch(line)
 	return len(matches) >= 2, matches
 }
+
+// isMirrorNode returns true if the given statement is a mirror call.
+func isMirrorNode(node *ruby.Node) bool {
+	return node.IsCall("mirror")
+}
+
+// extractMirrorNode returns the URL of the first mirror call.
+func extractMirrorNode(nodes []*ruby.Node) (string, error) {
+	return getStringArg(nodes[0])
+}
@@ -1,6 +1,10 @@
 package setup
 
-import "fmt"
+import (
+	"fmt"
+
+	"main/miner/pattern"
+)
 
 // RegEx patterns for parsing Formula fields.
 const (
@@ -25,11 +29,32 @@ const (
 	// and the literal string "do".
 	stableUrlBeginPattern = `\s{2}stable\s+do`
 
-	// stableUrlPattern matches two or more consecutive spaces,
+	// stableUrlPattern matches two to four consecutive spaces,
 	// followed by the literal string "url",
 	// followed by a string enclosed in double quotes, which is captured.
 	// Further, an optional trailing comma is matched and captured.
-	stableUrlPattern = `^\s{2,}url\s+"([^"]+)"(,?)`
+	// URLs nested deeper belong to platform specific blocks.
+	stableUrlPattern = `^\s{2,4}url\s+"([^"]+)"(,?)`
+
+	// checksumPattern matches two consecutive spaces,
+	// followed by the literal string "sha256", one or more whitespaces,
+	// and a string enclosed in double quotes, which is captured.
+	checksumPattern = `^\s{2}sha256\s+"([^"]+)"`
+
+	// stableChecksumPattern matches two to four consecutive spaces,
+	// followed by the literal string "sha256", one or more whitespaces,
+	// and a string enclosed in double quotes, which is captured.
+	stableChecksumPattern = `^\s{2,4}sha256\s+"([^"]+)"`
+
+	// archiveURLPattern matches four or more consecutive spaces,
+	// followed by the literal string "url", one or more whitespaces,
+	// and a string enclosed in double quotes, which is captured.
+	archiveURLPattern = `^\s{4,}url\s+"([^"]+)"`
+
+	// archiveChecksumPattern matches four or more consecutive spaces,
+	// followed by the literal string "sha256", one or more whitespaces,
+	// and a string enclosed in double quotes, which is captured.
+	archiveChecksumPattern = `^\s{4,}sha256\s+"([^"]+)"`
 
 	// blockResourcePattern matches four consecutive spaces,
 	// followed by the literal string "resource".
@@ -83,8 +108,16 @@ const (
 
 	// headURLPattern matches two consecutive spaces,
 	// followed by the literal string "head",
-	// followed by a string enclosed in double quotes, which is captured.
-	headURLPattern = `\s{2}head\s+"([^"]+)"`
+	// followed by a string enclosed in double quotes and the rest of the line, which are captured.
+	headURLPattern = `\s{2}head\s+("[^"]+".*)`
+
+	// headArgsURLPattern matches a string enclosed in double quotes at the beginning of the arguments of a head,
+	// whose content is captured.
+	headArgsURLPattern = `^"([^"]+)"`
+
+	// usingPattern matches the literal string "using:", followed by one or more whitespace characters,
+	// and a symbol, whose name is captured.
+	usingPattern = `using:\s+:(\w+)`
 
 	// headBlockURLPattern matches the string "url"
 	// with four leading whitespace characters, followed by a string enclosed in double quotes.
@@ -129,8 +162,8 @@ const (
 	// or a line that starts with two or more white spaces, followed by either of the listed keywords:
 	// ("depends_on", "uses_from_macos", "on_arm", etc.).
 	// Further, any line strting with four or more whitespace characters followed by "fails_with" or "resource" is also matched.
-	// Further, any line starting with six or more whitespace characters followed by "url" or "sha256" is also matched.
-	endDependencyPatternNegated = `^(\s{2,})(depends_on|uses_from_macos|on_macos|on_arm|on_intel|on_linux|on_system|on_el_capitan|on_sierra|on_high_sierra|on_mojave|on_catalina|on_big_sur|on_monterey|on_ventura|on_sonoma|on_el_capitan|end|if DevelopmentTools\.)|^[\s\t]*$|^\s*#.*$|^(\s{4,}(fails_with|resource))|^(\s{6,}(url|sha256))`
+	// Further, any line starting with four or more whitespace characters followed by "url" or "sha256" is also matched.
+	endDependencyPatternNegated = `^(\s{2,})(depends_on|uses_from_macos|on_macos|on_arm|on_intel|on_linux|on_system|on_el_capitan|on_sierra|on_high_sierra|on_mojave|on_catalina|on_big_sur|on_monterey|on_ventura|on_sonoma|on_el_capitan|end|else|if DevelopmentTools\.|(if|unless) Hardware::CPU\.)|^[\s\t]*$|^\s*#.*$|^(\s{4,}(fails_with|resource))|^(\s{4,}(url|sha256))`
 
 	// commentPattern matches matches a sequence that starts with the "#" character,
 	// followed by any sequence of characters until the end of the line.
@@ -184,6 +217,16 @@ const (
 	// Further, it is ensured that the line does not start with a comment character "#".
 	clangVersionPattern = `^[^#]*if\s+\w+\.clang_build_version\s+([<>]?=?=?\s+\d+)`
 
+	// cpuConditionPattern matches a sequence beginning with the literal string "if" or "unless", which is captured,
+	// followed by one or more whitespace characters, the literal string "Hardware::CPU.",
+	// and then either "arm" or "intel", which is captured, followed by a question mark.
+	// Further, it is ensured that the line does not start with a comment character "#".
+	cpuConditionPattern = `^[^#]*\b(if|unless)\s+Hardware::CPU\.(arm|intel)\?`
+
+	// elsePattern matches a line beginning with two or more whitespace characters,
+	// followed by the literal string "else".
+	elsePattern = `^(\s{2,})else\b`
+
 	// failsWithPattern matches a sequence beginning with two or more whitespace characters,
 	// followed by the literal string "fails_with",
 	// followed by an optional sequence of words, spaces, ', =, >, and : characters,
@@ -196,23 +239,125 @@ const (
 	// and the literal string "do".
 	resourcePattern = `^\s{2,}resource\s+"[^"]+"\s+do`
 
+	// conflictsWithPattern matches two consecutive spaces,
+	// followed by the literal string "conflicts_with" and one or more whitespace characters.
+	conflictsWithPattern = `^\s{2}conflicts_with\s+`
+
+	// endConflictsPatternNegated matches lines that consist entirely of whitespace characters,
+	// or a comment line (starts with zero or more spaces followed by '#'),
+	// or a line that starts with two consecutive spaces followed by the literal string "conflicts_with",
+	// or a continuation line that starts with four or more whitespace characters.
+	endConflictsPatternNegated = `^\s{2}conflicts_with\s+|^[\s\t]*$|^\s*#.*$|^\s{4,}\S`
+
+	// linkOverwritePattern matches two consecutive spaces,
+	// followed by the literal string "link_overwrite" and one or more whitespace characters.
+	linkOverwritePattern = `^\s{2}link_overwrite\s+`
+
+	// endLinkOverwritePatternNegated matches lines that consist entirely of whitespace characters,
+	// or a comment line (starts with zero or more spaces followed by '#'),
+	// or a line that starts with two consecutive spaces followed by the literal string "link_overwrite",
+	// or a continuation line that starts with four or more whitespace characters.
+	endLinkOverwritePatternNegated = `^\s{2}link_overwrite\s+|^[\s\t]*$|^\s*#.*$|^\s{4,}\S`
+
+	// becausePattern matches the literal string "because:",
+	// followed by zero or more whitespace characters and a string enclosed in double quotes, which is captured.
+	becausePattern = `because:\s*"([^"]*)"`
+
+	// conflictOptionPattern matches an option of a conflicts_with statement other than "because:", e.g. `cask: "foo"`,
+	// whose value is a string enclosed in double quotes or an array enclosed in square brackets.
+	conflictOptionPattern = `\w+:\s*("[^"]*"|\[[^\]]*\])`
+
+	// quotedStringPattern matches a string enclosed in double quotes, which is captured.
+	quotedStringPattern = `"([^"]*)"`
+
 	// InterpolationPattern matches a sequence beginning with the literal "#{",
 	// followed by one or more characters that are not the closing "}" character,
 	// which is captured, and ending with the "}" character.
 	// This extracts a variable used for Ruby string interpolation.
 	InterpolationPattern = `#\{([^}]+)\}`
+
+	// AssignmentPattern matches a sequence beginning with zero or more whitespace characters,
+	// followed by a variable or constant name, which is captured,
+	// an assignment ("=" character) and a string enclosed in double quotes, which is captured.
+	// Optionally, the string may be followed by ".freeze" and a trailing comment.
+	AssignmentPattern = `^\s*([A-Za-z_]\w*)\s*=\s*"([^"]*)"(?:\.freeze)?\s*(?:#.*)?$`
+
+	// VersionPattern matches two to four consecutive spaces,
+	// followed by the literal string "version", one or more whitespaces,
+	// and a string enclosed in double quotes, which is captured.
+	// Versions nested deeper belong to resources.
+	VersionPattern = `^\s{2,4}version\s+"([^"]+)"`
+
+	// URLVersionPattern matches a version within the file name of an archive URL.
+	// The version begins after the start, a hyphen, an underscore, a slash or a "v",
+	// and consists of two or more numbers separated by dots, which are captured.
+	URLVersionPattern = `(?:^|[-_/v])v?(\d+(?:\.\d+)+)`
 )
 
+// init compiles the static patterns upfront, such that an invalid pattern fails on start
+// rather than once a formula using it is parsed.
+func init() {
+	pattern.MustRegister(
+		homepagePattern,
+		urlPattern,
+		urlBeginPattern,
+		stableUrlBeginPattern,
+		stableUrlPattern,
+		checksumPattern,
+		stableChecksumPattern,
+		archiveURLPattern,
+		archiveChecksumPattern,
+		blockResourcePattern,
+		blockPatchPattern,
+		tagPattern,
+		tagExtractPattern,
+		revisionPattern,
+		mirrorPattern,
+		licensePattern,
+		licenseKeywordPattern,
+		trailingCommaPattern,
+		headURLPattern,
+		headArgsURLPattern,
+		usingPattern,
+		headBlockURLPattern,
+		beginHeadPattern,
+		dependencyTypePattern,
+		dependencyKeywordPattern,
+		macOSSystemDependencyPattern,
+		osRestrictionPattern,
+		beginDependencyPattern,
+		endDependencyPatternNegated,
+		commentPattern,
+		onSystemPattern,
+		onSystemExtractPattern,
+		onLinuxPattern,
+		onMacosPattern,
+		onMacOSVersionPattern,
+		onArmPattern,
+		onIntelPattern,
+		endPatternGeneric,
+		formulaRequirementPattern,
+		clangVersionPattern,
+		cpuConditionPattern,
+		elsePattern,
+		failsWithPattern,
+		resourcePattern,
+		conflictsWithPattern,
+		endConflictsPatternNegated,
+		linkOverwritePattern,
+		endLinkOverwritePatternNegated,
+		becausePattern,
+		conflictOptionPattern,
+		quotedStringPattern,
+		InterpolationPattern,
+		AssignmentPattern,
+		VersionPattern,
+		URLVersionPattern,
+	)
+}
+
 // endPattern returns a RegEx pattern matching a sequence beginning with
 // the number of given leadingSpaces, followed by the literal string "end".
 func endPattern(leadingSpaces int) string {
 	return fmt.Sprintf(`^\s{%d}end`, leadingSpaces)
 }
-
-// VarAssignmentPattern returns a RegEx pattern matching a sequence beggining with
-// two or more whitespace characters, followed by the given varName,
-// followed by an assignment ("=" character) and a string enclosed in qutotes,
-// which is captured.
-func VarAssignmentPattern(varName string) string {
-	return fmt.Sprintf(`^\s{2,}%s\s+=\s+"([^"]+)"`, varName)
-}
@@ -0,0 +1,101 @@
+package setup
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"main/miner/parser"
+)
+
+// registry holds the custom strategies, which extract additional fields from the formula files.
+// The values of custom fields are stored in the Extra map of a formula.
+var registry = struct {
+	mu sync.RWMutex
+
+	// The names of the custom fields in order of registration.
+	names []string
+
+	// The builders of the custom strategies for the syntax tree, where the key is the name of the field.
+	nodeBuilders map[string]func() parser.NodeStrategy
+
+	// The builders of the custom strategies for the line parser, where the key is the name of the field.
+	lineBuilders map[string]func(parser.FormulaParser) parser.ParseStrategy
+}{
+	names:        make([]string, 0),
+	nodeBuilders: make(map[string]func() parser.NodeStrategy),
+	lineBuilders: make(map[string]func(parser.FormulaParser) parser.ParseStrategy),
+}
+
+// Register registers a custom field with the given name.
+// The build function returns a new strategy extracting the field from the syntax tree of a formula file.
+// Optionally, buildLine returns a new strategy matching the field line by line for the legacy parser.
+// Both functions are called once per formula file and the name of their strategies must match the given name.
+// Custom strategies are appended to the built-in ones, thus a statement matched by a built-in field
+// isn't passed to any custom strategy.
+// It returns an error if the name is already taken by a built-in or another custom field.
+func Register(name string, build func() parser.NodeStrategy, buildLine func(parser.FormulaParser) parser.ParseStrategy) error {
+	if build == nil {
+		return fmt.Errorf("no strategy provided for field %s", name)
+	}
+	if slices.Contains(builtinFields(), name) {
+		return fmt.Errorf("field %s is a built-in field", name)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if _, ok := registry.nodeBuilders[name]; ok {
+		return fmt.Errorf("field %s is already registered", name)
+	}
+
+	registry.names = append(registry.names, name)
+	registry.nodeBuilders[name] = build
+	if buildLine != nil {
+		registry.lineBuilders[name] = buildLine
+	}
+	return nil
+}
+
+// CustomFields returns the names of all registered custom fields in order of registration.
+func CustomFields() []string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	return slices.Clone(registry.names)
+}
+
+// customNodeStrategies returns new instances of the registered custom strategies for the syntax tree.
+func customNodeStrategies() []parser.NodeStrategy {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	strategies := make([]parser.NodeStrategy, 0, len(registry.names))
+	for _, name := range registry.names {
+		strategies = append(strategies, registry.nodeBuilders[name]())
+	}
+	return strategies
+}
+
+// customStrategies returns new instances of the registered custom strategies for the line parser.
+func customStrategies(fp parser.FormulaParser) []parser.ParseStrategy {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+
+	strategies := make([]parser.ParseStrategy, 0, len(registry.names))
+	for _, name := range registry.names {
+		if buildLine, ok := registry.lineBuilders[name]; ok {
+			strategies = append(strategies, buildLine(fp))
+		}
+	}
+	return strategies
+}
+
+// builtinFields returns the names of the built-in fields.
+func builtinFields() []string {
+	names := make([]string, 0)
+	for _, s := range builtinNodeStrategies() {
+		names = append(names, s.Name())
+	}
+	return names
+}
@@ -0,0 +1,53 @@
+package setup
+
+import (
+	"log"
+	"testing"
+
+	"main/miner/parser"
+	"main/miner/ruby"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// buildLivecheckNodeMatcher returns a NodeMatcher extracting the URL of a livecheck block.
+func buildLivecheckNodeMatcher() parser.NodeStrategy {
+	return parser.NewNM[string]("livecheck_url", func(node *ruby.Node) bool {
+		return node.IsCall("livecheck")
+	}, func(nodes []*ruby.Node) (string, error) {
+		for _, node := range nodes[0].Body {
+			if node.IsCall("url") {
+				return node.ArgsRaw(), nil
+			}
+		}
+		return "", nil
+	})
+}
+
+func TestRegister(t *testing.T) {
+	assert.NoError(t, Register("livecheck_url", buildLivecheckNodeMatcher, nil))
+	assert.Error(t, Register("livecheck_url", buildLivecheckNodeMatcher, nil), "expected error for duplicate field")
+	assert.Error(t, Register("homepage", buildLivecheckNodeMatcher, nil), "expected error for built-in field")
+	assert.Error(t, Register("foo", nil, nil), "expected error for missing strategy")
+	assert.Equal(t, []string{"livecheck_url"}, CustomFields())
+
+	tree, err := ruby.Parse(`class Foo < Formula
+  homepage "https://example.com/foo"
+  livecheck do
+    url :homepage
+  end
+end`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	treeParser := &parser.TreeParser{Tree: tree}
+	results, err := treeParser.ParseFields(BuildNodeStrategies())
+	if err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, map[string]interface{}{
+		"homepage":      "https://example.com/foo",
+		"livecheck_url": ":homepage",
+	}, results)
+}
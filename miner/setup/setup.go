@@ -6,16 +6,25 @@ import (
 )
 
 // BuildStrategies returns a list of parse strategies.
-// The list contains a strategy for each field, parsed from the formula file.
+// The list contains a strategy for each field, parsed from the formula file,
+// followed by the registered custom strategies for the line parser.
 func BuildStrategies(fp parser.FormulaParser) []parser.ParseStrategy {
+	return append(builtinStrategies(fp), customStrategies(fp)...)
+}
+
+// builtinStrategies returns a list of the parse strategies for the built-in fields.
+func builtinStrategies(fp parser.FormulaParser) []parser.ParseStrategy {
 	return []parser.ParseStrategy{
 		BuildHomepageMatcher(fp),
 		BuildURLMatcher(fp),
 		BuildStableURLMatcher(fp),
 		BuildMirrorMatcher(fp),
+		BuildChecksumMatcher(fp),
 		BuildLicenseMatcher(fp),
 		BuildHeadMatcher(fp),
 		BuildDependencyMatcher(fp),
+		BuildConflictMatcher(fp),
+		BuildLinkOverwriteMatcher(fp),
 	}
 }
 
@@ -39,6 +48,11 @@ func BuildMirrorMatcher(fp parser.FormulaParser) *parser.SingleLineMatcher[strin
 	return parser.NewSLM[string]("mirror", isDefaultMirrorPattern, fp)
 }
 
+// BuildChecksumMatcher returns a SingleLineMatcher for the sha256 field.
+func BuildChecksumMatcher(fp parser.FormulaParser) *parser.SingleLineMatcher[string] {
+	return parser.NewSLM[string]("sha256", isDefaultChecksumPattern, fp)
+}
+
 // BuildLicenseMatcher returns a MultiLineMatcher for the license field.
 func BuildLicenseMatcher(fp parser.FormulaParser) *parser.MultiLineMatcher[string] {
 	return parser.NewMLM[string]("license", isDefaultLicensePattern, fp, isBeginLicenseSequence, isEndLicenseSequence, cleanLicenseSequence)
@@ -51,5 +65,83 @@ func BuildHeadMatcher(fp parser.FormulaParser) *parser.MultiLineMatcher[*types.H
 
 // BuildDependencyMatcher returns a MultiLineMatcher for the dependency fields.
 func BuildDependencyMatcher(fp parser.FormulaParser) *parser.MultiLineMatcher[*types.Dependencies] {
-	return parser.NewMLM[*types.Dependencies]("dependency", isDefaultDependencyPattern, fp, isBeginDependencySequence, isEndDependencySequence, cleanDependencySequence)
+	return parser.NewMLM[*types.Dependencies]("dependency", isDefaultDependencyPattern, fp, isBeginDependencySequence, isEndDependencySequence, cleanDependencySequence).ExcludeEnd()
+}
+
+// BuildConflictMatcher returns a MultiLineMatcher for the conflicts_with fields.
+func BuildConflictMatcher(fp parser.FormulaParser) *parser.MultiLineMatcher[[]*types.Conflict] {
+	return parser.NewMLM[[]*types.Conflict]("conflict", isDefaultConflictPattern, fp, isBeginConflictSequence, isEndConflictSequence, cleanConflictSequence).ExcludeEnd()
+}
+
+// BuildLinkOverwriteMatcher returns a MultiLineMatcher for the link_overwrite fields.
+func BuildLinkOverwriteMatcher(fp parser.FormulaParser) *parser.MultiLineMatcher[[]string] {
+	return parser.NewMLM[[]string]("link_overwrite", isDefaultLinkOverwritePattern, fp, isBeginLinkOverwriteSequence, isEndLinkOverwriteSequence, cleanLinkOverwriteSequence).ExcludeEnd()
+}
+
+// BuildNodeStrategies returns a list of node strategies.
+// The list contains a strategy for each field, extracted from the syntax tree of the formula file,
+// followed by the registered custom strategies.
+// The field names match the ones of the strategies returned by BuildStrategies.
+func BuildNodeStrategies() []parser.NodeStrategy {
+	return append(builtinNodeStrategies(), customNodeStrategies()...)
+}
+
+// builtinNodeStrategies returns a list of the node strategies for the built-in fields.
+func builtinNodeStrategies() []parser.NodeStrategy {
+	return []parser.NodeStrategy{
+		BuildHomepageNodeMatcher(),
+		BuildURLNodeMatcher(),
+		BuildMirrorNodeMatcher(),
+		BuildChecksumNodeMatcher(),
+		BuildLicenseNodeMatcher(),
+		BuildHeadNodeMatcher(),
+		BuildDependencyNodeMatcher(),
+		BuildConflictNodeMatcher(),
+		BuildLinkOverwriteNodeMatcher(),
+	}
+}
+
+// BuildHomepageNodeMatcher returns a NodeMatcher for the homepage field.
+func BuildHomepageNodeMatcher() *parser.NodeMatcher[string] {
+	return parser.NewNM[string]("homepage", isHomepageNode, extractHomepageNode)
+}
+
+// BuildURLNodeMatcher returns a NodeMatcher for the URL field, including stable blocks.
+func BuildURLNodeMatcher() *parser.NodeMatcher[*types.Stable] {
+	return parser.NewNM[*types.Stable]("url", isURLNode, extractURLNode)
+}
+
+// BuildMirrorNodeMatcher returns a NodeMatcher for the mirror field.
+func BuildMirrorNodeMatcher() *parser.NodeMatcher[string] {
+	return parser.NewNM[string]("mirror", isMirrorNode, extractMirrorNode)
+}
+
+// BuildChecksumNodeMatcher returns a NodeMatcher for the sha256 field.
+func BuildChecksumNodeMatcher() *parser.NodeMatcher[string] {
+	return parser.NewNM[string]("sha256", isChecksumNode, extractChecksumNode)
+}
+
+// BuildLicenseNodeMatcher returns a NodeMatcher for the license field.
+func BuildLicenseNodeMatcher() *parser.NodeMatcher[string] {
+	return parser.NewNM[string]("license", isLicenseNode, extractLicenseNode)
+}
+
+// BuildHeadNodeMatcher returns a NodeMatcher for the head field.
+func BuildHeadNodeMatcher() *parser.NodeMatcher[*types.Head] {
+	return parser.NewNM[*types.Head]("head", isHeadNode, extractHeadNode)
+}
+
+// BuildDependencyNodeMatcher returns a NodeMatcher for the dependency fields.
+func BuildDependencyNodeMatcher() *parser.NodeMatcher[*types.Dependencies] {
+	return parser.NewNM[*types.Dependencies]("dependency", isDependencyNode, extractDependencyNodes)
+}
+
+// BuildConflictNodeMatcher returns a NodeMatcher for the conflicts_with fields.
+func BuildConflictNodeMatcher() *parser.NodeMatcher[[]*types.Conflict] {
+	return parser.NewNM[[]*types.Conflict]("conflict", isConflictNode, extractConflictNodes)
+}
+
+// BuildLinkOverwriteNodeMatcher returns a NodeMatcher for the link_overwrite fields.
+func BuildLinkOverwriteNodeMatcher() *parser.NodeMatcher[[]string] {
+	return parser.NewNM[[]string]("link_overwrite", isLinkOverwriteNode, extractLinkOverwriteNodes)
 }
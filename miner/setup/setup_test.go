@@ -0,0 +1,88 @@
+package setup
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"main/miner/parser"
+	"main/miner/ruby"
+)
+
+// readTestData returns the content of the formula files within the test-data directory.
+func readTestData(b *testing.B) [][]byte {
+	paths, err := filepath.Glob("../../test-data/*.rb")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	srcs := make([][]byte, 0, len(paths))
+	for _, path := range paths {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		srcs = append(srcs, src)
+	}
+	return srcs
+}
+
+func BenchmarkNodeStrategies(b *testing.B) {
+	srcs := readTestData(b)
+
+	// Parse the syntax trees upfront, such that only the strategies are measured.
+	classes := make([]*ruby.Node, 0, len(srcs))
+	for _, src := range srcs {
+		tree, err := ruby.Parse(string(src))
+		if err != nil {
+			b.Fatal(err)
+		}
+		classes = append(classes, tree.FormulaClass())
+	}
+
+	for i, s := range builtinNodeStrategies() {
+		b.Run(s.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				for _, class := range classes {
+					// Build a new strategy per file, as done by the reader.
+					strategy := builtinNodeStrategies()[i]
+
+					matched := make([]*ruby.Node, 0)
+					for _, node := range class.Body {
+						if strategy.MatchesNode(node) {
+							matched = append(matched, node)
+						}
+					}
+					if len(matched) == 0 {
+						continue
+					}
+					if _, err := strategy.ExtractFromNodes(matched); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkStrategies(b *testing.B) {
+	srcs := readTestData(b)
+
+	for i, s := range builtinStrategies(parser.FormulaParser{}) {
+		b.Run(s.Name(), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				for _, src := range srcs {
+					fp := &parser.FormulaParser{Scanner: bufio.NewScanner(bytes.NewReader(src))}
+					strategy := builtinStrategies(*fp)[i]
+					if _, err := fp.ParseFields([]parser.ParseStrategy{strategy}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
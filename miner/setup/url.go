@@ -3,9 +3,10 @@ package setup
 import (
 	"fmt"
 	"path"
-	"regexp"
 	"strings"
 
+	"main/miner/pattern"
+	"main/miner/ruby"
 	"main/miner/types"
 )
 
@@ -16,11 +17,21 @@ func cleanURLSequence(sequence []string) *types.Stable {
 	}
 
 	stable := &types.Stable{}
+
+	// Check for the checksum.
+	for i := range sequence {
+		regex := pattern.Get(stableChecksumPattern)
+		if checksumMatches := regex.FindStringSubmatch(sequence[i]); len(checksumMatches) >= 2 {
+			stable.Checksum = checksumMatches[1]
+			break
+		}
+	}
+
 	var index int
 	for i := range sequence {
 		// Check for the URL.
 		if stable.URL == "" {
-			regex := regexp.MustCompile(stableUrlPattern)
+			regex := pattern.Get(stableUrlPattern)
 			urlMatches := regex.FindStringSubmatch(sequence[i])
 			if len(urlMatches) >= 3 {
 				stable.URL = urlMatches[1]
@@ -33,7 +44,7 @@ func cleanURLSequence(sequence []string) *types.Stable {
 		}
 
 		// Check for the tag.
-		regex := regexp.MustCompile(tagPattern)
+		regex := pattern.Get(tagPattern)
 		tagMatches := regex.FindStringSubmatch(sequence[i])
 		if len(tagMatches) >= 2 {
 			stable.URL = formatURL(stable.URL, tagMatches[1])
@@ -68,7 +79,10 @@ func formatURL(url, tag string) string {
 // The function returns a boolean indicating if the line matches the default pattern,
 // and a slice containing the matches if the line matches the default pattern.
 func isDefaultURLPattern(line string) (bool, []string) {
-	regex := regexp.MustCompile(urlPattern)
+	if !strings.Contains(line, "url") {
+		return false, nil
+	}
+	regex := pattern.Get(urlPattern)
 	matches := regex.FindStringSubmatch(line)
 	if len(matches) < 2 {
 		return false, nil
@@ -81,7 +95,7 @@ func isDefaultURLPattern(line string) (bool, []string) {
 	}
 
 	// Check for tag within the same line.
-	regex = regexp.MustCompile(tagExtractPattern)
+	regex = pattern.Get(tagExtractPattern)
 	if tagMatches := regex.FindStringSubmatch(rem); len(tagMatches) >= 2 {
 		return true, []string{
 			fmt.Sprintf("%s, %s", matches[0], tagMatches[0]),
@@ -99,14 +113,17 @@ func isDefaultURLPattern(line string) (bool, []string) {
 // isBeginStableURLSequence returns true if the given line
 // is the beginning of a stable block sequence.
 func isBeginURLSequence(line string) bool {
-	match, _ := regexp.MatchString(urlBeginPattern, line)
+	if !strings.Contains(line, "url") {
+		return false
+	}
+	match := pattern.Get(urlBeginPattern).MatchString(line)
 	return match && !(strings.Contains(line, "tag:") || strings.Contains(line, "using:") || strings.Contains(line, "revision:"))
 }
 
 // isEndURLSequence returns true if the given line
 // is the end of a URL sequence.
 func isEndURLSequence(line string) bool {
-	revMatch, _ := regexp.MatchString(revisionPattern, line)
+	revMatch := pattern.Get(revisionPattern).MatchString(line)
 	return revMatch
 }
 
@@ -119,13 +136,68 @@ func isDefaultStableURLPattern(line string) (bool, []string) {
 // isBeginStableURLSequence returns true if the given line
 // is the beginning of a stable block sequence.
 func isBeginStableURLSequence(line string) bool {
-	match, _ := regexp.MatchString(stableUrlBeginPattern, line)
+	match := pattern.Get(stableUrlBeginPattern).MatchString(line)
 	return match
 }
 
 // isEndStableURLSequence returns true if the given line
 // is the end of a stable block sequence.
 func isEndStableURLSequence(line string) bool {
-	match, _ := regexp.MatchString(endPattern(2), line)
+	match := pattern.Get(endPattern(2)).MatchString(line)
 	return match
 }
+
+// isURLNode returns true if the given statement is a url call or a stable block.
+func isURLNode(node *ruby.Node) bool {
+	return node.IsCall("url", "stable")
+}
+
+// extractURLNode returns the stable version of the first url call or stable block.
+// The checksum and dependencies of a stable block are extracted as well.
+func extractURLNode(nodes []*ruby.Node) (*types.Stable, error) {
+	node := nodes[0]
+	if node.Name == "url" {
+		url, err := getURL(node)
+		if err != nil {
+			return nil, err
+		}
+		return &types.Stable{URL: url}, nil
+	}
+
+	stable := &types.Stable{}
+	rest := make([]*ruby.Node, 0)
+	for _, stmt := range node.Body {
+		switch {
+		case stmt.IsCall("url") && stable.URL == "":
+			url, err := getURL(stmt)
+			if err != nil {
+				return nil, err
+			}
+			stable.URL = url
+		case stmt.IsCall("sha256") && stable.Checksum == "":
+			checksum, err := getStringArg(stmt)
+			if err != nil {
+				return nil, err
+			}
+			stable.Checksum = checksum
+		default:
+			rest = append(rest, stmt)
+		}
+	}
+
+	stable.Dependencies = extractDepNodes(rest)
+	return stable, nil
+}
+
+// getURL returns the URL of the given url call.
+// If a tag is specified, it's joined with the URL.
+func getURL(node *ruby.Node) (string, error) {
+	url, err := getStringArg(node)
+	if err != nil {
+		return "", err
+	}
+	if tag := node.Options().Get("tag"); tag != nil && tag.Kind == ruby.StringValue {
+		url = formatURL(url, tag.Text)
+	}
+	return url, nil
+}
@@ -0,0 +1,127 @@
+package snapshot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"main/miner/types"
+)
+
+// Load reads the formulae from the output file at the given path.
+// Files with the extension ".json" are read as JSON output, any other file as TSV output.
+func Load(path string) (map[string]*types.Formula, error) {
+	if filepath.Ext(path) == ".json" {
+		return LoadJSON(path)
+	}
+	return LoadTSV(path)
+}
+
+// LoadJSON reads the formulae from the JSON output file at the given path.
+func LoadJSON(path string) (map[string]*types.Formula, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	formulae := make(map[string]*types.Formula)
+	if err := json.Unmarshal(content, &formulae); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return formulae, nil
+}
+
+// LoadTSV reads the formulae from the TSV output file at the given path.
+// Package lines are read into formulae, and dependency and conflict lines into the dependencies
+// and conflicts of the preceding formula.
+func LoadTSV(path string) (map[string]*types.Formula, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	formulae := make(map[string]*types.Formula)
+	var current *types.Formula
+
+	scanner := bufio.NewScanner(file)
+	// Package lines may hold many archives.
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if scanner.Text() == "" {
+			continue
+		}
+
+		values := strings.Split(scanner.Text(), "\t")
+		for i := range values {
+			values[i] = strings.Trim(values[i], "\"")
+		}
+
+		switch values[0] {
+		case "0":
+			// `0  "brew"  "<name>"  "<license>"  "<repo_url>"  "<archives>"  "<system_requirement>"  "<purl>"  "<upstream_purl>"  "<license_category>"  "<version>"  "<homepage>"  "<archive_checksums>"`
+			// The trailing columns following the system requirement are missing in the output of older versions.
+			if len(values) < 7 {
+				return nil, fmt.Errorf("%s:%d: invalid package line", path, lineNo)
+			}
+			current = &types.Formula{
+				Name:              values[2],
+				License:           values[3],
+				RepoURL:           values[4],
+				Dependencies:      make([]*types.Dependency, 0),
+				SystemRequirement: values[6],
+			}
+			if len(values) >= 9 {
+				current.PURL, current.UpstreamPURL = values[7], values[8]
+			}
+			if len(values) >= 10 {
+				current.LicenseCategory = values[9]
+			}
+			checksums := ""
+			if len(values) >= 13 {
+				current.Version, current.Homepage, checksums = values[10], values[11], values[12]
+			}
+			current.ArchiveURL = types.ParseArchives(values[5], checksums)
+			formulae[current.Name] = current
+		case "1":
+			// `1  "brew"  "<name>"  "<license>"  "<type>"  "<restriction>"`
+			if len(values) < 6 || current == nil {
+				return nil, fmt.Errorf("%s:%d: invalid dependency line", path, lineNo)
+			}
+			current.Dependencies = append(current.Dependencies, &types.Dependency{
+				Name:        values[2],
+				DepType:     parseDepType(values[4]),
+				Restriction: values[5],
+			})
+		case "2":
+			// `2  "brew"  "<name>"  "<license>"  "conflict"  "<reason>"`
+			if len(values) < 6 || current == nil {
+				return nil, fmt.Errorf("%s:%d: invalid conflict line", path, lineNo)
+			}
+			current.Conflicts = append(current.Conflicts, &types.Conflict{
+				Name:   values[2],
+				Reason: values[5],
+			})
+		default:
+			return nil, fmt.Errorf("%s:%d: unknown line type %s", path, lineNo, values[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return formulae, nil
+}
+
+// parseDepType returns the dependency types of the given type column.
+// Runtime dependencies have no type.
+func parseDepType(s string) []string {
+	if s == "runtime" || s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ", ")
+}
@@ -0,0 +1,73 @@
+package snapshot
+
+import (
+	"log"
+	"path/filepath"
+	"testing"
+
+	"main/miner/types"
+	"main/miner/writer"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var formulae = map[string]*types.Formula{
+	"foo": {Name: "foo", Version: "1.0", Homepage: "https://example.com/foo", ArchiveURL: []*types.Archive{
+		{URL: "https://example.com/foo-1.0-darwin.tar.gz", Checksum: "0123", Restriction: "macos: >= sonoma, arm"},
+		{URL: "https://example.com/foo-1.0-linux.tar.gz", Restriction: "linux"},
+		{URL: "https://example.com/foo-1.0.tar.gz", Checksum: "4567"},
+	}, License: "MIT", LicenseCategory: "permissive", RepoURL: "https://github.com/example/foo.git", SystemRequirement: "linux", PURL: "pkg:brew/foo@1.0", UpstreamPURL: "pkg:github/example/foo@v1.0", Dependencies: []*types.Dependency{
+		{Name: "bar", DepType: []string{}},
+		{Name: "baz", DepType: []string{"build", "test"}},
+		{Name: "zlib", DepType: []string{}, Restriction: "linux"},
+	}},
+	"bar": {Name: "bar", License: "MIT", Dependencies: []*types.Dependency{}, Conflicts: []*types.Conflict{
+		{Name: "baz", Reason: "both install a bar binary"},
+		{Name: "zlib", Reason: ""},
+	}},
+	"baz":  {Name: "baz", License: "Apache-2.0", Dependencies: []*types.Dependency{}},
+	"zlib": {Name: "zlib", License: "Zlib", Dependencies: []*types.Dependency{}},
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	if err := writer.WriteFormulae(dir, formulae, false); err != nil {
+		log.Fatal(err)
+	}
+	if err := writer.WriteJSON(dir, formulae); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, pattern := range []string{"deps-brew-*.tsv", "deps-brew-*.json"} {
+		paths, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil || len(paths) != 1 {
+			log.Fatalf("expected a single output file, got %v: %v", paths, err)
+		}
+
+		loaded, err := Load(paths[0])
+		if err != nil {
+			log.Fatal(err)
+		}
+		assert.Len(t, loaded, len(formulae))
+		for name, f := range formulae {
+			if assert.Contains(t, loaded, name) {
+				assert.Equal(t, f.License, loaded[name].License, "license of %s read from %s", name, paths[0])
+				assert.Equal(t, f.RepoURL, loaded[name].RepoURL, "repo of %s read from %s", name, paths[0])
+				assert.Equal(t, f.SystemRequirement, loaded[name].SystemRequirement, "system requirement of %s read from %s", name, paths[0])
+				assert.Equal(t, f.PURL, loaded[name].PURL, "purl of %s read from %s", name, paths[0])
+				assert.Equal(t, f.UpstreamPURL, loaded[name].UpstreamPURL, "upstream purl of %s read from %s", name, paths[0])
+				assert.Equal(t, f.LicenseCategory, loaded[name].LicenseCategory, "license category of %s read from %s", name, paths[0])
+				assert.Equal(t, f.Version, loaded[name].Version, "version of %s read from %s", name, paths[0])
+				assert.Equal(t, f.Homepage, loaded[name].Homepage, "homepage of %s read from %s", name, paths[0])
+				assert.Equal(t, f.ArchiveURL, loaded[name].ArchiveURL, "archives of %s read from %s", name, paths[0])
+				assert.Equal(t, f.Dependencies, loaded[name].Dependencies, "dependencies of %s read from %s", name, paths[0])
+				assert.Equal(t, f.Conflicts, loaded[name].Conflicts, "conflicts of %s read from %s", name, paths[0])
+			}
+		}
+	}
+}
+
+func TestLoadTSVInvalid(t *testing.T) {
+	_, err := LoadTSV("../../test-data/geckodriver.rb")
+	assert.Error(t, err)
+}
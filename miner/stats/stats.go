@@ -0,0 +1,245 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"main/miner/forge"
+	"main/miner/license"
+	"main/miner/types"
+)
+
+// DefaultTop is the default number of most depended-upon formulae listed by a summary.
+const DefaultTop = 10
+
+// Summary holds summary statistics of mined formulae.
+type Summary struct {
+	// Formulae is the number of formulae.
+	Formulae int `json:"formulae"`
+
+	// Dependencies is the number of dependency edges.
+	Dependencies int `json:"dependencies"`
+
+	// FallbackLicenses is the number of formulae with the fallback license.
+	FallbackLicenses int `json:"fallback_licenses"`
+
+	// WithoutRepo is the number of formulae without a repository URL.
+	WithoutRepo int `json:"without_repo"`
+
+	// DependencyTypes counts the dependency edges by type, where an edge with multiple types is counted once per type.
+	DependencyTypes map[string]int `json:"dependency_types"`
+
+	// Licenses counts the formulae by license.
+	Licenses map[string]int `json:"licenses"`
+
+	// LicenseCategories counts the formulae by license category.
+	LicenseCategories map[string]int `json:"license_categories"`
+
+	// RepoHosts counts the formulae with a repository URL by the domain of the URL.
+	RepoHosts map[string]int `json:"repo_hosts"`
+
+	// SystemRequirements counts the formulae by system requirement, where a formula with multiple requirements
+	// is counted once per requirement.
+	SystemRequirements map[string]int `json:"system_requirements"`
+
+	// MostDependedUpon lists the formulae with the most dependents, the most depended-upon first.
+	MostDependedUpon []*Count `json:"most_depended_upon"`
+}
+
+// Count is a counted value.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Collector collects the summary statistics of formulae added one by one, such that the formulae don't need to be
+// held in memory at once. A collector must not be used concurrently.
+type Collector struct {
+	summary         *Summary
+	fallbackLicense string
+	top             int
+	categories      license.Categories
+
+	// dependents counts the formulae depending on each formula, where the key is the name of the dependency.
+	dependents map[string]int
+}
+
+// NewCollector creates a collector counting the formulae with the given fallback license
+// and listing the given number of most depended-upon formulae.
+func NewCollector(fallbackLicense string, top int) *Collector {
+	return &Collector{
+		summary: &Summary{
+			DependencyTypes:    make(map[string]int),
+			Licenses:           make(map[string]int),
+			LicenseCategories:  make(map[string]int),
+			RepoHosts:          make(map[string]int),
+			SystemRequirements: make(map[string]int),
+		},
+		fallbackLicense: fallbackLicense,
+		top:             top,
+		dependents:      make(map[string]int),
+	}
+}
+
+// Compute returns the summary statistics of the given formulae, see NewCollector.
+func Compute(formulae map[string]*types.Formula, fallbackLicense string, top int) *Summary {
+	c := NewCollector(fallbackLicense, top)
+	for _, f := range formulae {
+		c.Add(f)
+	}
+	return c.Summary()
+}
+
+// Add adds the given formula to the statistics.
+// Formulae without a license category, e.g. read from the output files of older versions, are classified by the
+// default license categories.
+func (c *Collector) Add(f *types.Formula) {
+	s := c.summary
+	s.Formulae++
+
+	s.Licenses[f.License]++
+	if f.License == c.fallbackLicense && c.fallbackLicense != "" {
+		s.FallbackLicenses++
+	}
+	category := f.LicenseCategory
+	if category == "" {
+		if c.categories == nil {
+			c.categories = license.DefaultCategories()
+		}
+		category = c.categories.Classify(f.License)
+	}
+	s.LicenseCategories[category]++
+
+	if f.RepoURL == "" {
+		s.WithoutRepo++
+	} else if host := forge.Domain(f.RepoURL); host != "" {
+		s.RepoHosts[host]++
+	}
+
+	if f.SystemRequirement != "" {
+		for _, r := range strings.Split(f.SystemRequirement, ",") {
+			s.SystemRequirements[strings.TrimSpace(r)]++
+		}
+	}
+
+	// A formula depending on another formula multiple times, e.g. with different restrictions, is counted once.
+	seen := make(map[string]bool)
+	for _, dep := range f.Dependencies {
+		s.Dependencies++
+		if len(dep.DepType) == 0 {
+			s.DependencyTypes["runtime"]++
+		}
+		for _, t := range dep.DepType {
+			s.DependencyTypes[t]++
+		}
+		if !seen[dep.Name] {
+			seen[dep.Name] = true
+			c.dependents[dep.Name]++
+		}
+	}
+}
+
+// Summary returns the summary statistics of the formulae added so far.
+func (c *Collector) Summary() *Summary {
+	most := sortCounts(c.dependents)
+	if len(most) > c.top {
+		most = most[:c.top]
+	}
+	c.summary.MostDependedUpon = most
+	return c.summary
+}
+
+// sortCounts returns the given counts, the most frequent ones first.
+func sortCounts(counts map[string]int) []*Count {
+	sorted := make([]*Count, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, &Count{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// section is a titled list of counts of a summary.
+type section struct {
+	title  string
+	header string
+	counts []*Count
+}
+
+// sections returns the titled lists of counts of the summary, in the order they are written.
+func (s *Summary) sections() []section {
+	return []section{
+		{"Dependency types", "Type", sortCounts(s.DependencyTypes)},
+		{"Licenses", "License", sortCounts(s.Licenses)},
+		{"License categories", "Category", sortCounts(s.LicenseCategories)},
+		{"Repository hosts", "Host", sortCounts(s.RepoHosts)},
+		{"System requirements", "Requirement", sortCounts(s.SystemRequirements)},
+		{"Most depended-upon formulae", "Formula", s.MostDependedUpon},
+	}
+}
+
+// totals returns the labeled totals of the summary.
+func (s *Summary) totals() []*Count {
+	return []*Count{
+		{"Formulae", s.Formulae},
+		{"Dependencies", s.Dependencies},
+		{"Formulae with the fallback license", s.FallbackLicenses},
+		{"Formulae without repository URL", s.WithoutRepo},
+	}
+}
+
+// WriteJSON writes the summary as JSON.
+func (s *Summary) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// WriteText writes the summary in a human-readable form.
+func (s *Summary) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+	for _, t := range s.totals() {
+		fmt.Fprintf(b, "%s: %d\n", t.Name, t.Count)
+	}
+	for _, sec := range s.sections() {
+		fmt.Fprintf(b, "\n%s:\n", sec.title)
+		for _, c := range sec.counts {
+			fmt.Fprintf(b, "%8d\t%s\n", c.Count, c.Name)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the summary as a Markdown document with a table per list of counts.
+func (s *Summary) WriteMarkdown(w io.Writer) error {
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "# Summary\n\n| Metric | Count |\n|--------|------:|\n")
+	for _, t := range s.totals() {
+		fmt.Fprintf(b, "| %s | %d |\n", t.Name, t.Count)
+	}
+	for _, sec := range s.sections() {
+		fmt.Fprintf(b, "\n## %s\n\n| %s | Count |\n|---|------:|\n", sec.title, sec.header)
+		for _, c := range sec.counts {
+			fmt.Fprintf(b, "| %s | %d |\n", markdownEscape(c.Name), c.Count)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownEscape escapes the characters of the given value which would break a Markdown table cell.
+func markdownEscape(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ").Replace(s)
+}
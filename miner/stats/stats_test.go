@@ -0,0 +1,86 @@
+package stats
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"main/miner/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var formulae = map[string]*types.Formula{
+	"foo": {Name: "foo", License: "MIT", LicenseCategory: "permissive", RepoURL: "https://github.com/example/foo.git", SystemRequirement: "macos, linux", Dependencies: []*types.Dependency{
+		{Name: "bar", DepType: []string{}},
+		{Name: "baz", DepType: []string{"build", "test"}},
+		{Name: "zlib", DepType: []string{}, Restriction: "linux"},
+		{Name: "zlib", DepType: []string{"build"}, Restriction: "macos"},
+	}},
+	"bar":  {Name: "bar", License: "pseudo", Dependencies: []*types.Dependency{{Name: "zlib"}}},
+	"baz":  {Name: "baz", License: "GPL-3.0-only", RepoURL: "https://gitlab.com/example/baz.git", SystemRequirement: "linux"},
+	"zlib": {Name: "zlib", License: "Zlib", RepoURL: "https://github.com/madler/zlib.git"},
+}
+
+func TestCompute(t *testing.T) {
+	s := Compute(formulae, "pseudo", 2)
+
+	assert.Equal(t, 4, s.Formulae)
+	assert.Equal(t, 5, s.Dependencies)
+	assert.Equal(t, 1, s.FallbackLicenses)
+	assert.Equal(t, 1, s.WithoutRepo)
+	assert.Equal(t, map[string]int{"runtime": 3, "build": 2, "test": 1}, s.DependencyTypes)
+	assert.Equal(t, map[string]int{"permissive": 2, "strong-copyleft": 1, "unknown": 1}, s.LicenseCategories)
+	assert.Equal(t, map[string]int{"github.com": 2, "gitlab.com": 1}, s.RepoHosts)
+	assert.Equal(t, map[string]int{"linux": 2, "macos": 1}, s.SystemRequirements)
+	assert.Equal(t, []*Count{{"zlib", 2}, {"bar", 1}}, s.MostDependedUpon)
+}
+
+func TestWrite(t *testing.T) {
+	s := Compute(map[string]*types.Formula{
+		"foo": {Name: "foo", License: "MIT or Apache-2.0", Dependencies: []*types.Dependency{{Name: "bar"}}},
+		"bar": {Name: "bar", License: "pseudo", RepoURL: "https://github.com/example/bar.git"},
+	}, "pseudo", DefaultTop)
+
+	var text bytes.Buffer
+	if err := s.WriteText(&text); err != nil {
+		log.Fatal(err)
+	}
+	assert.Equal(t, `Formulae: 2
+Dependencies: 1
+Formulae with the fallback license: 1
+Formulae without repository URL: 1
+
+Dependency types:
+       1	runtime
+
+Licenses:
+       1	MIT or Apache-2.0
+       1	pseudo
+
+License categories:
+       1	permissive
+       1	unknown
+
+Repository hosts:
+       1	github.com
+
+System requirements:
+
+Most depended-upon formulae:
+       1	bar
+`, text.String())
+
+	var markdown bytes.Buffer
+	if err := s.WriteMarkdown(&markdown); err != nil {
+		log.Fatal(err)
+	}
+	assert.Contains(t, markdown.String(), "# Summary\n\n| Metric | Count |\n|--------|------:|\n| Formulae | 2 |\n")
+	assert.Contains(t, markdown.String(), "\n## Licenses\n\n| License | Count |\n|---|------:|\n| MIT or Apache-2.0 | 1 |\n| pseudo | 1 |\n")
+
+	var j bytes.Buffer
+	if err := s.WriteJSON(&j); err != nil {
+		log.Fatal(err)
+	}
+	assert.Contains(t, j.String(), `"most_depended_upon": [`)
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Archive represents a stable archive of a formula.
+// Formulae shipping binaries may define a separate archive per platform.
+type Archive struct {
+	// URL of the archive.
+	URL string `json:"url"`
+
+	// SHA256 checksum of the archive.
+	Checksum string `json:"sha256"`
+
+	// (System) restriction for the archive.
+	Restriction string `json:"restriction"`
+}
+
+func (a *Archive) String() string {
+	return fmt.Sprintf("{%s %s %s}", a.URL, a.Checksum, a.Restriction)
+}
+
+// formatArchives formats the given archives as a comma separated list.
+// The restriction of an archive is appended to its URL in parentheses.
+func formatArchives(archives []*Archive) string {
+	res := make([]string, 0, len(archives))
+	for _, a := range archives {
+		if a.Restriction == "" {
+			res = append(res, a.URL)
+			continue
+		}
+		res = append(res, fmt.Sprintf("%s (%s)", a.URL, a.Restriction))
+	}
+	return strings.Join(res, ", ")
+}
+
+// formatChecksums formats the checksums of the given archives as a comma separated list,
+// in the order of the archives formatted by formatArchives.
+func formatChecksums(archives []*Archive) string {
+	res := make([]string, 0, len(archives))
+	for _, a := range archives {
+		res = append(res, a.Checksum)
+	}
+	return strings.Join(res, ", ")
+}
+
+// ParseArchives parses the archives formatted by formatArchives, along with their checksums formatted by formatChecksums.
+// Checksums are missing in the output of older versions, in which case the archives have no checksum.
+// It returns nil if there are no archives.
+func ParseArchives(archives, checksums string) []*Archive {
+	if archives == "" {
+		return nil
+	}
+
+	sums := strings.Split(checksums, ", ")
+	res := make([]*Archive, 0)
+	for i, entry := range splitArchives(archives) {
+		// URLs don't contain spaces, thus the restriction starts at the first space.
+		url, restriction, _ := strings.Cut(entry, " ")
+		a := &Archive{URL: url, Restriction: strings.TrimSuffix(strings.TrimPrefix(restriction, "("), ")")}
+		if i < len(sums) {
+			a.Checksum = sums[i]
+		}
+		res = append(res, a)
+	}
+	return res
+}
+
+// splitArchives splits the archives formatted by formatArchives into the formatted archives.
+// Commas within the parentheses of a restriction don't separate archives.
+func splitArchives(s string) []string {
+	res := make([]string, 0)
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '(':
+			depth++
+		case s[i] == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], ", "):
+			res = append(res, s[start:i])
+			start = i + 2
+			i++
+		}
+	}
+	return append(res, s[start:])
+}
@@ -0,0 +1,16 @@
+package types
+
+import "fmt"
+
+// Conflict represents a formula which can't be installed alongside another formula.
+type Conflict struct {
+	// Name of the conflicting formula.
+	Name string `json:"name"`
+
+	// Reason of the conflict.
+	Reason string `json:"reason"`
+}
+
+func (c *Conflict) String() string {
+	return fmt.Sprintf("{%s %s}", c.Name, c.Reason)
+}
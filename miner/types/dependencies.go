@@ -3,7 +3,7 @@ package types
 import "fmt"
 
 // Dependecies represents a formula's dependencies.
-// This struct is used to frther store the formula's system requirements,
+// This struct is used to frther store the formula's system requirements and platform specific archives,
 // since these are defined in the same section of a formula's definiton.
 type Dependencies struct {
 	// List of dependencies.
@@ -11,8 +11,11 @@ type Dependencies struct {
 
 	// Formula's system requirements.
 	SystemRequirements string
+
+	// Formula's platform specific archives.
+	Archives []*Archive
 }
 
 func (d *Dependencies) String() string {
-	return fmt.Sprintf("{%v\n, SystemRequirements: %s}", d.Lst, d.SystemRequirements)
+	return fmt.Sprintf("{%v\n, SystemRequirements: %s, Archives: %v}", d.Lst, d.SystemRequirements, d.Archives)
 }
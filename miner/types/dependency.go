@@ -1,19 +1,25 @@
 package types
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
+	"strings"
 )
 
 // Dependency represents a dependency of a formula.
 type Dependency struct {
 	// Name of the dependency.
-	Name string
+	Name string `json:"name"`
 
 	// DepType is the type of the dependency.
-	DepType []string
+	DepType []string `json:"types"`
 
 	// (System) restirction for the dependency.
-	Restriction string
+	Restriction string `json:"restriction"`
+
+	// Source of the dependency within the formula file.
+	Source *Source `json:"-"`
 }
 
 func (d *Dependency) String() string {
@@ -23,3 +29,16 @@ func (d *Dependency) String() string {
 func (d *Dependency) Id() string {
 	return fmt.Sprintf("%s,%s", d.Name, d.DepType)
 }
+
+// SortDependencies sorts the given dependencies by their name, type and restriction.
+func SortDependencies(deps []*Dependency) {
+	slices.SortStableFunc(deps, func(a, b *Dependency) int {
+		if c := cmp.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(strings.Join(a.DepType, ","), strings.Join(b.DepType, ",")); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Restriction, b.Restriction)
+	})
+}
@@ -3,37 +3,67 @@ package types
 import (
 	"fmt"
 	"strings"
+
+	"main/miner/purl"
 )
 
 // Formula represents a formula from the brew package manager.
 type Formula struct {
 	// Name of the formula.
-	Name string
+	Name string `json:"name"`
+
+	// Version of the formula.
+	Version string `json:"version"`
+
+	// Homepage of the formula.
+	Homepage string `json:"homepage"`
 
 	// Repository URL of the formula.
-	RepoURL string
+	RepoURL string `json:"repo_url"`
+
+	// Package URL of the formula, e.g. "pkg:brew/foo@1.0".
+	PURL string `json:"purl"`
 
-	// Archive URL of the formula.
-	ArchiveURL string
+	// Package URL of the formula's upstream source, e.g. "pkg:github/owner/repo@v1.0", if any.
+	UpstreamPURL string `json:"upstream_purl"`
+
+	// Archives of the formula's stable version.
+	ArchiveURL []*Archive `json:"archives"`
 
 	// License of the formula.
-	License string
+	License string `json:"license"`
+
+	// Category of the formula's license, e.g. "permissive" or "strong-copyleft".
+	LicenseCategory string `json:"license_category"`
 
 	// A list of the formula's dependencies.
-	Dependencies []*Dependency
+	Dependencies []*Dependency `json:"dependencies"`
 
 	// System requirement of the formula.
-	SystemRequirement string
+	SystemRequirement string `json:"system_requirement"`
+
+	// A list of the formulae conflicting with the formula.
+	Conflicts []*Conflict `json:"conflicts"`
+
+	// A list of paths the formula is allowed to overwrite when linking.
+	LinkOverwrite []string `json:"link_overwrite"`
+
+	// Provenance of the values extracted from the formula file.
+	// It is written to a separate output file.
+	Provenance []*Provenance `json:"-"`
+
+	// Values of the custom fields, where the key is the name of the field.
+	Extra map[string]any `json:"extra,omitempty"`
 }
 
 func (f *Formula) String() string {
-	return fmt.Sprintf("%s\nRepo: %s\nArchive: %s\nLicense: %s\nDependencies: %v\nSystemRequirements: %s\n", f.Name, f.RepoURL, f.ArchiveURL, f.License, f.Dependencies, f.SystemRequirement)
+	return fmt.Sprintf("%s\nVersion: %s\nHomepage: %s\nRepo: %s\nPURL: %s\nUpstreamPURL: %s\nArchive: %s\nLicense: %s\nLicenseCategory: %s\nDependencies: %v\nSystemRequirements: %s\nConflicts: %v\nLinkOverwrite: %v\n", f.Name, f.Version, f.Homepage, f.RepoURL, f.PURL, f.UpstreamPURL, f.ArchiveURL, f.License, f.LicenseCategory, f.Dependencies, f.SystemRequirement, f.Conflicts, f.LinkOverwrite)
 }
 
 // FormatPackageLine formats the formula as a package line.
-// `0,"<package_manager>","<name>","<license>","<namespace>/<username>/<repository>","<stable_archive_url>","<system_requirement>"`
+// `0,"<package_manager>","<name>","<license>","<namespace>/<username>/<repository>","<stable_archive_url>","<system_requirement>","<purl>","<upstream_purl>","<license_category>","<version>","<homepage>","<stable_archive_sha256>"`
 func (f *Formula) FormatPackageLine() string {
-	return fmt.Sprintf("0\t\"brew\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\n", f.Name, f.License, f.RepoURL, f.ArchiveURL, f.SystemRequirement)
+	return fmt.Sprintf("0\t\"brew\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\n", f.Name, f.License, f.RepoURL, formatArchives(f.ArchiveURL), f.SystemRequirement, f.PURL, f.UpstreamPURL, f.LicenseCategory, f.Version, f.Homepage, formatChecksums(f.ArchiveURL))
 }
 
 // FormatDependencyLine formats the formula as a dependency line.
@@ -48,12 +78,24 @@ func (f *Formula) FormatDependencyLine(dep *Dependency) string {
 	return fmt.Sprintf("1\t\"brew\"\t\"%s\"\t\"%s\"\t\"%s\"\t\"%s\"\n", dep.Name, f.License, depType, dep.Restriction)
 }
 
+// FormatConflictLine formats the formula as a conflict line.
+// `2,"<package_manager>","<name>","<license>","conflict","<reason>"`
+func (f *Formula) FormatConflictLine(c *Conflict) string {
+	return fmt.Sprintf("2\t\"brew\"\t\"%s\"\t\"%s\"\t\"conflict\"\t\"%s\"\n", c.Name, f.License, c.Reason)
+}
+
 // fromSourceFormula creates a formula from a source formula and evaluates the reopURL.
 // It returns a pointer to the newly created formula.
 func FromSourceFormula(sf *SourceFormula, fallbackLicense string, deriveRepo bool) *Formula {
 	f := &Formula{
-		Name:       sf.Name,
-		ArchiveURL: sf.Stable.URL,
+		Name:          sf.Name,
+		Version:       sf.Version,
+		Homepage:      sf.Homepage,
+		ArchiveURL:    sf.archives(),
+		Conflicts:     sf.Conflicts,
+		LinkOverwrite: sf.LinkOverwrite,
+		Provenance:    sf.provenance(),
+		Extra:         sf.Extra,
 	}
 
 	if sf.License == "" {
@@ -69,7 +111,7 @@ func FromSourceFormula(sf *SourceFormula, fallbackLicense string, deriveRepo boo
 		f.Dependencies = []*Dependency{}
 	}
 
-	if sf.Stable.Dependencies != nil {
+	if sf.Stable != nil && sf.Stable.Dependencies != nil {
 		f.Dependencies = append(f.Dependencies, sf.Stable.Dependencies.Lst...)
 
 		if sf.Stable.Dependencies.SystemRequirements != "" {
@@ -82,12 +124,24 @@ func FromSourceFormula(sf *SourceFormula, fallbackLicense string, deriveRepo boo
 		}
 	}
 
+	// Derived repository URLs are the clone URLs of git repositories.
+	vcs := "git"
 	if sf.Head == nil {
 		if deriveRepo {
 			f.RepoURL = sf.deriveRepoURL()
 		}
 	} else {
 		f.RepoURL = sf.Head.URL
+		vcs = sf.Head.VCS()
+	}
+
+	f.PURL = purl.Brew(f.Name, f.Version).String()
+	archiveURL := ""
+	if len(f.ArchiveURL) > 0 {
+		archiveURL = f.ArchiveURL[0].URL
+	}
+	if upstream := purl.Upstream(f.Name, f.Version, archiveURL, f.RepoURL, vcs); upstream != nil {
+		f.UpstreamPURL = upstream.String()
 	}
 
 	return f
@@ -2,13 +2,21 @@ package types
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 )
 
+// vcsStrategies are the download strategies of the using option, which check out a repository.
+var vcsStrategies = []string{"git", "hg", "svn", "bzr", "fossil", "cvs"}
+
 // Head represents the head of a formula.
 type Head struct {
 	// URL of the head.
 	URL string
 
+	// Download strategy declared by the using option of the head, e.g. "hg", if any.
+	Using string
+
 	// Dependencies of the head.
 	Dependencies []*Dependency
 }
@@ -16,3 +24,32 @@ type Head struct {
 func (h *Head) String() string {
 	return fmt.Sprintf("{%s, %s}", h.URL, h.Dependencies)
 }
+
+// VCS returns the version control system of the head, e.g. "git", "hg" or "svn",
+// or an empty string if the head isn't a repository or its version control system is unknown.
+// Without a using option, the version control system is detected from the URL like Homebrew does.
+func (h *Head) VCS() string {
+	if h.Using != "" {
+		if slices.Contains(vcsStrategies, h.Using) {
+			return h.Using
+		}
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(h.URL, "git://"), strings.HasSuffix(h.URL, ".git"), strings.HasPrefix(h.URL, "https://git.sr.ht/"):
+		return "git"
+	case strings.HasPrefix(h.URL, "svn://"), strings.HasPrefix(h.URL, "svn+"), strings.HasPrefix(h.URL, "https://svn."),
+		strings.HasPrefix(h.URL, "http://svn."):
+		return "svn"
+	case strings.HasPrefix(h.URL, "hg://"):
+		return "hg"
+	case strings.HasPrefix(h.URL, "bzr://"):
+		return "bzr"
+	case strings.HasPrefix(h.URL, "fossil://"):
+		return "fossil"
+	case strings.HasPrefix(h.URL, "cvs://"):
+		return "cvs"
+	}
+	return ""
+}
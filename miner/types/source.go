@@ -0,0 +1,60 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Source references the lines of a formula file a value has been extracted from.
+type Source struct {
+	// Path of the formula file.
+	Path string
+
+	// Line is the first line of the source, starting at 1.
+	Line int
+
+	// EndLine is the last line of the source.
+	EndLine int
+
+	// Raw text of the source lines.
+	Raw string
+}
+
+func (s *Source) String() string {
+	if s.Line == s.EndLine {
+		return fmt.Sprintf("%s:%d", s.Path, s.Line)
+	}
+	return fmt.Sprintf("%s:%d-%d", s.Path, s.Line, s.EndLine)
+}
+
+// find returns the source of the first line containing the given text, if any.
+// The raw text of the returned source is trimmed of surrounding whitespace.
+func (s *Source) find(text string) *Source {
+	for i, line := range strings.Split(s.Raw, "\n") {
+		if strings.Contains(line, text) {
+			return &Source{
+				Path:    s.Path,
+				Line:    s.Line + i,
+				EndLine: s.Line + i,
+				Raw:     strings.TrimSpace(line),
+			}
+		}
+	}
+	return nil
+}
+
+// Provenance pairs a value extracted from a formula file with its source.
+type Provenance struct {
+	// Field the value has been extracted for, e.g. "homepage" or "dependency".
+	Field string
+
+	// Value as extracted from the formula file.
+	Value string
+
+	// Source of the value.
+	Source *Source
+}
+
+func (p *Provenance) String() string {
+	return fmt.Sprintf("{%s %s %s}", p.Field, p.Value, p.Source)
+}
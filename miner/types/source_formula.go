@@ -2,9 +2,10 @@ package types
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
+	"main/miner/forge"
+	"main/miner/pattern"
 	"main/stack"
 )
 
@@ -16,6 +17,9 @@ type SourceFormula struct {
 	// Homepage of the formula.
 	Homepage string
 
+	// Version of the formula.
+	Version string
+
 	// Stable version of the formula.
 	Stable *Stable
 
@@ -30,42 +34,152 @@ type SourceFormula struct {
 
 	// Head of the formula.
 	Head *Head
+
+	// List of the formulae conflicting with the formula.
+	Conflicts []*Conflict
+
+	// List of paths the formula is allowed to overwrite when linking.
+	LinkOverwrite []string
+
+	// Sources of the formula's fields, where the key is the name of the field.
+	Sources map[string][]*Source
+
+	// Values of the custom fields, where the key is the name of the field.
+	Extra map[string]any
 }
 
 func (sf *SourceFormula) String() string {
-	return fmt.Sprintf("%s\nHomepage: %s\nStable: %s\nMirror: %s\nLicense: %s\nDependencies: %v\nHead: %v", sf.Name, sf.Homepage, sf.Stable, sf.Mirror, sf.License, sf.Dependencies, sf.Head)
+	return fmt.Sprintf("%s\nHomepage: %s\nVersion: %s\nStable: %s\nMirror: %s\nLicense: %s\nDependencies: %v\nHead: %v\nConflicts: %v\nLinkOverwrite: %v", sf.Name, sf.Homepage, sf.Version, sf.Stable, sf.Mirror, sf.License, sf.Dependencies, sf.Head, sf.Conflicts, sf.LinkOverwrite)
 }
 
-// deriveRepoURL attempts to derive the repository URL of the formula.
-// It therfore inspects the URL, mirror and homepage fields of the formula.
-func (sf *SourceFormula) deriveRepoURL() string {
-	var repoURL string
+// SetSources sets the sources of the formula's fields, which have been extracted from the file at the given path.
+// The source of each dependency is narrowed down to the line declaring it.
+func (sf *SourceFormula) SetSources(path string, sources map[string][]*Source) {
+	for _, spans := range sources {
+		for _, s := range spans {
+			s.Path = path
+		}
+	}
+	sf.Sources = sources
 
-	// Check homepage for known repository hosts.
-	if m, repoURL := matchesKnownGitRepoHost(sf.Homepage); m {
-		return repoURL
+	setDependencySources := func(deps []*Dependency, fields ...string) {
+		for _, dep := range deps {
+			for _, field := range fields {
+				if dep.Source = findSource(sources[field], `"`+dep.Name+`"`); dep.Source != nil {
+					break
+				}
+			}
+		}
 	}
+	if sf.Dependencies != nil {
+		setDependencySources(sf.Dependencies.Lst, "dependency")
+	}
+	if sf.Stable != nil && sf.Stable.Dependencies != nil {
+		setDependencySources(sf.Stable.Dependencies.Lst, "url")
+	}
+	if sf.Head != nil {
+		setDependencySources(sf.Head.Dependencies, "head")
+	}
+}
 
-	if sf.Stable != nil && sf.Stable.URL != "" {
-		repoURL = sf.Stable.URL
-	} else if sf.Mirror != "" {
-		repoURL = sf.Mirror
-	} else {
-		return ""
+// provenance returns the provenance of the formula's fields followed by the provenance of its dependencies.
+// A field matched at several places within the formula file has a provenance for each of its sources.
+func (sf *SourceFormula) provenance() []*Provenance {
+	values := make(map[string]string)
+	values["homepage"] = sf.Homepage
+	values["mirror"] = sf.Mirror
+	values["license"] = sf.License
+	if sf.Stable != nil {
+		values["url"] = sf.Stable.URL
+		values["sha256"] = sf.Stable.Checksum
+	}
+	if sf.Head != nil {
+		values["head"] = sf.Head.URL
+	}
+	names := make([]string, 0, len(sf.Conflicts))
+	for _, c := range sf.Conflicts {
+		names = append(names, c.Name)
 	}
+	values["conflict"] = strings.Join(names, ", ")
+	values["link_overwrite"] = strings.Join(sf.LinkOverwrite, ", ")
 
-	if m, cleandedURL := matchesKnownGitRepoHost(repoURL); m {
-		return cleandedURL
+	provenance := make([]*Provenance, 0)
+	for _, field := range []string{"homepage", "url", "sha256", "mirror", "license", "head", "conflict", "link_overwrite"} {
+		for _, s := range sf.Sources[field] {
+			provenance = append(provenance, &Provenance{Field: field, Value: values[field], Source: s})
+		}
 	}
 
-	if m, cleandedURL := matchesKnownGitArchiveHost(repoURL); m {
-		return cleandedURL
+	deps := make([]*Dependency, 0)
+	if sf.Dependencies != nil {
+		deps = append(deps, sf.Dependencies.Lst...)
+	}
+	if sf.Stable != nil && sf.Stable.Dependencies != nil {
+		deps = append(deps, sf.Stable.Dependencies.Lst...)
+	}
+	if sf.Head != nil {
+		deps = append(deps, sf.Head.Dependencies...)
+	}
+	for _, dep := range deps {
+		if dep.Source != nil {
+			provenance = append(provenance, &Provenance{Field: "dependency", Value: dep.Name, Source: dep.Source})
+		}
+	}
+	return provenance
+}
+
+// findSource returns the source of the first line containing the given text within the given sources.
+func findSource(sources []*Source, text string) *Source {
+	for _, s := range sources {
+		if found := s.find(text); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// archives returns all archives of the formula's stable version.
+// The archive of the stable URL is followed by the platform specific archives.
+func (sf *SourceFormula) archives() []*Archive {
+	archives := make([]*Archive, 0)
+	if sf.Stable != nil && sf.Stable.URL != "" {
+		archives = append(archives, &Archive{URL: sf.Stable.URL, Checksum: sf.Stable.Checksum})
+	}
+	if sf.Stable != nil && sf.Stable.Dependencies != nil {
+		archives = append(archives, sf.Stable.Dependencies.Archives...)
 	}
+	if sf.Dependencies != nil {
+		archives = append(archives, sf.Dependencies.Archives...)
+	}
+	return archives
+}
 
-	if strings.HasSuffix(repoURL, ".git") {
+// deriveRepoURL attempts to derive the repository URL of the formula.
+// It therfore inspects the URLs of all archives, the mirror and homepage fields of the formula.
+func (sf *SourceFormula) deriveRepoURL() string {
+	// Check homepage for known repository hosts.
+	if repoURL, ok := forge.RepoURL(sf.Homepage); ok {
 		return repoURL
 	}
 
+	repoURLs := make([]string, 0)
+	for _, a := range sf.archives() {
+		repoURLs = append(repoURLs, a.URL)
+	}
+	if len(repoURLs) == 0 && sf.Mirror != "" {
+		repoURLs = append(repoURLs, sf.Mirror)
+	}
+
+	for _, repoURL := range repoURLs {
+		if cleanedURL, ok := forge.ArchiveRepoURL(repoURL); ok {
+			return cleanedURL
+		}
+
+		if strings.HasSuffix(repoURL, ".git") {
+			return repoURL
+		}
+	}
+
 	return ""
 }
 
@@ -80,7 +194,7 @@ func (sf *SourceFormula) formatLicense() string {
 	license := r.Replace(sf.License)
 
 	// Remove license exception's curly brackets.
-	re := regexp.MustCompile(`=>{with:([-.\w]+)}`)
+	re := pattern.Get(`=>{with:([-.\w]+)}`)
 	license = re.ReplaceAllString(license, "=>with:$1")
 
 	// Remove unnecessary curly brackets.
@@ -179,67 +293,3 @@ func (sf *SourceFormula) formatLicense() string {
 	)
 	return r.Replace(string(result))
 }
-
-// Known hosts for repository extraction.
-const (
-	// githubRepoPattern matches the URL of a Github repository.
-	githubRepoPattern = `https://github.com/([a-zA-Z0-9_.-]+)\/([a-zA-Z0-9_.-]+)(/|\.git|\?.*)?$`
-
-	// gitlabRepoPattern matches the URL of a Gitlab repository.
-	gitlabRepoPattern = `https://gitlab.com/([a-zA-Z0-9_.-]+)\/([a-zA-Z0-9_.-]+)(/|\.git|\?.*)?$`
-
-	// bitbucketRepoPattern matches the URL of a Bitbucket repository.
-	bitbucketRepoPattern = `https://bitbucket.org/([a-zA-Z0-9_.-]+)\/([a-zA-Z0-9_.-]+)(/|\.git|\?.*)?$`
-
-	// repoPattern represents a general repo pattern that matches the URL of any repository.
-	repoPattern = `(https:\/\/[a-zA-Z0-9.-]+)\/([a-zA-Z0-9_.-]+)\/([a-zA-Z0-9_.-]+)`
-
-	// githubArchivePattern matches the URL of a Github archive.
-	githubArchivePattern = `(https://github.com/[a-zA-Z0-9_.-]+\/[a-zA-Z0-9_.-]+)\/(?:releases\/download|archive)\/.*`
-
-	// gitlabArchivePattern matches the URL of a Gitlab archive.
-	gitlabArchivePattern = `(https://gitlab.com/[a-zA-Z0-9_.-]+\/[a-zA-Z0-9_.-]+)\/(-\/archive|uploads)\/.*`
-
-	//bitbucketArchivePattern matches the URL of a Bitbucket archive.
-	bitbucketArchivePattern = `(https://bitbucket.org/[a-zA-Z0-9_.-]+\/[a-zA-Z0-9_.-]+)\/(downloads|get)\/.*`
-)
-
-// matchesKnownGitRepoHost checks if the given url matches a known git repository host pattern.
-// If true, it returns the matched repository url.
-func matchesKnownGitRepoHost(url string) (bool, string) {
-	githubRe := regexp.MustCompile(githubRepoPattern)
-	gitlabRe := regexp.MustCompile(gitlabRepoPattern)
-	bitBucketRe := regexp.MustCompile(bitbucketRepoPattern)
-
-	if !(githubRe.MatchString(url) || gitlabRe.MatchString(url) || bitBucketRe.MatchString(url)) {
-		return false, ""
-	}
-
-	matches := regexp.MustCompile(repoPattern).FindStringSubmatch(url)
-
-	return true, matches[0] + ".git"
-}
-
-// matchesKnownGitArchiveHost checks if the given url matches a known git archive host pattern.
-// If true, it returns the matched repository url.
-func matchesKnownGitArchiveHost(url string) (bool, string) {
-	githubRe := regexp.MustCompile(githubArchivePattern)
-	if githubRe.MatchString(url) {
-		matches := githubRe.FindStringSubmatch(url)
-		return true, matches[1] + ".git"
-	}
-
-	gitlabRe := regexp.MustCompile(gitlabArchivePattern)
-	if gitlabRe.MatchString(url) {
-		matches := gitlabRe.FindStringSubmatch(url)
-		return true, matches[1] + ".git"
-	}
-
-	bitbucketRe := regexp.MustCompile(bitbucketArchivePattern)
-	if bitbucketRe.MatchString(url) {
-		matches := bitbucketRe.FindStringSubmatch(url)
-		return true, matches[1] + ".git"
-	}
-
-	return false, ""
-}
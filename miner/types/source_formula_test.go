@@ -69,3 +69,114 @@ func TestFormatLicense(t *testing.T) {
 		}
 	}
 }
+
+var deriveRepoURLTests = []struct {
+	input    *SourceFormula
+	expected string
+}{
+	{
+		input: &SourceFormula{
+			Homepage: "https://srecord.sourceforge.net/",
+			Stable:   &Stable{URL: "https://downloads.sourceforge.net/project/srecord/srecord/1.64/srecord-1.64.tar.gz"},
+		},
+		expected: "",
+	},
+	{
+		input: &SourceFormula{
+			Homepage: "https://www.example.com/tool",
+			Stable:   &Stable{URL: "https://files.pythonhosted.org/packages/9d/be/tool-1.0.tar.gz"},
+		},
+		expected: "",
+	},
+	{
+		input: &SourceFormula{
+			Homepage: "https://example.org/sed",
+			Stable:   &Stable{URL: "https://ftp.gnu.org/gnu/sed/sed-4.9.tar.xz"},
+		},
+		expected: "",
+	},
+	{
+		input: &SourceFormula{
+			Homepage: "https://savannah.nongnu.org/projects/lzip",
+			Stable:   &Stable{URL: "https://download.savannah.nongnu.org/releases/lzip/lzip-1.24.tar.gz"},
+		},
+		expected: "https://git.savannah.nongnu.org/git/lzip.git",
+	},
+	{
+		input: &SourceFormula{
+			Homepage: "https://www.wireshark.org",
+			Stable:   &Stable{URL: "https://github.com/wireshark/wireshark/archive/refs/tags/v4.2.3.tar.gz"},
+		},
+		expected: "https://github.com/wireshark/wireshark.git",
+	},
+	{
+		input: &SourceFormula{
+			Homepage: "https://example.com/binary-tool",
+			Dependencies: &Dependencies{
+				Archives: []*Archive{
+					{URL: "https://downloads.example.com/binary-tool-darwin-arm64.tar.gz", Restriction: "macos and arm"},
+					{URL: "https://github.com/example/binary-tool/releases/download/v1.4.2/binary-tool-linux-amd64.tar.gz", Restriction: "linux"},
+				},
+			},
+		},
+		expected: "https://github.com/example/binary-tool.git",
+	},
+}
+
+func TestDeriveRepoURL(t *testing.T) {
+	for _, test := range deriveRepoURLTests {
+		repoURL := test.input.deriveRepoURL()
+		if repoURL != test.expected {
+			t.Errorf("expected: %s, got: %s", test.expected, repoURL)
+		}
+	}
+}
+
+func TestFromSourceFormulaPURL(t *testing.T) {
+	sf := &SourceFormula{
+		Name:    "wireshark",
+		Version: "4.2.3",
+		Stable:  &Stable{URL: "https://github.com/wireshark/wireshark/archive/refs/tags/v4.2.3.tar.gz"},
+	}
+	f := FromSourceFormula(sf, "", true)
+	if f.PURL != "pkg:brew/wireshark@4.2.3" {
+		t.Errorf("expected: pkg:brew/wireshark@4.2.3, got: %s", f.PURL)
+	}
+	if f.UpstreamPURL != "pkg:github/wireshark/wireshark@v4.2.3" {
+		t.Errorf("expected: pkg:github/wireshark/wireshark@v4.2.3, got: %s", f.UpstreamPURL)
+	}
+}
+
+func TestFromSourceFormulaUpstreamVCS(t *testing.T) {
+	sf := &SourceFormula{
+		Name:    "geckodriver",
+		Version: "0.34.0",
+		Stable:  &Stable{URL: "https://hg.mozilla.org/mozilla-central/archive/bc25087baba1.zip/testing/geckodriver/"},
+		Head:    &Head{URL: "https://hg.mozilla.org/mozilla-central/", Using: "hg"},
+	}
+	f := FromSourceFormula(sf, "", true)
+	expected := "pkg:generic/geckodriver@0.34.0?download_url=https:%2F%2Fhg.mozilla.org%2Fmozilla-central%2Farchive%2Fbc25087baba1.zip%2Ftesting%2Fgeckodriver%2F"
+	if f.UpstreamPURL != expected {
+		t.Errorf("expected: %s, got: %s", expected, f.UpstreamPURL)
+	}
+}
+
+var headVCSTests = []struct {
+	input    *Head
+	expected string
+}{
+	{&Head{URL: "https://github.com/example/foo.git"}, "git"},
+	{&Head{URL: "https://github.com/example/foo", Using: "git"}, "git"},
+	{&Head{URL: "https://hg.mozilla.org/mozilla-central/", Using: "hg"}, "hg"},
+	{&Head{URL: "https://svn.code.sf.net/p/spimsimulator/code/"}, "svn"},
+	{&Head{URL: "https://example.com/foo-head.tar.gz", Using: "homebrew_curl"}, ""},
+	{&Head{URL: "https://example.com/foo"}, ""},
+}
+
+func TestHeadVCS(t *testing.T) {
+	for _, test := range headVCSTests {
+		if vcs := test.input.VCS(); vcs != test.expected {
+			t.Errorf("expected: %s, got: %s", test.expected, vcs)
+		}
+	}
+}
@@ -7,10 +7,13 @@ type Stable struct {
 	// URL of the fomula's stable version.
 	URL string
 
+	// SHA256 checksum of the stable version's archive.
+	Checksum string
+
 	// Dependencies of the stable version.
 	Dependencies *Dependencies
 }
 
 func (s *Stable) String() string {
-	return fmt.Sprintf("{%s, %s}", s.URL, s.Dependencies)
+	return fmt.Sprintf("{%s, %s, %s}", s.URL, s.Checksum, s.Dependencies)
 }
@@ -0,0 +1,156 @@
+package writer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"main/miner/purl"
+	"main/miner/types"
+)
+
+// BOMOptions holds the options of a software bill of materials.
+type BOMOptions struct {
+	// Root is the name of the formula whose transitive dependencies are included in the BOM.
+	// If empty, all formulae are included.
+	Root string
+
+	// SerialNumber is the unique identifier of the BOM, e.g. "urn:uuid:<uuid>".
+	SerialNumber string
+
+	// Timestamp is the time the BOM has been created.
+	Timestamp time.Time
+
+	// ToolVersion is the version of the miner creating the BOM.
+	ToolVersion string
+
+	// FallbackLicense is the license the formulae without a license have been mined with, if any.
+	// It isn't the license of a formula, thus it is omitted from the BOM.
+	FallbackLicense string
+}
+
+// ToolName is the name of the miner as listed in the metadata of a BOM.
+const ToolName = "brew-metadata-miner"
+
+// Scopes of a dependency, ordered from the weakest to the strongest.
+const (
+	scopeExcluded = iota
+	scopeOptional
+	scopeRequired
+)
+
+// scopeNames holds the CycloneDX names of the scopes.
+var scopeNames = []string{"excluded", "optional", "required"}
+
+// dependencyScope returns the scope of the given dependency, derived from its types.
+// Build and test dependencies are excluded, optional and recommended dependencies are optional,
+// and all other dependencies are required.
+// A dependency with multiple types has the weakest scope of its types, e.g. an optional build dependency is excluded.
+func dependencyScope(dep *types.Dependency) int {
+	scope := scopeRequired
+	for _, t := range dep.DepType {
+		s := scopeRequired
+		switch t {
+		case "build", "test":
+			s = scopeExcluded
+		case "optional", "recommended":
+			s = scopeOptional
+		}
+		scope = min(scope, s)
+	}
+	return scope
+}
+
+// bomRef returns the reference of the formula within a BOM, which is its package URL.
+func bomRef(f *types.Formula) string {
+	if f.PURL != "" {
+		return f.PURL
+	}
+	return purl.Brew(f.Name, f.Version).String()
+}
+
+// bomGraph is the dependency graph of the formulae included in a BOM.
+type bomGraph struct {
+	// Root is the formula whose transitive dependencies are included, or nil if all formulae are included.
+	root *types.Formula
+
+	// Formulae holds the included formulae, excluding the root, sorted by their name.
+	formulae []*types.Formula
+
+	// Scopes holds the scope of each included formula, where the key is the name of the formula.
+	// If all formulae are included, formulae no other formula depends on have no scope.
+	scopes map[string]int
+
+	// All holds all formulae the BOM has been created from.
+	all map[string]*types.Formula
+}
+
+// newBOMGraph selects the formulae of a BOM from the given formulae.
+// If root is empty, all formulae are included, and the scope of a formula is the strongest scope of the
+// dependencies on it. Otherwise, the transitive dependencies of the root formula are included, and the scope of a
+// formula is the strongest scope of the paths from the root to it, where a path has the weakest scope of its dependencies.
+// Dependencies on formulae which don't exist are ignored.
+func newBOMGraph(formulae map[string]*types.Formula, root string) (*bomGraph, error) {
+	g := &bomGraph{scopes: make(map[string]int), all: formulae}
+
+	if root == "" {
+		for _, f := range formulae {
+			g.formulae = append(g.formulae, f)
+			for _, dep := range f.Dependencies {
+				if _, ok := formulae[dep.Name]; !ok {
+					continue
+				}
+				if s, ok := g.scopes[dep.Name]; !ok || dependencyScope(dep) > s {
+					g.scopes[dep.Name] = dependencyScope(dep)
+				}
+			}
+		}
+	} else {
+		var ok bool
+		if g.root, ok = formulae[root]; !ok {
+			return nil, fmt.Errorf("formula %s not found", root)
+		}
+
+		// The scopes are propagated until no scope is strengthened, which terminates as there are only three scopes.
+		g.scopes[root] = scopeRequired
+		queue := []string{root}
+		for len(queue) > 0 {
+			name := queue[0]
+			queue = queue[1:]
+			for _, dep := range formulae[name].Dependencies {
+				if _, ok := formulae[dep.Name]; !ok {
+					continue
+				}
+				scope := min(g.scopes[name], dependencyScope(dep))
+				if s, ok := g.scopes[dep.Name]; !ok || scope > s {
+					g.scopes[dep.Name] = scope
+					queue = append(queue, dep.Name)
+				}
+			}
+		}
+		for name := range g.scopes {
+			if name != root {
+				g.formulae = append(g.formulae, formulae[name])
+			}
+		}
+	}
+
+	sort.Slice(g.formulae, func(i, j int) bool {
+		return g.formulae[i].Name < g.formulae[j].Name
+	})
+	return g, nil
+}
+
+// dependencies returns the sorted unique names of the included formulae the given formula depends on.
+func (g *bomGraph) dependencies(f *types.Formula) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, dep := range f.Dependencies {
+		if _, ok := g.all[dep.Name]; ok && !seen[dep.Name] {
+			seen[dep.Name] = true
+			names = append(names, dep.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,254 @@
+package writer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"time"
+
+	"main/miner/license"
+	"main/miner/types"
+)
+
+// CycloneDX specification version and XML namespace of the BOMs written.
+const (
+	cycloneDXVersion   = "1.5"
+	cycloneDXNamespace = "http://cyclonedx.org/schema/bom/1.5"
+)
+
+// CycloneDX is a CycloneDX software bill of materials of formulae.
+type CycloneDX struct {
+	bom *cdxBOM
+}
+
+// cdxBOM is the root of a CycloneDX BOM.
+// The structs are annotated for both the JSON and the XML format of CycloneDX.
+type cdxBOM struct {
+	XMLName      xml.Name         `json:"-" xml:"bom"`
+	Namespace    string           `json:"-" xml:"xmlns,attr"`
+	BOMFormat    string           `json:"bomFormat" xml:"-"`
+	SpecVersion  string           `json:"specVersion" xml:"-"`
+	SerialNumber string           `json:"serialNumber,omitempty" xml:"serialNumber,attr,omitempty"`
+	Version      int              `json:"version" xml:"version,attr"`
+	Metadata     *cdxMetadata     `json:"metadata" xml:"metadata"`
+	Components   []*cdxComponent  `json:"components" xml:"components>component"`
+	Dependencies []*cdxDependency `json:"dependencies" xml:"dependencies>dependency"`
+}
+
+type cdxMetadata struct {
+	Timestamp string        `json:"timestamp" xml:"timestamp"`
+	Tools     *cdxTools     `json:"tools" xml:"tools"`
+	Component *cdxComponent `json:"component,omitempty" xml:"component,omitempty"`
+}
+
+type cdxTools struct {
+	Components []*cdxComponent `json:"components" xml:"components>component"`
+}
+
+type cdxComponent struct {
+	Type               string        `json:"type" xml:"type,attr"`
+	BOMRef             string        `json:"bom-ref,omitempty" xml:"bom-ref,attr,omitempty"`
+	Name               string        `json:"name" xml:"name"`
+	Version            string        `json:"version,omitempty" xml:"version,omitempty"`
+	Scope              string        `json:"scope,omitempty" xml:"scope,omitempty"`
+	Licenses           *cdxLicenses  `json:"licenses,omitempty" xml:"licenses,omitempty"`
+	PURL               string        `json:"purl,omitempty" xml:"purl,omitempty"`
+	Pedigree           *cdxPedigree  `json:"pedigree,omitempty" xml:"pedigree,omitempty"`
+	ExternalReferences cdxReferences `json:"externalReferences,omitempty" xml:"externalReferences,omitempty"`
+}
+
+// cdxLicenses is either a license expression or a list of licenses.
+// In JSON, both are formatted as an array of license choices.
+type cdxLicenses struct {
+	Expression string        `xml:"expression,omitempty"`
+	Licenses   []*cdxLicense `xml:"license"`
+}
+
+type cdxLicense struct {
+	Name string `json:"name" xml:"name"`
+}
+
+func (l *cdxLicenses) MarshalJSON() ([]byte, error) {
+	if l.Expression != "" {
+		return json.Marshal([]map[string]string{{"expression": l.Expression}})
+	}
+	choices := make([]map[string]*cdxLicense, 0, len(l.Licenses))
+	for _, license := range l.Licenses {
+		choices = append(choices, map[string]*cdxLicense{"license": license})
+	}
+	return json.Marshal(choices)
+}
+
+type cdxPedigree struct {
+	Ancestors []*cdxComponent `json:"ancestors" xml:"ancestors>component"`
+}
+
+type cdxExternalReference struct {
+	Type   string    `json:"type" xml:"type,attr"`
+	URL    string    `json:"url" xml:"url"`
+	Hashes cdxHashes `json:"hashes,omitempty" xml:"hashes,omitempty"`
+}
+
+// cdxReferences and cdxHashes are encoded as an XML element wrapping an element per item,
+// which is omitted if there are no items.
+type cdxReferences []*cdxExternalReference
+
+type cdxHashes []*cdxHash
+
+func (r cdxReferences) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeXMLList(e, start, "reference", r)
+}
+
+func (h cdxHashes) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return encodeXMLList(e, start, "hash", h)
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg" xml:"alg,attr"`
+	Content string `json:"content" xml:",chardata"`
+}
+
+// cdxDependency lists the references a component depends on.
+// In JSON, they are listed by dependsOn, and in XML by nested dependency elements.
+type cdxDependency struct {
+	Ref       string           `json:"ref" xml:"ref,attr"`
+	DependsOn []string         `json:"dependsOn" xml:"-"`
+	Nested    []*cdxDependency `json:"-" xml:"dependency"`
+}
+
+// NewCycloneDX creates a CycloneDX BOM of the given formulae.
+// Each formula is a component with its license, package URL and external references, and the dependencies
+// between the formulae are listed in the dependencies of the BOM.
+// If a root formula is given, the BOM describes the root formula and includes its transitive dependencies only.
+func NewCycloneDX(formulae map[string]*types.Formula, opts *BOMOptions) (*CycloneDX, error) {
+	g, err := newBOMGraph(formulae, opts.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	bom := &cdxBOM{
+		Namespace:    cycloneDXNamespace,
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  cycloneDXVersion,
+		SerialNumber: opts.SerialNumber,
+		Version:      1,
+		Metadata: &cdxMetadata{
+			Timestamp: opts.Timestamp.UTC().Format(time.RFC3339),
+			Tools: &cdxTools{Components: []*cdxComponent{
+				{Type: "application", Name: ToolName, Version: opts.ToolVersion},
+			}},
+		},
+		Components:   make([]*cdxComponent, 0, len(g.formulae)),
+		Dependencies: make([]*cdxDependency, 0, len(g.formulae)+1),
+	}
+
+	described := g.formulae
+	if g.root != nil {
+		bom.Metadata.Component = newCDXComponent(g.root, "", opts.FallbackLicense)
+		described = append([]*types.Formula{g.root}, described...)
+	}
+	for _, f := range g.formulae {
+		scope := ""
+		if s, ok := g.scopes[f.Name]; ok {
+			scope = scopeNames[s]
+		}
+		bom.Components = append(bom.Components, newCDXComponent(f, scope, opts.FallbackLicense))
+	}
+	for _, f := range described {
+		dep := &cdxDependency{Ref: bomRef(f), DependsOn: make([]string, 0)}
+		for _, name := range g.dependencies(f) {
+			ref := bomRef(formulae[name])
+			dep.DependsOn = append(dep.DependsOn, ref)
+			dep.Nested = append(dep.Nested, &cdxDependency{Ref: ref})
+		}
+		bom.Dependencies = append(bom.Dependencies, dep)
+	}
+
+	return &CycloneDX{bom: bom}, nil
+}
+
+// newCDXComponent creates the component of the given formula with the given scope.
+// The licenses of the component omit the given fallback license.
+func newCDXComponent(f *types.Formula, scope, fallbackLicense string) *cdxComponent {
+	c := &cdxComponent{
+		Type:     "application",
+		BOMRef:   bomRef(f),
+		Name:     f.Name,
+		Version:  f.Version,
+		Scope:    scope,
+		Licenses: newCDXLicenses(f.License, fallbackLicense),
+		PURL:     f.PURL,
+	}
+
+	if f.UpstreamPURL != "" {
+		c.Pedigree = &cdxPedigree{Ancestors: []*cdxComponent{
+			{Type: "library", Name: f.Name, Version: f.Version, PURL: f.UpstreamPURL},
+		}}
+	}
+
+	if f.Homepage != "" {
+		c.ExternalReferences = append(c.ExternalReferences, &cdxExternalReference{Type: "website", URL: f.Homepage})
+	}
+	if f.RepoURL != "" {
+		c.ExternalReferences = append(c.ExternalReferences, &cdxExternalReference{Type: "vcs", URL: f.RepoURL})
+	}
+	for _, a := range f.ArchiveURL {
+		ref := &cdxExternalReference{Type: "distribution", URL: a.URL}
+		if a.Checksum != "" {
+			ref.Hashes = cdxHashes{{Alg: "SHA-256", Content: a.Checksum}}
+		}
+		c.ExternalReferences = append(c.ExternalReferences, ref)
+	}
+	return c
+}
+
+// newCDXLicenses returns the licenses of a component with the given license.
+// If the license is an SPDX license expression of listed identifiers only, it is used as the expression.
+// Otherwise, the license is listed by its name. The fallback license is no license of the component, thus
+// no licenses are returned for it.
+func newCDXLicenses(s, fallbackLicense string) *cdxLicenses {
+	if s == "" || s == fallbackLicense {
+		return nil
+	}
+	if e, err := license.Parse(s); err == nil && e.Listed() {
+		return &cdxLicenses{Expression: e.String()}
+	}
+	return &cdxLicenses{Licenses: []*cdxLicense{{Name: s}}}
+}
+
+// encodeXMLList encodes the given items as elements with the given name wrapped by the given start element.
+func encodeXMLList[T any](e *xml.Encoder, start xml.StartElement, name string, items []T) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if err := e.EncodeElement(item, xml.StartElement{Name: xml.Name{Local: name}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// WriteJSON writes the BOM in the CycloneDX JSON format to the given writer.
+func (c *CycloneDX) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.bom)
+}
+
+// WriteXML writes the BOM in the CycloneDX XML format to the given writer.
+func (c *CycloneDX) WriteXML(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(c.bom); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
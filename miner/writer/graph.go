@@ -0,0 +1,289 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"main/miner/forge"
+	"main/miner/types"
+)
+
+// GraphOptions holds the options of a dependency graph.
+type GraphOptions struct {
+	// Roots holds the names of the formulae the graph is restricted to, together with their dependencies and dependents.
+	// If empty, all formulae are included.
+	Roots []string
+
+	// Depth is the maximum number of dependency edges between a root and the other included formulae.
+	// If not positive, all transitive dependencies and dependents of the roots are included.
+	Depth int
+}
+
+// Graph is the dependency graph of formulae.
+type Graph struct {
+	nodes []*graphNode
+	edges []*graphEdge
+}
+
+// graphNode is a formula of the graph.
+type graphNode struct {
+	name               string
+	license            string
+	repoHost           string
+	systemRequirements string
+}
+
+// Relations of the edges of the graph.
+const (
+	relationDependsOn     = "DEPENDS_ON"
+	relationConflictsWith = "CONFLICTS_WITH"
+)
+
+// graphEdge is a dependency or conflict between two formulae of the graph.
+// The type and restriction are set for dependencies, and the reason for conflicts.
+type graphEdge struct {
+	source      string
+	target      string
+	relation    string
+	depType     string
+	restriction string
+	reason      string
+}
+
+// NewGraph creates the dependency graph of the given formulae.
+// Each formula is a node with its license, the host of its repository and its system requirements,
+// and each dependency is an edge from the dependent formula to the dependency with its type and restriction.
+// Each conflict is an edge from the formula declaring it to the conflicting formula with its reason.
+// Dependencies on and conflicts with formulae which aren't included are omitted.
+func NewGraph(formulae map[string]*types.Formula, opts *GraphOptions) (*Graph, error) {
+	included := make(map[string]bool)
+	if len(opts.Roots) == 0 {
+		for name := range formulae {
+			included[name] = true
+		}
+	} else {
+		dependents := make(map[string][]string)
+		for _, f := range formulae {
+			for _, dep := range f.Dependencies {
+				dependents[dep.Name] = append(dependents[dep.Name], f.Name)
+			}
+		}
+		dependencies := func(name string) []string {
+			names := make([]string, 0, len(formulae[name].Dependencies))
+			for _, dep := range formulae[name].Dependencies {
+				names = append(names, dep.Name)
+			}
+			return names
+		}
+
+		for _, root := range opts.Roots {
+			if _, ok := formulae[root]; !ok {
+				return nil, fmt.Errorf("formula %s not found", root)
+			}
+		}
+		neighbourhood(included, formulae, opts.Roots, opts.Depth, dependencies)
+		neighbourhood(included, formulae, opts.Roots, opts.Depth, func(name string) []string {
+			return dependents[name]
+		})
+	}
+
+	g := &Graph{}
+	for name := range included {
+		f := formulae[name]
+		g.nodes = append(g.nodes, &graphNode{
+			name:               f.Name,
+			license:            f.License,
+			repoHost:           forge.Domain(f.RepoURL),
+			systemRequirements: f.SystemRequirement,
+		})
+		for _, dep := range sortedDependencies(f.Dependencies) {
+			if !included[dep.Name] {
+				continue
+			}
+			depType := strings.Join(dep.DepType, ", ")
+			if depType == "" {
+				depType = "runtime"
+			}
+			g.edges = append(g.edges, &graphEdge{source: f.Name, target: dep.Name, relation: relationDependsOn, depType: depType, restriction: dep.Restriction})
+		}
+		for _, c := range sortedConflicts(f.Conflicts) {
+			if !included[c.Name] {
+				continue
+			}
+			g.edges = append(g.edges, &graphEdge{source: f.Name, target: c.Name, relation: relationConflictsWith, reason: c.Reason})
+		}
+	}
+
+	sort.Slice(g.nodes, func(i, j int) bool {
+		return g.nodes[i].name < g.nodes[j].name
+	})
+	sort.SliceStable(g.edges, func(i, j int) bool {
+		return g.edges[i].source < g.edges[j].source
+	})
+	return g, nil
+}
+
+// neighbourhood adds the formulae within the given depth of the given roots to included, where the neighbours of
+// a formula are returned by next. If depth isn't positive, all transitively reachable formulae are added.
+func neighbourhood(included map[string]bool, formulae map[string]*types.Formula, roots []string, depth int, next func(string) []string) {
+	distance := make(map[string]int)
+	queue := make([]string, 0, len(roots))
+	for _, root := range roots {
+		distance[root] = 0
+		queue = append(queue, root)
+	}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		included[name] = true
+		if depth > 0 && distance[name] >= depth {
+			continue
+		}
+		for _, n := range next(name) {
+			if _, ok := formulae[n]; !ok {
+				continue
+			}
+			if _, ok := distance[n]; !ok {
+				distance[n] = distance[name] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+}
+
+type graphML struct {
+	XMLName   xml.Name        `xml:"graphml"`
+	Namespace string          `xml:"xmlns,attr"`
+	Keys      []*graphMLKey   `xml:"key"`
+	Graph     *graphMLContent `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLContent struct {
+	ID          string         `xml:"id,attr"`
+	EdgeDefault string         `xml:"edgedefault,attr"`
+	Nodes       []*graphMLNode `xml:"node"`
+	Edges       []*graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string         `xml:"id,attr"`
+	Data []*graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string         `xml:"id,attr"`
+	Source string         `xml:"source,attr"`
+	Target string         `xml:"target,attr"`
+	Data   []*graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph in the GraphML format to the given writer.
+func (g *Graph) WriteGraphML(w io.Writer) error {
+	doc := &graphML{
+		Namespace: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []*graphMLKey{
+			{ID: "license", For: "node", AttrName: "license", AttrType: "string"},
+			{ID: "repo_host", For: "node", AttrName: "repo_host", AttrType: "string"},
+			{ID: "system_requirements", For: "node", AttrName: "system_requirements", AttrType: "string"},
+			{ID: "relation", For: "edge", AttrName: "relation", AttrType: "string"},
+			{ID: "type", For: "edge", AttrName: "type", AttrType: "string"},
+			{ID: "restriction", For: "edge", AttrName: "restriction", AttrType: "string"},
+			{ID: "reason", For: "edge", AttrName: "reason", AttrType: "string"},
+		},
+		Graph: &graphMLContent{ID: "brew", EdgeDefault: "directed"},
+	}
+	for _, n := range g.nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, &graphMLNode{ID: n.name, Data: []*graphMLData{
+			{Key: "license", Value: n.license},
+			{Key: "repo_host", Value: n.repoHost},
+			{Key: "system_requirements", Value: n.systemRequirements},
+		}})
+	}
+	for i, e := range g.edges {
+		data := []*graphMLData{{Key: "relation", Value: e.relation}}
+		if e.relation == relationConflictsWith {
+			data = append(data, &graphMLData{Key: "reason", Value: e.reason})
+		} else {
+			data = append(data, &graphMLData{Key: "type", Value: e.depType}, &graphMLData{Key: "restriction", Value: e.restriction})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, &graphMLEdge{ID: fmt.Sprintf("e%d", i), Source: e.source, Target: e.target, Data: data})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteDOT writes the graph in the Graphviz DOT format to the given writer.
+// Conflicts are drawn as dashed edges.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph brew {")
+	for _, n := range g.nodes {
+		fmt.Fprintf(b, "  %s [license=%s, repo_host=%s, system_requirements=%s];\n",
+			dotQuote(n.name), dotQuote(n.license), dotQuote(n.repoHost), dotQuote(n.systemRequirements))
+	}
+	for _, e := range g.edges {
+		if e.relation == relationConflictsWith {
+			fmt.Fprintf(b, "  %s -> %s [relation=%s, reason=%s, style=dashed];\n",
+				dotQuote(e.source), dotQuote(e.target), dotQuote(e.relation), dotQuote(e.reason))
+			continue
+		}
+		fmt.Fprintf(b, "  %s -> %s [relation=%s, type=%s, restriction=%s];\n",
+			dotQuote(e.source), dotQuote(e.target), dotQuote(e.relation), dotQuote(e.depType), dotQuote(e.restriction))
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// dotQuote returns the given string as a quoted DOT identifier.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// WriteNeo4j writes the graph as CSV files for the bulk import of neo4j-admin.
+// The formulae are written to nodes with the label Formula, the dependencies to relationships of the type DEPENDS_ON,
+// and the conflicts to relationships of the type CONFLICTS_WITH.
+func (g *Graph) WriteNeo4j(nodes, relationships io.Writer) error {
+	n := csv.NewWriter(nodes)
+	n.Write([]string{"name:ID(Formula)", "license", "repo_host", "system_requirements", ":LABEL"})
+	for _, node := range g.nodes {
+		n.Write([]string{node.name, node.license, node.repoHost, node.systemRequirements, "Formula"})
+	}
+	n.Flush()
+	if err := n.Error(); err != nil {
+		return err
+	}
+
+	r := csv.NewWriter(relationships)
+	r.Write([]string{":START_ID(Formula)", ":END_ID(Formula)", ":TYPE", "type", "restriction", "reason"})
+	for _, e := range g.edges {
+		r.Write([]string{e.source, e.target, e.relation, e.depType, e.restriction, e.reason})
+	}
+	r.Flush()
+	return r.Error()
+}
@@ -0,0 +1,91 @@
+package writer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestFileName is the name of the manifest file written to the output directory of each run.
+const ManifestFileName = "manifest.json"
+
+// Manifest describes a single run of the miner and the output files it has written.
+type Manifest struct {
+	// ToolVersion is the version of the miner.
+	ToolVersion string `json:"tool_version"`
+
+	// ConfigHash is the SHA-256 hash of the application config.
+	ConfigHash string `json:"config_hash"`
+
+	// CoreRepoCommit is the SHA of the commit of the core repository, if it is a git repository.
+	CoreRepoCommit string `json:"core_repo_commit,omitempty"`
+
+	// StartedAt is the time the run has been started.
+	StartedAt time.Time `json:"started_at"`
+
+	// FinishedAt is the time the output files have been completed.
+	FinishedAt time.Time `json:"finished_at"`
+
+	// Partial is true if the formulae have only partially been read.
+	Partial bool `json:"partial"`
+
+	// Counts holds the number of formulae, dependencies and conflicts written.
+	Counts ManifestCounts `json:"counts"`
+
+	// Files holds the output files written, in the order they have been added.
+	Files []*ManifestFile `json:"files"`
+}
+
+// ManifestCounts holds the number of values written by a run.
+type ManifestCounts struct {
+	Formulae     int `json:"formulae"`
+	Dependencies int `json:"dependencies"`
+	Conflicts    int `json:"conflicts"`
+}
+
+// ManifestFile is an output file listed in the manifest.
+type ManifestFile struct {
+	// Name of the file, relative to the output directory of the run.
+	Name string `json:"name"`
+
+	// Size of the file in bytes.
+	Size int64 `json:"size"`
+
+	// SHA256 is the hex encoded SHA-256 checksum of the file's content.
+	SHA256 string `json:"sha256"`
+}
+
+// AddFile adds the output file at the given path to the manifest, along with its size and checksum.
+func (m *Manifest) AddFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+	m.Files = append(m.Files, &ManifestFile{
+		Name:   filepath.Base(path),
+		Size:   size,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+	return nil
+}
+
+// WriteManifest writes the given manifest to the manifest file within the specified outputDir,
+// replacing the manifest of a previous run.
+func WriteManifest(outputDir string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outputDir, ManifestFileName), append(data, '\n'), 0644)
+}
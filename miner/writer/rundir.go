@@ -0,0 +1,113 @@
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runDirPrefix is the prefix of the names of the run directories.
+const runDirPrefix = "run-"
+
+// runDirLayout is the layout of the timestamp of a run directory's name, which sorts the names chronologically.
+const runDirLayout = "20060102T150405Z"
+
+// NewRunDir creates a new run directory within the given outputDir, named after the given start time of the run.
+// If a run directory of the same name exists, a numeric suffix is appended to the name.
+// It returns the path of the created directory.
+func NewRunDir(outputDir string, start time.Time) (string, error) {
+	name := runDirPrefix + start.UTC().Format(runDirLayout)
+	path := filepath.Join(outputDir, name)
+	for i := 1; ; i++ {
+		err := os.Mkdir(path, 0755)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+		path = filepath.Join(outputDir, fmt.Sprintf("%s-%d", name, i))
+	}
+}
+
+// PruneRunDirs removes the oldest run directories within the given outputDir, such that at most keep are left.
+// Only directories named like the ones created by NewRunDir and containing a manifest are considered,
+// such that other files and directories within the outputDir are left untouched.
+// It returns the paths of the removed directories.
+func PruneRunDirs(outputDir string, keep int) ([]string, error) {
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && isRunDir(outputDir, entry.Name()) {
+			runs = append(runs, entry.Name())
+		}
+	}
+	if len(runs) <= keep {
+		return nil, nil
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runOrder(runs[i], runs[j])
+	})
+
+	removed := make([]string, 0, len(runs)-keep)
+	for _, name := range runs[:len(runs)-keep] {
+		path := filepath.Join(outputDir, name)
+		if err := os.RemoveAll(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// isRunDir returns true if the directory of the given name within the outputDir has been created by NewRunDir
+// and contains the manifest of a run.
+func isRunDir(outputDir, name string) bool {
+	if _, _, ok := splitRunName(name); !ok {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(outputDir, name, ManifestFileName))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// runOrder returns true if the run directory a has been created before b.
+// The names are ordered by their timestamp, followed by the numeric suffix of the name.
+func runOrder(a, b string) bool {
+	ta, sa, _ := splitRunName(a)
+	tb, sb, _ := splitRunName(b)
+	if ta != tb {
+		return ta < tb
+	}
+	return sa < sb
+}
+
+// splitRunName splits the name of a run directory into its timestamp and its numeric suffix,
+// which is zero if the name has none.
+// It returns false if the name is not formatted like the names created by NewRunDir.
+func splitRunName(name string) (string, int, bool) {
+	name, ok := strings.CutPrefix(name, runDirPrefix)
+	if !ok {
+		return "", 0, false
+	}
+	timestamp, suffix, hasSuffix := strings.Cut(name, "-")
+	if _, err := time.Parse(runDirLayout, timestamp); err != nil {
+		return "", 0, false
+	}
+	if !hasSuffix {
+		return timestamp, 0, true
+	}
+	n, err := strconv.Atoi(suffix)
+	if err != nil || n < 1 || strconv.Itoa(n) != suffix {
+		return "", 0, false
+	}
+	return timestamp, n, true
+}
@@ -0,0 +1,287 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"main/miner/license"
+	"main/miner/types"
+)
+
+// SPDX version, data license and identifier of the documents written.
+const (
+	spdxVersion     = "SPDX-2.3"
+	spdxDataLicense = "CC0-1.0"
+	spdxDocumentID  = "SPDXRef-DOCUMENT"
+	spdxNoAssertion = "NOASSERTION"
+)
+
+// SPDX is an SPDX document of formulae.
+type SPDX struct {
+	doc *spdxDocument
+}
+
+type spdxDocument struct {
+	SPDXVersion          string                  `json:"spdxVersion"`
+	DataLicense          string                  `json:"dataLicense"`
+	SPDXID               string                  `json:"SPDXID"`
+	Name                 string                  `json:"name"`
+	DocumentNamespace    string                  `json:"documentNamespace"`
+	CreationInfo         *spdxCreationInfo       `json:"creationInfo"`
+	Packages             []*spdxPackage          `json:"packages"`
+	Relationships        []*spdxRelationship     `json:"relationships"`
+	ExtractedLicenseInfo []*spdxExtractedLicense `json:"hasExtractedLicensingInfos,omitempty"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string             `json:"SPDXID"`
+	Name             string             `json:"name"`
+	Version          string             `json:"versionInfo,omitempty"`
+	DownloadLocation string             `json:"downloadLocation"`
+	FilesAnalyzed    bool               `json:"filesAnalyzed"`
+	Checksums        []*spdxChecksum    `json:"checksums,omitempty"`
+	Homepage         string             `json:"homepage,omitempty"`
+	LicenseConcluded string             `json:"licenseConcluded"`
+	LicenseDeclared  string             `json:"licenseDeclared"`
+	CopyrightText    string             `json:"copyrightText"`
+	ExternalRefs     []*spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	Category string `json:"referenceCategory"`
+	Type     string `json:"referenceType"`
+	Locator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+type spdxExtractedLicense struct {
+	LicenseID     string `json:"licenseId"`
+	ExtractedText string `json:"extractedText"`
+	Name          string `json:"name"`
+}
+
+// NewSPDX creates an SPDX document of the given formulae.
+// Each formula is a package with its declared license, download location, checksum and package URL.
+// The dependencies between the formulae are relationships derived from the dependency types: the dependent formula
+// DEPENDS_ON a runtime dependency, and build, test and optional dependencies are the BUILD_DEPENDENCY_OF, TEST_DEPENDENCY_OF
+// and OPTIONAL_DEPENDENCY_OF the dependent formula respectively.
+// If a root formula is given, the document describes the root formula and includes its transitive dependencies only.
+// Otherwise, it describes all formulae.
+func NewSPDX(formulae map[string]*types.Formula, opts *BOMOptions) (*SPDX, error) {
+	g, err := newBOMGraph(formulae, opts.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	name := "brew-formulae"
+	included := g.formulae
+	if g.root != nil {
+		name = "brew-" + g.root.Name
+		included = append([]*types.Formula{g.root}, included...)
+	}
+
+	doc := &spdxDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       spdxDataLicense,
+		SPDXID:            spdxDocumentID,
+		Name:              name,
+		DocumentNamespace: opts.SerialNumber,
+		CreationInfo: &spdxCreationInfo{
+			Created:  opts.Timestamp.UTC().Format(time.RFC3339),
+			Creators: []string{fmt.Sprintf("Tool: %s-%s", ToolName, opts.ToolVersion)},
+		},
+		Packages:      make([]*spdxPackage, 0, len(included)),
+		Relationships: make([]*spdxRelationship, 0),
+	}
+
+	ids := newSPDXIDs(included)
+	publicDomain := false
+	for _, f := range included {
+		pkg := newSPDXPackage(f, ids[f.Name], opts.FallbackLicense)
+		doc.Packages = append(doc.Packages, pkg)
+		publicDomain = publicDomain || strings.Contains(pkg.LicenseDeclared, license.PublicDomainRef)
+
+		if g.root == nil || f == g.root {
+			doc.Relationships = append(doc.Relationships, &spdxRelationship{Element: spdxDocumentID, Type: "DESCRIBES", Related: ids[f.Name]})
+		}
+	}
+	for _, f := range included {
+		doc.Relationships = append(doc.Relationships, spdxRelationships(f, ids)...)
+	}
+
+	if publicDomain {
+		doc.ExtractedLicenseInfo = []*spdxExtractedLicense{
+			{LicenseID: license.PublicDomainRef, ExtractedText: license.PublicDomain, Name: license.PublicDomain},
+		}
+	}
+	return &SPDX{doc: doc}, nil
+}
+
+// newSPDXIDs returns the SPDX identifiers of the given formulae, where the key is the name of the formula.
+// Characters of the name which aren't allowed in identifiers are replaced by hyphens, and a number is appended
+// to identifiers which would otherwise be ambiguous.
+func newSPDXIDs(formulae []*types.Formula) map[string]string {
+	ids := make(map[string]string, len(formulae))
+	used := make(map[string]bool, len(formulae))
+	for _, f := range formulae {
+		id := "SPDXRef-Package-" + strings.Map(func(r rune) rune {
+			if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+				return r
+			}
+			return '-'
+		}, f.Name)
+		unique := id
+		for i := 2; used[unique]; i++ {
+			unique = fmt.Sprintf("%s-%d", id, i)
+		}
+		used[unique] = true
+		ids[f.Name] = unique
+	}
+	return ids
+}
+
+// newSPDXPackage creates the package of the given formula with the given identifier.
+// The download location and checksum are those of the formula's first archive.
+// The given fallback license isn't declared as the license of the package.
+func newSPDXPackage(f *types.Formula, id, fallbackLicense string) *spdxPackage {
+	pkg := &spdxPackage{
+		SPDXID:           id,
+		Name:             f.Name,
+		Version:          f.Version,
+		DownloadLocation: spdxNoAssertion,
+		Homepage:         f.Homepage,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxLicense(f.License, fallbackLicense),
+		CopyrightText:    spdxNoAssertion,
+		ExternalRefs: []*spdxExternalRef{
+			{Category: "PACKAGE-MANAGER", Type: "purl", Locator: bomRef(f)},
+		},
+	}
+	if len(f.ArchiveURL) > 0 {
+		pkg.DownloadLocation = f.ArchiveURL[0].URL
+		if f.ArchiveURL[0].Checksum != "" {
+			pkg.Checksums = []*spdxChecksum{{Algorithm: "SHA256", Value: f.ArchiveURL[0].Checksum}}
+		}
+	}
+	return pkg
+}
+
+// spdxLicense returns the given license as an SPDX license expression.
+// If the license is the fallback license or can't be represented as an expression of identifiers
+// on the SPDX License List, NOASSERTION is returned.
+func spdxLicense(s, fallbackLicense string) string {
+	if s == "" || s == fallbackLicense {
+		return spdxNoAssertion
+	}
+	e, err := license.Parse(s)
+	if err != nil || !e.Listed() {
+		return spdxNoAssertion
+	}
+	return e.String()
+}
+
+// spdxRelationships returns the relationships between the given formula and its dependencies with the given identifiers.
+// A dependency with multiple types may result in multiple relationships.
+func spdxRelationships(f *types.Formula, ids map[string]string) []*spdxRelationship {
+	seen := make(map[spdxRelationship]bool)
+	relationships := make([]*spdxRelationship, 0)
+	add := func(r spdxRelationship) {
+		if !seen[r] {
+			seen[r] = true
+			relationships = append(relationships, &r)
+		}
+	}
+
+	for _, dep := range sortedDependencies(f.Dependencies) {
+		id, ok := ids[dep.Name]
+		if !ok {
+			continue
+		}
+		if len(dep.DepType) == 0 {
+			add(spdxRelationship{Element: ids[f.Name], Type: "DEPENDS_ON", Related: id})
+		}
+		for _, t := range dep.DepType {
+			switch t {
+			case "build":
+				add(spdxRelationship{Element: id, Type: "BUILD_DEPENDENCY_OF", Related: ids[f.Name]})
+			case "test":
+				add(spdxRelationship{Element: id, Type: "TEST_DEPENDENCY_OF", Related: ids[f.Name]})
+			case "optional", "recommended":
+				add(spdxRelationship{Element: id, Type: "OPTIONAL_DEPENDENCY_OF", Related: ids[f.Name]})
+			default:
+				add(spdxRelationship{Element: ids[f.Name], Type: "DEPENDS_ON", Related: id})
+			}
+		}
+	}
+	return relationships
+}
+
+// WriteJSON writes the document in the SPDX JSON format to the given writer.
+func (s *SPDX) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s.doc)
+}
+
+// WriteTagValue writes the document in the SPDX tag-value format to the given writer.
+func (s *SPDX) WriteTagValue(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	doc := s.doc
+
+	fmt.Fprintf(b, "SPDXVersion: %s\nDataLicense: %s\nSPDXID: %s\nDocumentName: %s\nDocumentNamespace: %s\n",
+		doc.SPDXVersion, doc.DataLicense, doc.SPDXID, doc.Name, doc.DocumentNamespace)
+	for _, creator := range doc.CreationInfo.Creators {
+		fmt.Fprintf(b, "Creator: %s\n", creator)
+	}
+	fmt.Fprintf(b, "Created: %s\n", doc.CreationInfo.Created)
+
+	for _, pkg := range doc.Packages {
+		fmt.Fprintf(b, "\nPackageName: %s\nSPDXID: %s\n", pkg.Name, pkg.SPDXID)
+		if pkg.Version != "" {
+			fmt.Fprintf(b, "PackageVersion: %s\n", pkg.Version)
+		}
+		fmt.Fprintf(b, "PackageDownloadLocation: %s\nFilesAnalyzed: %t\n", pkg.DownloadLocation, pkg.FilesAnalyzed)
+		for _, c := range pkg.Checksums {
+			fmt.Fprintf(b, "PackageChecksum: %s: %s\n", c.Algorithm, c.Value)
+		}
+		if pkg.Homepage != "" {
+			fmt.Fprintf(b, "PackageHomePage: %s\n", pkg.Homepage)
+		}
+		fmt.Fprintf(b, "PackageLicenseConcluded: %s\nPackageLicenseDeclared: %s\nPackageCopyrightText: %s\n",
+			pkg.LicenseConcluded, pkg.LicenseDeclared, pkg.CopyrightText)
+		for _, ref := range pkg.ExternalRefs {
+			fmt.Fprintf(b, "ExternalRef: %s %s %s\n", ref.Category, ref.Type, ref.Locator)
+		}
+	}
+
+	if len(doc.Relationships) > 0 {
+		fmt.Fprintln(b)
+	}
+	for _, r := range doc.Relationships {
+		fmt.Fprintf(b, "Relationship: %s %s %s\n", r.Element, r.Type, r.Related)
+	}
+
+	for _, l := range doc.ExtractedLicenseInfo {
+		fmt.Fprintf(b, "\nLicenseID: %s\nExtractedText: <text>%s</text>\nLicenseName: %s\n", l.LicenseID, l.ExtractedText, l.Name)
+	}
+	return b.Flush()
+}
@@ -0,0 +1,84 @@
+package writer
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+)
+
+// span is the location of a record within a spool file.
+type span struct {
+	offset int64
+	length int64
+}
+
+// spool buffers records in a temporary file, such that they can be read back sorted by their key
+// while only their locations are kept in memory.
+type spool struct {
+	file   *os.File
+	writer *bufio.Writer
+
+	// The number of bytes written so far.
+	offset int64
+
+	// The locations of the records, where the key is the key of the record.
+	// A record replaces any previous record with the same key.
+	index map[string]span
+}
+
+// newSpool creates a new spool file within the given directory.
+// The pattern of the file name is used as by os.CreateTemp.
+func newSpool(dir, pattern string) (*spool, error) {
+	file, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &spool{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		index:  make(map[string]span),
+	}, nil
+}
+
+// add adds the given record with the given key to the spool.
+func (s *spool) add(key string, record []byte) error {
+	n, err := s.writer.Write(record)
+	if err != nil {
+		return err
+	}
+	s.index[key] = span{offset: s.offset, length: int64(n)}
+	s.offset += int64(n)
+	return nil
+}
+
+// each calls fn with each record sorted by its key.
+func (s *spool) each(fn func(key string, record []byte) error) error {
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(s.index))
+	for key := range s.index {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		sp := s.index[key]
+		record := make([]byte, sp.length)
+		if _, err := s.file.ReadAt(record, sp.offset); err != nil && err != io.EOF {
+			return err
+		}
+		if err := fn(key, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remove closes and removes the spool file.
+func (s *spool) remove() error {
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
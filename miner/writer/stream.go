@@ -0,0 +1,346 @@
+package writer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"main/miner/types"
+)
+
+// Stream writes formulae to an output file as soon as they have been read, without retaining them.
+// The formulae are written sorted by their name, regardless of the order they have been read in.
+type Stream interface {
+	// Write writes the given formula.
+	Write(formula *types.Formula) error
+
+	// Close completes and closes the output file.
+	// If partial is true, the formulae have only partially been read.
+	// An existing output file of the same name is replaced.
+	Close(partial bool) error
+
+	// Abort discards the formulae written so far without writing the output file.
+	// An existing output file of the same name is kept.
+	Abort() error
+
+	// Path returns the path of the output file once the stream has been closed.
+	Path() string
+}
+
+// tsvRecord is the record of a formula spooled by a TSVStream.
+type tsvRecord struct {
+	// Line is the formatted package line.
+	Line string `json:"line"`
+
+	// Dependencies of the formula, sorted by their name and type.
+	Dependencies []*types.Dependency `json:"dependencies,omitempty"`
+
+	// Conflicts of the formula, sorted by their name.
+	Conflicts []*types.Conflict `json:"conflicts,omitempty"`
+}
+
+// TSVStream writes formulae to the TSV output file.
+// Package lines are formatted immediately and spooled to a temporary file along with the dependencies and conflicts.
+// The dependency and conflict lines require the license of another formula, thus they are formatted in a
+// second pass over the spool file on Close. Only the licenses of the formulae are kept in memory.
+type TSVStream struct {
+	outputDir string
+	spool     *spool
+
+	// The path of the output file, once the stream has been closed.
+	path string
+
+	// The licenses of the formulae written so far, where the key is the name of the formula.
+	licenses map[string]string
+}
+
+// NewTSVStream creates a new TSVStream writing to the specified outputDir.
+func NewTSVStream(outputDir string) (*TSVStream, error) {
+	spool, err := newSpool(outputDir, "deps-brew-*.spool")
+	if err != nil {
+		return nil, err
+	}
+	return &TSVStream{
+		outputDir: outputDir,
+		spool:     spool,
+		licenses:  make(map[string]string),
+	}, nil
+}
+
+// Write spools the package line, dependencies and conflicts of the given formula.
+func (s *TSVStream) Write(formula *types.Formula) error {
+	s.licenses[formula.Name] = formula.License
+
+	record, err := json.Marshal(&tsvRecord{
+		Line:         formula.FormatPackageLine(),
+		Dependencies: sortedDependencies(formula.Dependencies),
+		Conflicts:    sortedConflicts(formula.Conflicts),
+	})
+	if err != nil {
+		return err
+	}
+	return s.spool.add(formula.Name, record)
+}
+
+// Close writes the spooled lines to the output file and removes the spool file.
+// If partial is true, the output file is marked as partial and dependencies
+// which haven't been read are written without a license.
+// It returns an error if a dependency hasn't been read, unless partial is true.
+func (s *TSVStream) Close(partial bool) error {
+	defer s.spool.remove()
+
+	formattedDate := time.Now().Format("2006-01-02")
+	fileName := fmt.Sprintf("deps-brew-%s.tsv", formattedDate)
+	if partial {
+		fileName = fmt.Sprintf("deps-brew-%s.partial.tsv", formattedDate)
+	}
+
+	s.path = filepath.Join(s.outputDir, fileName)
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	err = s.spool.each(func(name string, data []byte) error {
+		var record tsvRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return err
+		}
+
+		// Write package line.
+		if _, err := writer.WriteString(record.Line); err != nil {
+			return err
+		}
+
+		// Write dependency lines.
+		for _, dep := range record.Dependencies {
+			license, ok := s.licenses[dep.Name]
+			if !ok && !partial {
+				return fmt.Errorf("dependency %s not found in formula %s", dep.Name, name)
+			}
+			f := &types.Formula{Name: dep.Name, License: license}
+			if _, err := writer.WriteString(f.FormatDependencyLine(dep)); err != nil {
+				return err
+			}
+		}
+
+		// Write conflict lines.
+		for _, c := range record.Conflicts {
+			// Conflicting formulae are not required to exist in the core repository.
+			f := &types.Formula{Name: c.Name, License: s.licenses[c.Name]}
+			if _, err := writer.WriteString(f.FormatConflictLine(c)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Abort removes the spool file without writing the output file.
+func (s *TSVStream) Abort() error {
+	return s.spool.remove()
+}
+
+// Path returns the path of the TSV output file once the stream has been closed.
+func (s *TSVStream) Path() string {
+	return s.path
+}
+
+// JSONStream writes formulae as a JSON object to the JSON output file, where the key is the name of the formula.
+// The encoded formulae are spooled to a temporary file until the stream is closed.
+type JSONStream struct {
+	outputDir string
+	spool     *spool
+
+	// The path of the output file, once the stream has been closed.
+	path string
+}
+
+// NewJSONStream creates a new JSONStream writing to the specified outputDir.
+func NewJSONStream(outputDir string) (*JSONStream, error) {
+	spool, err := newSpool(outputDir, "deps-brew-*.json.spool")
+	if err != nil {
+		return nil, err
+	}
+	return &JSONStream{outputDir: outputDir, spool: spool}, nil
+}
+
+// Write spools the given formula encoded as JSON.
+func (s *JSONStream) Write(formula *types.Formula) error {
+	sorted := *formula
+	sorted.Dependencies = sortedDependencies(formula.Dependencies)
+	sorted.Conflicts = sortedConflicts(formula.Conflicts)
+
+	value, err := json.MarshalIndent(&sorted, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	return s.spool.add(formula.Name, value)
+}
+
+// Close writes the spooled formulae as a JSON object to the output file and removes the spool file.
+func (s *JSONStream) Close(partial bool) error {
+	defer s.spool.remove()
+
+	formattedDate := time.Now().Format("2006-01-02")
+	s.path = filepath.Join(s.outputDir, fmt.Sprintf("deps-brew-%s.json", formattedDate))
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	count := 0
+	err = s.spool.each(func(name string, value []byte) error {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+
+		separator := ",\n"
+		if count == 0 {
+			separator = "{\n"
+		}
+		count++
+
+		_, err = fmt.Fprintf(writer, "%s  %s: %s", separator, key, value)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	closing := "\n}\n"
+	if count == 0 {
+		closing = "{}\n"
+	}
+	if _, err := writer.WriteString(closing); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Abort removes the spool file without writing the output file.
+func (s *JSONStream) Abort() error {
+	return s.spool.remove()
+}
+
+// Path returns the path of the JSON output file once the stream has been closed.
+func (s *JSONStream) Path() string {
+	return s.path
+}
+
+// ProvenanceStream writes the provenance of the formulae's values to the provenance output file.
+// The formatted lines are spooled to a temporary file until the stream is closed.
+type ProvenanceStream struct {
+	outputDir string
+	spool     *spool
+
+	// The path of the output file, once the stream has been closed.
+	path string
+}
+
+// NewProvenanceStream creates a new ProvenanceStream writing to the specified outputDir.
+func NewProvenanceStream(outputDir string) (*ProvenanceStream, error) {
+	spool, err := newSpool(outputDir, "provenance-brew-*.spool")
+	if err != nil {
+		return nil, err
+	}
+	return &ProvenanceStream{outputDir: outputDir, spool: spool}, nil
+}
+
+// Write spools the provenance of the given formula's values.
+func (s *ProvenanceStream) Write(formula *types.Formula) error {
+	var lines strings.Builder
+	for _, p := range formula.Provenance {
+		// Quote the values to escape quotes and tabs, and to keep multiple lines of the raw source text on a single line.
+		fmt.Fprintf(&lines, "%s\t%s\t%s\t%s\t%s\n", strconv.Quote(formula.Name), strconv.Quote(p.Field), strconv.Quote(p.Value),
+			strconv.Quote(p.Source.String()), strconv.Quote(p.Source.Raw))
+	}
+	return s.spool.add(formula.Name, []byte(lines.String()))
+}
+
+// Close writes the spooled lines to the output file and removes the spool file.
+func (s *ProvenanceStream) Close(partial bool) error {
+	defer s.spool.remove()
+
+	formattedDate := time.Now().Format("2006-01-02")
+	s.path = filepath.Join(s.outputDir, fmt.Sprintf("provenance-brew-%s.tsv", formattedDate))
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	err = s.spool.each(func(_ string, lines []byte) error {
+		_, err := writer.Write(lines)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Abort removes the spool file without writing the output file.
+func (s *ProvenanceStream) Abort() error {
+	return s.spool.remove()
+}
+
+// Path returns the path of the provenance output file once the stream has been closed.
+func (s *ProvenanceStream) Path() string {
+	return s.path
+}
+
+// writeAll writes the given formulae to the given stream and closes it.
+// If a formula can't be written, the stream is aborted.
+func writeAll(s Stream, formulae map[string]*types.Formula, partial bool) error {
+	for _, formula := range formulae {
+		if err := s.Write(formula); err != nil {
+			s.Abort()
+			return err
+		}
+	}
+	return s.Close(partial)
+}
+
+// sortedDependencies returns a copy of the given dependencies sorted by their name and type.
+func sortedDependencies(deps []*types.Dependency) []*types.Dependency {
+	sorted := slices.Clone(deps)
+	types.SortDependencies(sorted)
+	return sorted
+}
+
+// sortedConflicts returns a copy of the given conflicts sorted by their name.
+func sortedConflicts(conflicts []*types.Conflict) []*types.Conflict {
+	sorted := slices.Clone(conflicts)
+	slices.SortStableFunc(sorted, func(a, b *types.Conflict) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return sorted
+}
@@ -3,58 +3,123 @@ package writer
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"main/miner/coverage"
+	"main/miner/parser"
 	"main/miner/types"
 )
 
 // WriteFormulae writes the given formulae to the specified outputDir.
-func WriteFormulae(outputDir string, formulae map[string]*types.Formula) error {
-	currentTime := time.Now()
-	formattedDate := currentTime.Format("2006-01-02")
+// If partial is true, the formulae have only partially been read. In this case, the output file is
+// marked as partial and dependencies which haven't been read are written without a license.
+func WriteFormulae(outputDir string, formulae map[string]*types.Formula, partial bool) error {
+	s, err := NewTSVStream(outputDir)
+	if err != nil {
+		return err
+	}
+	return writeAll(s, formulae, partial)
+}
 
-	fileName := fmt.Sprintf("deps-brew-%s.tsv", formattedDate)
+// WriteJSON writes the given formulae as a JSON object to the specified outputDir,
+// where the key is the name of the formula. The values of custom fields are included in the "extra" object of a formula.
+func WriteJSON(outputDir string, formulae map[string]*types.Formula) error {
+	s, err := NewJSONStream(outputDir)
+	if err != nil {
+		return err
+	}
+	return writeAll(s, formulae, false)
+}
 
-	path := filepath.Join(outputDir, fileName)
-	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// WriteProvenance writes the provenance of the given formulae's values to the specified outputDir.
+// Each line holds the formula, the field, the value, the source location and the raw source text:
+// `"<name>"  "<field>"  "<value>"  "<path>:<line>-<end_line>"  "<raw>"`
+func WriteProvenance(outputDir string, formulae map[string]*types.Formula) error {
+	s, err := NewProvenanceStream(outputDir)
 	if err != nil {
 		return err
 	}
-	// Close file on function exit and check its' returned error.
-	defer func() error {
-		if err := file.Close(); err != nil {
+	return writeAll(s, formulae, false)
+}
+
+// Explain writes each value extracted for the given formula next to the numbered source lines it came from.
+func Explain(w io.Writer, formula *types.Formula) error {
+	if _, err := fmt.Fprintf(w, "%s\n", formula.Name); err != nil {
+		return err
+	}
+
+	for _, p := range formula.Provenance {
+		if _, err := fmt.Fprintf(w, "\n%s: %s\n  %s\n", p.Field, p.Value, p.Source); err != nil {
 			return err
 		}
-		return nil
-	}()
+		for i, line := range strings.Split(p.Source.Raw, "\n") {
+			if _, err := fmt.Fprintf(w, "  %5d | %s\n", p.Source.Line+i, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteCoverage writes the given coverage report to the specified outputDir and returns the path of the written file.
+// The report lists the number of lines per class followed by the unknown top-level constructs
+// and the archive hosts which couldn't be mapped to a repository, the most frequent ones first.
+func WriteCoverage(outputDir string, report *coverage.Report) (string, error) {
+	formattedDate := time.Now().Format("2006-01-02")
+	path := filepath.Join(outputDir, fmt.Sprintf("coverage-brew-%s.txt", formattedDate))
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
 
 	writer := bufio.NewWriter(file)
-	defer writer.Flush()
 
-	for _, formula := range formulae {
-		// Write package line.
-		line := formula.FormatPackageLine()
-		_, err := writer.WriteString(line)
-		if err != nil {
-			return err
+	unparsed := report.Unparsed()
+	fmt.Fprintf(writer, "Files: %d (%d unparsed)\n", report.Files(), len(unparsed))
+
+	total := 0
+	classes := []parser.LineClass{parser.Consumed, parser.Ignored, parser.Unknown}
+	for _, c := range classes {
+		total += report.Lines(c)
+	}
+	for _, c := range classes {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(report.Lines(c)) / float64(total)
 		}
+		fmt.Fprintf(writer, "Lines %s: %d (%.1f%%)\n", c, report.Lines(c), share)
+	}
 
-		// Write dependency lines.
-		for _, dep := range formula.Dependencies {
-			f := formulae[dep.Name]
-			if f == nil {
-				panic(fmt.Sprintf("Dependency %s not found in formula %s", dep.Name, formula.Name))
-			}
+	fmt.Fprintf(writer, "\nUnknown constructs:\n")
+	for _, c := range report.Constructs() {
+		fmt.Fprintf(writer, "%8d\t%s\t%s\n", c.Count, c.Name, strings.Join(c.Examples, ", "))
+	}
 
-			line := f.FormatDependencyLine(dep)
-			_, err := writer.WriteString(line)
-			if err != nil {
-				return err
+	if hosts := report.UnmappedHosts(); len(hosts) > 0 {
+		fmt.Fprintf(writer, "\nUnmapped archive hosts:\n")
+		for _, h := range hosts {
+			domain := h.Domain
+			if h.Registry {
+				domain += " (package registry)"
 			}
+			fmt.Fprintf(writer, "%8d\t%s\t%s\n", h.Count, domain, strings.Join(h.Examples, ", "))
 		}
 	}
-	return nil
 
+	if len(unparsed) > 0 {
+		fmt.Fprintf(writer, "\nUnparsed files:\n")
+		for _, u := range unparsed {
+			fmt.Fprintf(writer, "%s\n", u)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return "", err
+	}
+	return path, file.Close()
 }
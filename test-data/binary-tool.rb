@@ -0,0 +1,32 @@
+class BinaryTool < Formula
+  desc "Command-line tool distributed as prebuilt binaries"
+  homepage "https://github.com/example/binary-tool"
+  version "1.4.2"
+  license "Apache-2.0"
+
+  on_macos do
+    on_arm do
+      url "https://github.com/example/binary-tool/releases/download/v#{version}/binary-tool-darwin-arm64.tar.gz"
+      sha256 "3f1b4d0c7e7c0a4b4a4f7f2b9c1a8d5e6f0e2d3c4b5a69788796a5b4c3d2e1f0"
+    end
+    on_intel do
+      url "https://github.com/example/binary-tool/releases/download/v#{version}/binary-tool-darwin-amd64.tar.gz"
+      sha256 "0f1e2d3c4b5a69788796a5b4c3d2e1f03f1b4d0c7e7c0a4b4a4f7f2b9c1a8d5e"
+    end
+  end
+
+  on_linux do
+    url "https://github.com/example/binary-tool/releases/download/v#{version}/binary-tool-linux-amd64.tar.gz"
+    sha256 "9c1a8d5e6f0e2d3c4b5a69788796a5b4c3d2e1f03f1b4d0c7e7c0a4b4a4f7f2b"
+
+    depends_on "glibc"
+  end
+
+  def install
+    bin.install "binary-tool"
+  end
+
+  test do
+    assert_match version.to_s, shell_output("#{bin}/binary-tool --version")
+  end
+end
@@ -0,0 +1,33 @@
+class CpuTool < Formula
+  desc "Command-line tool distributed as prebuilt binaries per CPU architecture"
+  homepage "https://github.com/example/cpu-tool"
+  version "2.0.1"
+  license "MIT"
+
+  on_macos do
+    if Hardware::CPU.arm?
+      url "https://github.com/example/cpu-tool/releases/download/v#{version}/cpu-tool-darwin-arm64.tar.gz"
+      sha256 "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+    else
+      url "https://github.com/example/cpu-tool/releases/download/v#{version}/cpu-tool-darwin-amd64.tar.gz"
+      sha256 "0918273645a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b"
+    end
+  end
+
+  on_linux do
+    if Hardware::CPU.intel?
+      url "https://github.com/example/cpu-tool/releases/download/v#{version}/cpu-tool-linux-amd64.tar.gz"
+      sha256 "f0e1d2c3b4a5968778695a4b3c2d1e0ff0e1d2c3b4a5968778695a4b3c2d1e0f"
+    end
+
+    depends_on "glibc"
+  end
+
+  def install
+    bin.install "cpu-tool"
+  end
+
+  test do
+    assert_match version.to_s, shell_output("#{bin}/cpu-tool --version")
+  end
+end